@@ -2,8 +2,10 @@ package main
 
 import (
 	"github.com/KQLXK/Family-Finance-System/model"
+	"github.com/KQLXK/Family-Finance-System/service"
 	"gorm.io/gorm"
 	"log"
+	"time"
 
 	"github.com/KQLXK/Family-Finance-System/database"
 )
@@ -28,6 +30,14 @@ func main() {
 
 	log.Println("Database migration completed successfully")
 
+	// 定时为到期的分期和周期交易生成交易、将到期的计划交易转为正常交易，启动时先补做停机期间错过的部分
+	service.NewScheduler().
+		Every("分期交易", time.Hour, service.NewInstallmentService().PostDueInstallments).
+		Every("周期交易", time.Hour, service.NewRecurringService().RunDueTemplates).
+		Every("计划交易", 10*time.Minute, service.NewTransactionService().PromotePlannedTransactions).
+		Every("账单提醒", time.Hour, service.NewBillService().SendDueReminders).
+		Start()
+
 	//设置路由
 	r := SetupRouter()
 
@@ -45,5 +55,31 @@ func AutoMigrate(db *gorm.DB) error {
 		&model.Tag{},
 		&model.Transaction{},
 		&model.TransactionTag{},
+		&model.TransactionSplit{},
+		&model.SharedExpense{},
+		&model.ExpenseShare{},
+		&model.Settlement{},
+		&model.Debt{},
+		&model.DebtRepayment{},
+		&model.StatementPayment{},
+		&model.InstallmentPlan{},
+		&model.Installment{},
+		&model.Budget{},
+		&model.BudgetAllocation{},
+		&model.BudgetMove{},
+		&model.SavingsGoal{},
+		&model.GoalContribution{},
+		&model.SpendingLimit{},
+		&model.RecurringTemplate{},
+		&model.RecurringOccurrence{},
+		&model.Bill{},
+		&model.NotificationChannel{},
+		&model.Notification{},
+		&model.PasswordResetToken{},
+		&model.Invitation{},
+		&model.APIToken{},
+		&model.AuditLog{},
+		&model.Account{},
+		&model.ExchangeRate{},
 	)
 }
@@ -2,6 +2,8 @@ package main
 
 import (
 	"github.com/KQLXK/Family-Finance-System/handler"
+	"github.com/KQLXK/Family-Finance-System/middleware"
+	"github.com/KQLXK/Family-Finance-System/model"
 	"github.com/gin-gonic/gin"
 )
 
@@ -14,73 +16,288 @@ func SetupRouter() *gin.Engine {
 	categoryHandler := handler.NewCategoryHandler()
 	transactionHandler := handler.NewTransactionHandler()
 	tagHandler := handler.NewTagHandler()
+	authHandler := handler.NewAuthHandler()
+	invitationHandler := handler.NewInvitationHandler()
+	apiTokenHandler := handler.NewAPITokenHandler()
+	auditHandler := handler.NewAuditHandler()
+	accountHandler := handler.NewAccountHandler()
+	exchangeRateHandler := handler.NewExchangeRateHandler()
+	sharedExpenseHandler := handler.NewSharedExpenseHandler()
+	debtHandler := handler.NewDebtHandler()
+	statementHandler := handler.NewStatementHandler()
+	installmentHandler := handler.NewInstallmentHandler()
+	budgetHandler := handler.NewBudgetHandler()
+	envelopeHandler := handler.NewEnvelopeHandler()
+	savingsGoalHandler := handler.NewSavingsGoalHandler()
+	spendingLimitHandler := handler.NewSpendingLimitHandler()
+	recurringHandler := handler.NewRecurringHandler()
+	cashFlowHandler := handler.NewCashFlowHandler()
+	billHandler := handler.NewBillHandler()
+	notificationHandler := handler.NewNotificationHandler()
+
+	// 除注册、登录、兑换邀请码和重置密码外，/api 路由均需要登录
+	authRequired := middleware.Auth()
+
+	// 认证相关路由
+	authGroup := r.Group("/api/auth")
+	{
+		authGroup.POST("/register", authHandler.Register)
+		authGroup.POST("/login", authHandler.Login)
+		authGroup.POST("/join", invitationHandler.RedeemInvitation)
+		authGroup.POST("/password/reset", authHandler.ResetPassword)
+		// 修改密码只允许登录会话，个人访问令牌由角色校验拒绝
+		authGroup.PUT("/password", authRequired, middleware.Read(), authHandler.ChangePassword)
+	}
+
+	// 权限策略：viewer 只读，member 可记账（仅限自己的交易），admin 管理家庭、成员、分类和标签
+	read := middleware.Read()
+	write := middleware.Write()
+	admin := middleware.Admin()
+	owner := middleware.TransactionOwner()
+
+	// 个人访问令牌权限范围，仅对交易和报表路由开放
+	txRead := middleware.RequireScope(model.ScopeTransactionsRead)
+	txWrite := middleware.RequireScope(model.ScopeTransactionsWrite)
+	reports := middleware.RequireScope(model.ScopeReportsRead)
 
 	// 家庭相关路由
-	familyGroup := r.Group("/api/families")
+	familyGroup := r.Group("/api/families", authRequired, middleware.FamilyScope())
 	{
-		familyGroup.POST("", familyHandler.CreateFamily)
-		familyGroup.GET("", familyHandler.GetAllFamilies)
+		familyGroup.POST("", admin, familyHandler.CreateFamily)
+		familyGroup.GET("", read, familyHandler.GetAllFamilies)
 
-		familyGroup.POST("/:id/members", memberHandler.CreateMember)
-		familyGroup.GET("/:id/members", memberHandler.GetMembersByFamilyID)
-		familyGroup.GET("/:id/members/active", memberHandler.GetActiveMembersByFamilyID)
+		familyGroup.POST("/:id/members", admin, memberHandler.CreateMember)
+		familyGroup.GET("/:id/members", read, memberHandler.GetMembersByFamilyID)
+		familyGroup.GET("/:id/members/active", read, memberHandler.GetActiveMembersByFamilyID)
 
-		familyGroup.GET("/:id", familyHandler.GetFamilyByID)
-		familyGroup.PUT("/:id", familyHandler.UpdateFamily)
-		familyGroup.DELETE("/:id", familyHandler.DeleteFamily)
+		familyGroup.GET("/:id", read, familyHandler.GetFamilyByID)
+		familyGroup.PUT("/:id", admin, familyHandler.UpdateFamily)
+		familyGroup.DELETE("/:id", admin, familyHandler.DeleteFamily)
+		familyGroup.PUT("/:id/approval", admin, familyHandler.UpdateApprovalThreshold)
+		familyGroup.PUT("/:id/currency", admin, familyHandler.UpdateBaseCurrency)
 
 		// 家庭交易相关路由
-		familyGroup.POST("/:id/transactions", transactionHandler.CreateTransaction)
-		familyGroup.GET("/:id/transactions", transactionHandler.GetTransactionsByFamilyID)
-		familyGroup.GET("/:id/transactions/time-range", transactionHandler.GetTransactionsByTimeRange)
-		familyGroup.GET("/:id/transactions/summary/category", transactionHandler.GetTransactionSummaryByCategory)
-		familyGroup.GET("/:id/transactions/summary/time", transactionHandler.GetTransactionSummaryByTime)
+		familyGroup.POST("/:id/transactions", txWrite, write, transactionHandler.CreateTransaction)
+		familyGroup.GET("/:id/transactions", txRead, read, transactionHandler.GetTransactionsByFamilyID)
+		familyGroup.GET("/:id/transactions/time-range", txRead, read, transactionHandler.GetTransactionsByTimeRange)
+		familyGroup.GET("/:id/transactions/pending", txRead, read, transactionHandler.GetPendingTransactions)
+		familyGroup.GET("/:id/transactions/planned", txRead, read, transactionHandler.GetPlannedTransactions)
+		familyGroup.GET("/:id/cashflow", reports, read, cashFlowHandler.GetProjection)
+		familyGroup.GET("/:id/transactions/summary/category", reports, read, transactionHandler.GetTransactionSummaryByCategory)
+		familyGroup.GET("/:id/transactions/summary/time", reports, read, transactionHandler.GetTransactionSummaryByTime)
 
 		// 家庭标签相关路由
-		familyGroup.POST("/:id/tags", tagHandler.CreateTag)
-		familyGroup.GET("/:id/tags", tagHandler.GetTagsByFamilyID)
-		familyGroup.GET("/:id/tags/type", tagHandler.GetTagsByType)
+		familyGroup.POST("/:id/tags", admin, tagHandler.CreateTag)
+		familyGroup.GET("/:id/tags", read, tagHandler.GetTagsByFamilyID)
+		familyGroup.GET("/:id/tags/type", read, tagHandler.GetTagsByType)
+
+		// 家庭邀请码相关路由
+		familyGroup.POST("/:id/invitations", admin, invitationHandler.CreateInvitation)
+		familyGroup.GET("/:id/invitations", admin, invitationHandler.GetInvitationsByFamilyID)
+		familyGroup.DELETE("/:id/invitations/:invitationId", admin, invitationHandler.RevokeInvitation)
+
+		// 家庭账户相关路由
+		familyGroup.POST("/:id/accounts", admin, accountHandler.CreateAccount)
+		familyGroup.GET("/:id/accounts", txRead, read, accountHandler.GetAccountsByFamilyID)
+		familyGroup.POST("/:id/accounts/migrate", admin, accountHandler.MigratePaymentMethods)
+		familyGroup.GET("/:id/cards/upcoming", txRead, read, statementHandler.GetUpcomingDues)
+		familyGroup.POST("/:id/installments", txWrite, write, installmentHandler.CreatePlan)
+		familyGroup.GET("/:id/installments", txRead, read, installmentHandler.GetPlans)
+		familyGroup.POST("/:id/recurring", txWrite, write, recurringHandler.CreateTemplate)
+		familyGroup.GET("/:id/recurring", txRead, read, recurringHandler.GetTemplatesByFamilyID)
+		familyGroup.POST("/:id/budgets", admin, budgetHandler.CreateBudget)
+		familyGroup.GET("/:id/budgets", read, budgetHandler.GetBudgetsByFamilyID)
+		familyGroup.GET("/:id/budgets/progress", reports, read, budgetHandler.GetFamilyBudgetProgress)
+		familyGroup.GET("/:id/envelopes", reports, read, envelopeHandler.GetFamilyEnvelopes)
+		familyGroup.GET("/:id/envelopes/moves", read, envelopeHandler.GetMoves)
+		familyGroup.POST("/:id/envelopes/moves", admin, envelopeHandler.MoveBetweenEnvelopes)
+
+		// 家庭储蓄目标相关路由
+		familyGroup.POST("/:id/goals", write, savingsGoalHandler.CreateGoal)
+		familyGroup.GET("/:id/goals", read, savingsGoalHandler.GetGoalsByFamilyID)
+
+		// 家庭成员消费限额相关路由
+		familyGroup.POST("/:id/limits", admin, spendingLimitHandler.CreateLimit)
+		familyGroup.GET("/:id/limits", read, spendingLimitHandler.GetLimitsByFamilyID)
+		familyGroup.GET("/:id/allowances", read, spendingLimitHandler.GetAllowances)
+
+		// 家庭汇率相关路由
+		familyGroup.GET("/:id/exchange-rates", read, exchangeRateHandler.GetExchangeRates)
+		familyGroup.POST("/:id/exchange-rates", admin, exchangeRateHandler.SaveExchangeRate)
+		familyGroup.POST("/:id/exchange-rates/import", admin, exchangeRateHandler.ImportExchangeRates)
+		familyGroup.DELETE("/:id/exchange-rates/:rateId", admin, exchangeRateHandler.DeleteExchangeRate)
+
+		// 家庭共同支出结算相关路由
+		familyGroup.GET("/:id/shared/balances", reports, read, sharedExpenseHandler.GetBalances)
+		familyGroup.GET("/:id/shared/settlement-plan", reports, read, sharedExpenseHandler.GetSettlementPlan)
+		familyGroup.GET("/:id/settlements", txRead, read, sharedExpenseHandler.GetSettlements)
+		familyGroup.POST("/:id/settlements", txWrite, write, sharedExpenseHandler.RecordSettlements)
+
+		// 家庭借贷相关路由
+		familyGroup.POST("/:id/debts", write, debtHandler.CreateDebt)
+		familyGroup.GET("/:id/debts", read, debtHandler.GetDebts)
+		familyGroup.GET("/:id/debts/overdue", read, debtHandler.GetOverdueDebts)
+
+		// 家庭账单相关路由
+		familyGroup.POST("/:id/bills", write, billHandler.CreateBill)
+		familyGroup.GET("/:id/bills/upcoming", read, billHandler.GetUpcomingBills)
+		familyGroup.GET("/:id/bills/overdue", read, billHandler.GetOverdueBills)
+		familyGroup.GET("/:id/bills/paid", read, billHandler.GetPaidBills)
+
+		// 家庭通知相关路由
+		familyGroup.POST("/:id/notification-channels", admin, notificationHandler.CreateChannel)
+		familyGroup.GET("/:id/notification-channels", admin, notificationHandler.GetChannels)
+		familyGroup.GET("/:id/notifications", read, notificationHandler.GetNotifications)
+
+		// 家庭审计日志
+		familyGroup.GET("/:id/audit", admin, auditHandler.GetAuditLogs)
 	}
 
 	// 成员相关路由（独立于家庭）
-	memberGroup := r.Group("/api/members")
+	memberGroup := r.Group("/api/members", authRequired)
+	{
+		memberGroup.GET("", read, memberHandler.GetAllMembers)
+		memberGroup.GET("/:id", read, memberHandler.GetMemberByID)
+		memberGroup.PUT("/:id", middleware.AdminOrSelf(), memberHandler.UpdateMember)
+		memberGroup.DELETE("/:id", admin, memberHandler.DeleteMember)
+		memberGroup.PUT("/:id/role", admin, memberHandler.ChangeMemberRole)
+		memberGroup.PUT("/:id/approval", admin, memberHandler.SetRequireApproval)
+		memberGroup.POST("/:id/password-reset", admin, authHandler.CreatePasswordResetToken)
+	}
+
+	categoryGroup := r.Group("/api/categories", authRequired)
+	{
+		categoryGroup.POST("", admin, categoryHandler.CreateCategory)
+		categoryGroup.GET("", read, categoryHandler.GetAllCategories)
+		categoryGroup.GET("/:id", read, categoryHandler.GetCategoryByID)
+		categoryGroup.PUT("/:id", admin, categoryHandler.UpdateCategory)
+		categoryGroup.DELETE("/:id", admin, categoryHandler.DeleteCategory)
+		categoryGroup.GET("/:id/path", read, categoryHandler.GetFullCategoryPath)
+		categoryGroup.GET("/type/list", read, categoryHandler.GetCategoriesByType)
+		categoryGroup.GET("/type/tree", read, categoryHandler.GetCategoryTreeByType)
+		categoryGroup.GET("/parent/children", read, categoryHandler.GetCategoriesByParentID)
+	}
+
+	transactionGroup := r.Group("/api/transactions", authRequired)
+	{
+		transactionGroup.GET("/:id", txRead, read, transactionHandler.GetTransactionByID)
+		transactionGroup.PUT("/:id", txWrite, write, owner, transactionHandler.UpdateTransaction)
+		transactionGroup.DELETE("/:id", txWrite, write, owner, transactionHandler.DeleteTransaction)
+		transactionGroup.POST("/:id/tags", txWrite, write, owner, transactionHandler.AddTagToTransaction)
+		transactionGroup.DELETE("/:id/tags/:tagId", txWrite, write, owner, transactionHandler.RemoveTagFromTransaction)
+		transactionGroup.GET("/:id/refunds", txRead, read, transactionHandler.GetRefunds)
+		transactionGroup.GET("/:id/shares", txRead, read, sharedExpenseHandler.GetSharedExpense)
+		transactionGroup.PUT("/:id/shares", txWrite, write, owner, sharedExpenseHandler.ShareTransaction)
+		transactionGroup.DELETE("/:id/shares", txWrite, write, owner, sharedExpenseHandler.RemoveSharing)
+		transactionGroup.POST("/:id/approve", admin, transactionHandler.ApproveTransaction)
+		transactionGroup.POST("/:id/reject", admin, transactionHandler.RejectTransaction)
+	}
+
+	// 账户相关路由（独立于家庭）
+	accountGroup := r.Group("/api/accounts", authRequired)
+	{
+		accountGroup.GET("/:id", txRead, read, accountHandler.GetAccountByID)
+		accountGroup.PUT("/:id", admin, accountHandler.UpdateAccount)
+		accountGroup.DELETE("/:id", admin, accountHandler.DeleteAccount)
+		accountGroup.GET("/:id/balance", reports, read, accountHandler.GetAccountBalance)
+		accountGroup.GET("/:id/balance/history", reports, read, accountHandler.GetAccountBalanceHistory)
+		accountGroup.GET("/:id/statements", txRead, read, statementHandler.GetStatements)
+		accountGroup.GET("/:id/statements/:date", txRead, read, statementHandler.GetStatement)
+		accountGroup.POST("/:id/statements/:date/payments", txWrite, write, statementHandler.SettleStatement)
+		accountGroup.DELETE("/:id/statement-payments/:paymentId", txWrite, write, statementHandler.DeleteStatementPayment)
+	}
+
+	// 预算相关路由（独立于家庭）
+	budgetGroup := r.Group("/api/budgets", authRequired)
+	{
+		budgetGroup.GET("/:id", read, budgetHandler.GetBudgetByID)
+		budgetGroup.PUT("/:id", admin, budgetHandler.UpdateBudget)
+		budgetGroup.DELETE("/:id", admin, budgetHandler.DeleteBudget)
+		budgetGroup.GET("/:id/progress", reports, read, budgetHandler.GetBudgetProgress)
+		budgetGroup.GET("/:id/envelope", reports, read, envelopeHandler.GetEnvelope)
+		budgetGroup.PUT("/:id/allocation", admin, envelopeHandler.SetAllocation)
+	}
+
+	// 消费限额相关路由（独立于家庭）
+	limitGroup := r.Group("/api/limits", authRequired)
+	{
+		limitGroup.PUT("/:id", admin, spendingLimitHandler.UpdateLimit)
+		limitGroup.DELETE("/:id", admin, spendingLimitHandler.DeleteLimit)
+	}
+
+	// 储蓄目标相关路由（独立于家庭）
+	goalGroup := r.Group("/api/goals", authRequired)
 	{
-		memberGroup.GET("", memberHandler.GetAllMembers)
-		memberGroup.GET("/:id", memberHandler.GetMemberByID)
-		memberGroup.PUT("/:id", memberHandler.UpdateMember)
-		memberGroup.DELETE("/:id", memberHandler.DeleteMember)
-		memberGroup.PUT("/:id/role", memberHandler.ChangeMemberRole)
+		goalGroup.GET("/:id", read, savingsGoalHandler.GetGoal)
+		goalGroup.PUT("/:id", write, savingsGoalHandler.UpdateGoal)
+		goalGroup.DELETE("/:id", admin, savingsGoalHandler.DeleteGoal)
+		goalGroup.GET("/:id/contributions", read, savingsGoalHandler.GetContributions)
+		goalGroup.POST("/:id/contributions", write, savingsGoalHandler.AddContribution)
+		goalGroup.DELETE("/:id/contributions/:contributionId", write, savingsGoalHandler.DeleteContribution)
 	}
 
-	categoryGroup := r.Group("/api/categories")
+	// 分期相关路由（独立于家庭）
+	installmentGroup := r.Group("/api/installments", authRequired)
 	{
-		categoryGroup.POST("", categoryHandler.CreateCategory)
-		categoryGroup.GET("", categoryHandler.GetAllCategories)
-		categoryGroup.GET("/:id", categoryHandler.GetCategoryByID)
-		categoryGroup.PUT("/:id", categoryHandler.UpdateCategory)
-		categoryGroup.DELETE("/:id", categoryHandler.DeleteCategory)
-		categoryGroup.GET("/:id/path", categoryHandler.GetFullCategoryPath)
-		categoryGroup.GET("/type/list", categoryHandler.GetCategoriesByType)
-		categoryGroup.GET("/type/tree", categoryHandler.GetCategoryTreeByType)
-		categoryGroup.GET("/parent/children", categoryHandler.GetCategoriesByParentID)
+		installmentGroup.GET("/:id", txRead, read, installmentHandler.GetPlan)
+		installmentGroup.POST("/:id/cancel", txWrite, write, installmentHandler.CancelPlan)
 	}
 
-	transactionGroup := r.Group("/api/transactions")
+	// 周期交易相关路由（独立于家庭）
+	recurringGroup := r.Group("/api/recurring", authRequired)
 	{
-		transactionGroup.GET("/:id", transactionHandler.GetTransactionByID)
-		transactionGroup.PUT("/:id", transactionHandler.UpdateTransaction)
-		transactionGroup.DELETE("/:id", transactionHandler.DeleteTransaction)
-		transactionGroup.POST("/:id/tags", transactionHandler.AddTagToTransaction)
-		transactionGroup.DELETE("/:id/tags/:tagId", transactionHandler.RemoveTagFromTransaction)
+		recurringGroup.GET("/:id", txRead, read, recurringHandler.GetTemplateByID)
+		recurringGroup.PUT("/:id", txWrite, write, recurringHandler.UpdateTemplate)
+		recurringGroup.DELETE("/:id", txWrite, write, recurringHandler.DeleteTemplate)
+		recurringGroup.POST("/:id/pause", txWrite, write, recurringHandler.PauseTemplate)
+		recurringGroup.POST("/:id/resume", txWrite, write, recurringHandler.ResumeTemplate)
+		recurringGroup.GET("/:id/occurrences", txRead, read, recurringHandler.GetOccurrences)
+	}
+
+	// 借贷相关路由（独立于家庭）
+	debtGroup := r.Group("/api/debts", authRequired)
+	{
+		debtGroup.GET("/:id", read, debtHandler.GetDebtByID)
+		debtGroup.PUT("/:id", write, debtHandler.UpdateDebt)
+		debtGroup.DELETE("/:id", admin, debtHandler.DeleteDebt)
+		debtGroup.GET("/:id/repayments", read, debtHandler.GetRepayments)
+		debtGroup.POST("/:id/repayments", write, debtHandler.AddRepayment)
+		debtGroup.DELETE("/:id/repayments/:repaymentId", write, debtHandler.DeleteRepayment)
+	}
+
+	// 账单相关路由（独立于家庭）
+	billGroup := r.Group("/api/bills", authRequired)
+	{
+		billGroup.GET("/:id", read, billHandler.GetBillByID)
+		billGroup.PUT("/:id", write, billHandler.UpdateBill)
+		billGroup.DELETE("/:id", write, billHandler.DeleteBill)
+		billGroup.POST("/:id/pay", txWrite, write, billHandler.PayBill)
+		billGroup.POST("/:id/unpay", txWrite, write, billHandler.UnpayBill)
+	}
+
+	// 通知渠道相关路由（独立于家庭）
+	channelGroup := r.Group("/api/notification-channels", authRequired)
+	{
+		channelGroup.PUT("/:id", admin, notificationHandler.UpdateChannel)
+		channelGroup.DELETE("/:id", admin, notificationHandler.DeleteChannel)
+		channelGroup.POST("/:id/test", admin, notificationHandler.TestChannel)
 	}
 
 	// 标签相关路由（独立于家庭）
-	tagGroup := r.Group("/api/tags")
+	tagGroup := r.Group("/api/tags", authRequired)
+	{
+		tagGroup.GET("", read, tagHandler.GetAllTags)
+		tagGroup.GET("/:id", read, tagHandler.GetTagByID)
+		tagGroup.PUT("/:id", admin, tagHandler.UpdateTag)
+		tagGroup.DELETE("/:id", admin, tagHandler.DeleteTag)
+	}
+
+	// 个人访问令牌管理路由（只能通过登录会话管理）
+	tokenGroup := r.Group("/api/tokens", authRequired)
 	{
-		tagGroup.GET("", tagHandler.GetAllTags)
-		tagGroup.GET("/:id", tagHandler.GetTagByID)
-		tagGroup.PUT("/:id", tagHandler.UpdateTag)
-		tagGroup.DELETE("/:id", tagHandler.DeleteTag)
+		tokenGroup.POST("", read, apiTokenHandler.CreateAPIToken)
+		tokenGroup.GET("", read, apiTokenHandler.GetAPITokens)
+		tokenGroup.DELETE("/:id", read, apiTokenHandler.RevokeAPIToken)
 	}
 
 	return r
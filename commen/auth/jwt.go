@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/KQLXK/Family-Finance-System/commen/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// defaultExpiration 配置缺失或格式错误时使用的令牌有效期
+const defaultExpiration = 24 * time.Hour
+
+// Claims JWT 载荷
+type Claims struct {
+	MemberID uint   `json:"member_id"`
+	FamilyID uint   `json:"family_id"`
+	Role     string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// GenerateToken 为成员签发令牌，返回令牌字符串及过期时间
+func GenerateToken(memberID, familyID uint, role string) (string, time.Time, error) {
+	cfg := config.GetConfig().JWT
+	if cfg.SecretKey == "" {
+		return "", time.Time{}, errors.New("未配置JWT密钥")
+	}
+
+	expiration := defaultExpiration
+	if cfg.ExpirationTime != "" {
+		if d, err := time.ParseDuration(cfg.ExpirationTime); err == nil && d > 0 {
+			expiration = d
+		}
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(expiration)
+	claims := Claims{
+		MemberID: memberID,
+		FamilyID: familyID,
+		Role:     role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.FormatUint(uint64(memberID), 10),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(cfg.SecretKey))
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("签发令牌失败: %v", err)
+	}
+	return token, expiresAt, nil
+}
+
+// ParseToken 校验令牌签名与有效期并返回载荷
+func ParseToken(tokenString string) (*Claims, error) {
+	secretKey := config.GetConfig().JWT.SecretKey
+	if secretKey == "" {
+		return nil, errors.New("未配置JWT密钥")
+	}
+	secret := []byte(secretKey)
+
+	var claims Claims
+	token, err := jwt.ParseWithClaims(tokenString, &claims, func(token *jwt.Token) (interface{}, error) {
+		return secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return nil, fmt.Errorf("令牌无效: %v", err)
+	}
+	if !token.Valid || claims.MemberID == 0 {
+		return nil, errors.New("令牌无效")
+	}
+	return &claims, nil
+}
@@ -0,0 +1,230 @@
+// handler/account_handler.go
+package handler
+
+import (
+	"github.com/KQLXK/Family-Finance-System/middleware"
+	"github.com/KQLXK/Family-Finance-System/model"
+	"github.com/KQLXK/Family-Finance-System/service"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AccountHandler 账户处理器
+type AccountHandler struct {
+	accountService service.AccountService
+}
+
+// NewAccountHandler 创建账户处理器
+func NewAccountHandler() *AccountHandler {
+	return &AccountHandler{
+		accountService: service.NewAccountService(),
+	}
+}
+
+// CreateAccount 创建账户
+func (h *AccountHandler) CreateAccount(c *gin.Context) {
+	familyIDStr := c.Param("id")
+	familyID, err := strconv.ParseUint(familyIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的家庭ID"})
+		return
+	}
+
+	var account model.Account
+	if err := c.ShouldBindJSON(&account); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+	account.FamilyID = uint(familyID)
+
+	if err := h.accountService.CreateAccount(middleware.CurrentScope(c), &account); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "账户创建成功",
+		"data":    account,
+	})
+}
+
+// GetAccountByID 根据ID获取账户
+func (h *AccountHandler) GetAccountByID(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的账户ID"})
+		return
+	}
+
+	account, err := h.accountService.GetAccountByID(middleware.CurrentScope(c), uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": account,
+	})
+}
+
+// GetAccountsByFamilyID 获取家庭账户列表，all=true 时包括已归档账户
+func (h *AccountHandler) GetAccountsByFamilyID(c *gin.Context) {
+	familyIDStr := c.Param("id")
+	familyID, err := strconv.ParseUint(familyIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的家庭ID"})
+		return
+	}
+
+	includeArchived := c.DefaultQuery("all", "false") == "true"
+
+	accounts, err := h.accountService.GetAccountsByFamilyID(middleware.CurrentScope(c), uint(familyID), includeArchived)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": accounts,
+	})
+}
+
+// UpdateAccount 更新账户信息
+func (h *AccountHandler) UpdateAccount(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的账户ID"})
+		return
+	}
+
+	var account model.Account
+	if err := c.ShouldBindJSON(&account); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	account.ID = uint(id)
+	if err := h.accountService.UpdateAccount(middleware.CurrentScope(c), &account); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "账户信息更新成功",
+		"data":    account,
+	})
+}
+
+// DeleteAccount 删除账户
+func (h *AccountHandler) DeleteAccount(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的账户ID"})
+		return
+	}
+
+	if err := h.accountService.DeleteAccount(middleware.CurrentScope(c), uint(id)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "账户删除成功",
+	})
+}
+
+// GetAccountBalance 获取账户当前余额
+func (h *AccountHandler) GetAccountBalance(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的账户ID"})
+		return
+	}
+
+	balance, err := h.accountService.GetAccountBalance(middleware.CurrentScope(c), uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": balance,
+	})
+}
+
+// GetAccountBalanceHistory 获取账户余额走势
+func (h *AccountHandler) GetAccountBalanceHistory(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的账户ID"})
+		return
+	}
+
+	// 获取时间范围参数
+	startTimeStr := c.Query("startTime")
+	endTimeStr := c.Query("endTime")
+
+	var startTime, endTime time.Time
+	if startTimeStr != "" {
+		startTime, err = time.Parse(time.RFC3339, startTimeStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的开始时间格式，请使用RFC3339格式"})
+			return
+		}
+	} else {
+		// 默认开始时间为30天前
+		startTime = time.Now().AddDate(0, 0, -30)
+	}
+
+	if endTimeStr != "" {
+		endTime, err = time.Parse(time.RFC3339, endTimeStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的结束时间格式，请使用RFC3339格式"})
+			return
+		}
+	} else {
+		// 默认结束时间为当前时间
+		endTime = time.Now()
+	}
+
+	groupBy := c.DefaultQuery("groupBy", "day")
+
+	points, err := h.accountService.GetAccountBalanceHistory(middleware.CurrentScope(c), uint(id), startTime, endTime, groupBy)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": points,
+	})
+}
+
+// MigratePaymentMethods 将历史交易的支付方式迁移为账户
+func (h *AccountHandler) MigratePaymentMethods(c *gin.Context) {
+	familyIDStr := c.Param("id")
+	familyID, err := strconv.ParseUint(familyIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的家庭ID"})
+		return
+	}
+
+	result, err := h.accountService.MigratePaymentMethods(middleware.CurrentScope(c), uint(familyID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "支付方式迁移完成",
+		"data":    result,
+	})
+}
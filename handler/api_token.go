@@ -0,0 +1,75 @@
+// handler/api_token_handler.go
+package handler
+
+import (
+	"github.com/KQLXK/Family-Finance-System/middleware"
+	"github.com/KQLXK/Family-Finance-System/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APITokenHandler 个人访问令牌处理器
+type APITokenHandler struct {
+	apiTokenService service.APITokenService
+}
+
+// NewAPITokenHandler 创建个人访问令牌处理器
+func NewAPITokenHandler() *APITokenHandler {
+	return &APITokenHandler{
+		apiTokenService: service.NewAPITokenService(),
+	}
+}
+
+// CreateAPIToken 创建个人访问令牌
+func (h *APITokenHandler) CreateAPIToken(c *gin.Context) {
+	var request service.CreateAPITokenRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	result, err := h.apiTokenService.CreateAPIToken(middleware.CurrentScope(c), &request)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "访问令牌创建成功，请妥善保存，令牌只显示这一次",
+		"data":    result,
+	})
+}
+
+// GetAPITokens 获取当前成员的个人访问令牌列表
+func (h *APITokenHandler) GetAPITokens(c *gin.Context) {
+	tokens, err := h.apiTokenService.GetAPITokens(middleware.CurrentScope(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": tokens,
+	})
+}
+
+// RevokeAPIToken 撤销个人访问令牌
+func (h *APITokenHandler) RevokeAPIToken(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的令牌ID"})
+		return
+	}
+
+	if err := h.apiTokenService.RevokeAPIToken(middleware.CurrentScope(c), uint(id)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "访问令牌已撤销",
+	})
+}
@@ -0,0 +1,96 @@
+// handler/audit_handler.go
+package handler
+
+import (
+	"github.com/KQLXK/Family-Finance-System/middleware"
+	"github.com/KQLXK/Family-Finance-System/model"
+	"github.com/KQLXK/Family-Finance-System/service"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuditHandler 审计日志处理器
+type AuditHandler struct {
+	auditService service.AuditService
+}
+
+// NewAuditHandler 创建审计日志处理器
+func NewAuditHandler() *AuditHandler {
+	return &AuditHandler{
+		auditService: service.NewAuditService(),
+	}
+}
+
+// GetAuditLogs 分页查询家庭的审计日志
+func (h *AuditHandler) GetAuditLogs(c *gin.Context) {
+	familyIDStr := c.Param("id")
+	familyID, err := strconv.ParseUint(familyIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的家庭ID"})
+		return
+	}
+
+	// 获取分页参数
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	// 获取过滤参数
+	filters := make(map[string]interface{})
+	if entityType := c.Query("entityType"); entityType != "" {
+		filters["entity_type"] = entityType
+	}
+	if entityIDStr := c.Query("entityId"); entityIDStr != "" {
+		entityID, err := strconv.ParseUint(entityIDStr, 10, 32)
+		if err == nil {
+			filters["entity_id"] = uint(entityID)
+		}
+	}
+	if action := c.Query("action"); action != "" {
+		filters["action"] = model.AuditAction(action)
+	}
+	if actorIDStr := c.Query("actorId"); actorIDStr != "" {
+		actorID, err := strconv.ParseUint(actorIDStr, 10, 32)
+		if err == nil {
+			filters["actor_id"] = uint(actorID)
+		}
+	}
+
+	// 获取时间范围参数（可选）
+	var startTime, endTime time.Time
+	if startTimeStr := c.Query("startTime"); startTimeStr != "" {
+		startTime, err = time.Parse(time.RFC3339, startTimeStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的开始时间格式，请使用RFC3339格式"})
+			return
+		}
+	}
+	if endTimeStr := c.Query("endTime"); endTimeStr != "" {
+		endTime, err = time.Parse(time.RFC3339, endTimeStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的结束时间格式，请使用RFC3339格式"})
+			return
+		}
+	}
+
+	auditLogs, total, err := h.auditService.GetAuditLogs(middleware.CurrentScope(c), uint(familyID), page, pageSize, filters, startTime, endTime)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  auditLogs,
+		"total": total,
+		"page":  page,
+		"size":  pageSize,
+	})
+}
@@ -0,0 +1,129 @@
+// handler/auth_handler.go
+package handler
+
+import (
+	"github.com/KQLXK/Family-Finance-System/middleware"
+	"github.com/KQLXK/Family-Finance-System/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuthHandler 认证处理器
+type AuthHandler struct {
+	authService service.AuthService
+}
+
+// NewAuthHandler 创建认证处理器
+func NewAuthHandler() *AuthHandler {
+	return &AuthHandler{
+		authService: service.NewAuthService(),
+	}
+}
+
+// Register 注册新家庭及管理员账号
+func (h *AuthHandler) Register(c *gin.Context) {
+	var request service.RegisterRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	result, err := h.authService.Register(&request)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "注册成功",
+		"data":    result,
+	})
+}
+
+// Login 登录并签发令牌
+func (h *AuthHandler) Login(c *gin.Context) {
+	var request struct {
+		Account  string `json:"account"` // 邮箱或手机号
+		Password string `json:"password"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	result, err := h.authService.Login(request.Account, request.Password)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "登录成功",
+		"data":    result,
+	})
+}
+
+// ChangePassword 修改当前成员的密码
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	var request struct {
+		OldPassword string `json:"old_password"`
+		NewPassword string `json:"new_password"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	if err := h.authService.ChangePassword(middleware.CurrentScope(c), request.OldPassword, request.NewPassword); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "密码修改成功，请重新登录",
+	})
+}
+
+// CreatePasswordResetToken 管理员为成员生成密码重置令牌
+func (h *AuthHandler) CreatePasswordResetToken(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的成员ID"})
+		return
+	}
+
+	result, err := h.authService.CreatePasswordResetToken(middleware.CurrentScope(c), uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "重置令牌已生成",
+		"data":    result,
+	})
+}
+
+// ResetPassword 使用重置令牌设置新密码
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var request struct {
+		Token       string `json:"token"`
+		NewPassword string `json:"new_password"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	if err := h.authService.ResetPassword(request.Token, request.NewPassword); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "密码重置成功",
+	})
+}
@@ -0,0 +1,249 @@
+// handler/bill_handler.go
+package handler
+
+import (
+	"github.com/KQLXK/Family-Finance-System/middleware"
+	"github.com/KQLXK/Family-Finance-System/model"
+	"github.com/KQLXK/Family-Finance-System/service"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BillHandler 账单处理器
+type BillHandler struct {
+	billService service.BillService
+}
+
+// NewBillHandler 创建账单处理器
+func NewBillHandler() *BillHandler {
+	return &BillHandler{
+		billService: service.NewBillService(),
+	}
+}
+
+// CreateBill 创建账单
+func (h *BillHandler) CreateBill(c *gin.Context) {
+	familyIDStr := c.Param("id")
+	familyID, err := strconv.ParseUint(familyIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的家庭ID"})
+		return
+	}
+
+	var bill model.Bill
+	if err := c.ShouldBindJSON(&bill); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+	bill.FamilyID = uint(familyID)
+
+	if err := h.billService.CreateBill(middleware.CurrentScope(c), &bill); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "账单创建成功",
+		"data":    bill,
+	})
+}
+
+// GetUpcomingBills 获取即将到期的未付账单，days 默认30天
+func (h *BillHandler) GetUpcomingBills(c *gin.Context) {
+	familyIDStr := c.Param("id")
+	familyID, err := strconv.ParseUint(familyIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的家庭ID"})
+		return
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的天数"})
+		return
+	}
+
+	bills, err := h.billService.GetUpcomingBills(middleware.CurrentScope(c), uint(familyID), days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": bills,
+	})
+}
+
+// GetOverdueBills 获取逾期未付的账单
+func (h *BillHandler) GetOverdueBills(c *gin.Context) {
+	familyIDStr := c.Param("id")
+	familyID, err := strconv.ParseUint(familyIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的家庭ID"})
+		return
+	}
+
+	bills, err := h.billService.GetOverdueBills(middleware.CurrentScope(c), uint(familyID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": bills,
+	})
+}
+
+// GetPaidBills 获取已付账单历史，默认最近一年
+func (h *BillHandler) GetPaidBills(c *gin.Context) {
+	familyIDStr := c.Param("id")
+	familyID, err := strconv.ParseUint(familyIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的家庭ID"})
+		return
+	}
+
+	endTime := time.Now()
+	if endTimeStr := c.Query("endTime"); endTimeStr != "" {
+		endTime, err = time.Parse(time.RFC3339, endTimeStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的结束时间格式，请使用RFC3339格式"})
+			return
+		}
+	}
+	startTime := endTime.AddDate(-1, 0, 0)
+	if startTimeStr := c.Query("startTime"); startTimeStr != "" {
+		startTime, err = time.Parse(time.RFC3339, startTimeStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的开始时间格式，请使用RFC3339格式"})
+			return
+		}
+	}
+
+	bills, err := h.billService.GetPaidBills(middleware.CurrentScope(c), uint(familyID), startTime, endTime)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": bills,
+	})
+}
+
+// GetBillByID 获取账单详情
+func (h *BillHandler) GetBillByID(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的账单ID"})
+		return
+	}
+
+	bill, err := h.billService.GetBillByID(middleware.CurrentScope(c), uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": bill,
+	})
+}
+
+// UpdateBill 更新账单
+func (h *BillHandler) UpdateBill(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的账单ID"})
+		return
+	}
+
+	var bill model.Bill
+	if err := c.ShouldBindJSON(&bill); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	bill.ID = uint(id)
+	if err := h.billService.UpdateBill(middleware.CurrentScope(c), &bill); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "账单更新成功",
+		"data":    bill,
+	})
+}
+
+// DeleteBill 删除账单
+func (h *BillHandler) DeleteBill(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的账单ID"})
+		return
+	}
+
+	if err := h.billService.DeleteBill(middleware.CurrentScope(c), uint(id)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "账单删除成功",
+	})
+}
+
+// PayBill 将账单关联到付款交易并标记为已付
+func (h *BillHandler) PayBill(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的账单ID"})
+		return
+	}
+
+	var request service.PayBillRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	bill, err := h.billService.PayBill(middleware.CurrentScope(c), uint(id), &request)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "账单已标记为已付",
+		"data":    bill,
+	})
+}
+
+// UnpayBill 撤销账单付款
+func (h *BillHandler) UnpayBill(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的账单ID"})
+		return
+	}
+
+	bill, err := h.billService.UnpayBill(middleware.CurrentScope(c), uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "账单付款已撤销",
+		"data":    bill,
+	})
+}
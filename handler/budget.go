@@ -0,0 +1,203 @@
+// handler/budget_handler.go
+package handler
+
+import (
+	"github.com/KQLXK/Family-Finance-System/middleware"
+	"github.com/KQLXK/Family-Finance-System/model"
+	"github.com/KQLXK/Family-Finance-System/service"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BudgetHandler 预算处理器
+type BudgetHandler struct {
+	budgetService service.BudgetService
+}
+
+// NewBudgetHandler 创建预算处理器
+func NewBudgetHandler() *BudgetHandler {
+	return &BudgetHandler{
+		budgetService: service.NewBudgetService(),
+	}
+}
+
+// CreateBudget 创建分类预算
+func (h *BudgetHandler) CreateBudget(c *gin.Context) {
+	familyIDStr := c.Param("id")
+	familyID, err := strconv.ParseUint(familyIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的家庭ID"})
+		return
+	}
+
+	var budget model.Budget
+	if err := c.ShouldBindJSON(&budget); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+	budget.FamilyID = uint(familyID)
+
+	if err := h.budgetService.CreateBudget(middleware.CurrentScope(c), &budget); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "预算创建成功",
+		"data":    budget,
+	})
+}
+
+// GetBudgetsByFamilyID 获取家庭的预算列表
+func (h *BudgetHandler) GetBudgetsByFamilyID(c *gin.Context) {
+	familyIDStr := c.Param("id")
+	familyID, err := strconv.ParseUint(familyIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的家庭ID"})
+		return
+	}
+
+	budgets, err := h.budgetService.GetBudgetsByFamilyID(middleware.CurrentScope(c), uint(familyID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": budgets,
+	})
+}
+
+// GetFamilyBudgetProgress 获取家庭所有预算的执行情况
+func (h *BudgetHandler) GetFamilyBudgetProgress(c *gin.Context) {
+	familyIDStr := c.Param("id")
+	familyID, err := strconv.ParseUint(familyIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的家庭ID"})
+		return
+	}
+
+	date, ok := progressDate(c)
+	if !ok {
+		return
+	}
+
+	progress, err := h.budgetService.GetFamilyBudgetProgress(middleware.CurrentScope(c), uint(familyID), date)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": progress,
+	})
+}
+
+// GetBudgetByID 获取预算
+func (h *BudgetHandler) GetBudgetByID(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的预算ID"})
+		return
+	}
+
+	budget, err := h.budgetService.GetBudgetByID(middleware.CurrentScope(c), uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": budget,
+	})
+}
+
+// UpdateBudget 更新预算
+func (h *BudgetHandler) UpdateBudget(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的预算ID"})
+		return
+	}
+
+	var budget model.Budget
+	if err := c.ShouldBindJSON(&budget); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	budget.ID = uint(id)
+	if err := h.budgetService.UpdateBudget(middleware.CurrentScope(c), &budget); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "预算更新成功",
+		"data":    budget,
+	})
+}
+
+// DeleteBudget 删除预算
+func (h *BudgetHandler) DeleteBudget(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的预算ID"})
+		return
+	}
+
+	if err := h.budgetService.DeleteBudget(middleware.CurrentScope(c), uint(id)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "预算删除成功",
+	})
+}
+
+// GetBudgetProgress 获取预算的执行情况
+func (h *BudgetHandler) GetBudgetProgress(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的预算ID"})
+		return
+	}
+
+	date, ok := progressDate(c)
+	if !ok {
+		return
+	}
+
+	progress, err := h.budgetService.GetBudgetProgress(middleware.CurrentScope(c), uint(id), date)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": progress,
+	})
+}
+
+// progressDate 解析统计日期参数，默认为当天；解析失败时已写入错误响应
+func progressDate(c *gin.Context) (time.Time, bool) {
+	dateStr := c.Query("date")
+	if dateStr == "" {
+		return time.Now(), true
+	}
+
+	date, err := time.ParseInLocation("2006-01-02", dateStr, time.Local)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的日期格式，请使用 2006-01-02 格式"})
+		return time.Time{}, false
+	}
+	return date, true
+}
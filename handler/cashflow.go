@@ -0,0 +1,50 @@
+// handler/cashflow_handler.go
+package handler
+
+import (
+	"github.com/KQLXK/Family-Finance-System/middleware"
+	"github.com/KQLXK/Family-Finance-System/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CashFlowHandler 现金流预测处理器
+type CashFlowHandler struct {
+	cashFlowService service.CashFlowService
+}
+
+// NewCashFlowHandler 创建现金流预测处理器
+func NewCashFlowHandler() *CashFlowHandler {
+	return &CashFlowHandler{
+		cashFlowService: service.NewCashFlowService(),
+	}
+}
+
+// GetProjection 获取家庭未来的现金流预测，包含计划交易和周期交易
+func (h *CashFlowHandler) GetProjection(c *gin.Context) {
+	familyIDStr := c.Param("id")
+	familyID, err := strconv.ParseUint(familyIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的家庭ID"})
+		return
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "90"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的预测天数"})
+		return
+	}
+	groupBy := c.DefaultQuery("groupBy", "day")
+
+	projection, err := h.cashFlowService.GetProjection(middleware.CurrentScope(c), uint(familyID), days, groupBy)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": projection,
+	})
+}
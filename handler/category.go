@@ -2,6 +2,7 @@
 package handler
 
 import (
+	"github.com/KQLXK/Family-Finance-System/middleware"
 	"github.com/KQLXK/Family-Finance-System/model"
 	"github.com/KQLXK/Family-Finance-System/service"
 	"net/http"
@@ -30,7 +31,7 @@ func (h *CategoryHandler) CreateCategory(c *gin.Context) {
 		return
 	}
 
-	if err := h.categoryService.CreateCategory(&category); err != nil {
+	if err := h.categoryService.CreateCategory(middleware.CurrentScope(c), &category); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -152,7 +153,7 @@ func (h *CategoryHandler) UpdateCategory(c *gin.Context) {
 	}
 
 	category.ID = uint(id)
-	if err := h.categoryService.UpdateCategory(&category); err != nil {
+	if err := h.categoryService.UpdateCategory(middleware.CurrentScope(c), &category); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -172,7 +173,7 @@ func (h *CategoryHandler) DeleteCategory(c *gin.Context) {
 		return
 	}
 
-	if err := h.categoryService.DeleteCategory(uint(id)); err != nil {
+	if err := h.categoryService.DeleteCategory(middleware.CurrentScope(c), uint(id)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
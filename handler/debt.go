@@ -0,0 +1,230 @@
+// handler/debt_handler.go
+package handler
+
+import (
+	"github.com/KQLXK/Family-Finance-System/middleware"
+	"github.com/KQLXK/Family-Finance-System/model"
+	"github.com/KQLXK/Family-Finance-System/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DebtHandler 借贷处理器
+type DebtHandler struct {
+	debtService service.DebtService
+}
+
+// NewDebtHandler 创建借贷处理器
+func NewDebtHandler() *DebtHandler {
+	return &DebtHandler{
+		debtService: service.NewDebtService(),
+	}
+}
+
+// CreateDebt 创建借贷记录
+func (h *DebtHandler) CreateDebt(c *gin.Context) {
+	familyIDStr := c.Param("id")
+	familyID, err := strconv.ParseUint(familyIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的家庭ID"})
+		return
+	}
+
+	var debt model.Debt
+	if err := c.ShouldBindJSON(&debt); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+	debt.FamilyID = uint(familyID)
+
+	if err := h.debtService.CreateDebt(middleware.CurrentScope(c), &debt); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "借贷记录创建成功",
+		"data":    debt,
+	})
+}
+
+// GetDebts 获取家庭借贷记录，status 支持 open（默认）、closed、all
+func (h *DebtHandler) GetDebts(c *gin.Context) {
+	familyIDStr := c.Param("id")
+	familyID, err := strconv.ParseUint(familyIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的家庭ID"})
+		return
+	}
+
+	debts, err := h.debtService.GetDebts(middleware.CurrentScope(c), uint(familyID), c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": debts,
+	})
+}
+
+// GetOverdueDebts 获取家庭逾期借贷记录
+func (h *DebtHandler) GetOverdueDebts(c *gin.Context) {
+	familyIDStr := c.Param("id")
+	familyID, err := strconv.ParseUint(familyIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的家庭ID"})
+		return
+	}
+
+	debts, err := h.debtService.GetOverdueDebts(middleware.CurrentScope(c), uint(familyID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": debts,
+	})
+}
+
+// GetDebtByID 获取借贷记录
+func (h *DebtHandler) GetDebtByID(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的借贷ID"})
+		return
+	}
+
+	debt, err := h.debtService.GetDebtByID(middleware.CurrentScope(c), uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": debt,
+	})
+}
+
+// UpdateDebt 更新借贷信息
+func (h *DebtHandler) UpdateDebt(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的借贷ID"})
+		return
+	}
+
+	var debt model.Debt
+	if err := c.ShouldBindJSON(&debt); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	debt.ID = uint(id)
+	if err := h.debtService.UpdateDebt(middleware.CurrentScope(c), &debt); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "借贷记录更新成功",
+		"data":    debt,
+	})
+}
+
+// DeleteDebt 删除借贷记录
+func (h *DebtHandler) DeleteDebt(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的借贷ID"})
+		return
+	}
+
+	if err := h.debtService.DeleteDebt(middleware.CurrentScope(c), uint(id)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "借贷记录删除成功",
+	})
+}
+
+// AddRepayment 登记还款
+func (h *DebtHandler) AddRepayment(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的借贷ID"})
+		return
+	}
+
+	var request service.AddRepaymentRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	repayment, err := h.debtService.AddRepayment(middleware.CurrentScope(c), uint(id), request)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "还款登记成功",
+		"data":    repayment,
+	})
+}
+
+// GetRepayments 获取借贷的还款记录
+func (h *DebtHandler) GetRepayments(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的借贷ID"})
+		return
+	}
+
+	repayments, err := h.debtService.GetRepayments(middleware.CurrentScope(c), uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": repayments,
+	})
+}
+
+// DeleteRepayment 删除还款记录
+func (h *DebtHandler) DeleteRepayment(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的借贷ID"})
+		return
+	}
+
+	repaymentIDStr := c.Param("repaymentId")
+	repaymentID, err := strconv.ParseUint(repaymentIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的还款记录ID"})
+		return
+	}
+
+	if err := h.debtService.DeleteRepayment(middleware.CurrentScope(c), uint(id), uint(repaymentID)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "还款记录删除成功",
+	})
+}
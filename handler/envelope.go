@@ -0,0 +1,177 @@
+// handler/envelope_handler.go
+package handler
+
+import (
+	"github.com/KQLXK/Family-Finance-System/middleware"
+	"github.com/KQLXK/Family-Finance-System/service"
+	"github.com/shopspring/decimal"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EnvelopeHandler 信封预算处理器
+type EnvelopeHandler struct {
+	envelopeService service.EnvelopeService
+}
+
+// NewEnvelopeHandler 创建信封预算处理器
+func NewEnvelopeHandler() *EnvelopeHandler {
+	return &EnvelopeHandler{
+		envelopeService: service.NewEnvelopeService(),
+	}
+}
+
+// GetEnvelope 获取预算信封的余额
+func (h *EnvelopeHandler) GetEnvelope(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的预算ID"})
+		return
+	}
+
+	date, ok := progressDate(c)
+	if !ok {
+		return
+	}
+
+	envelope, err := h.envelopeService.GetEnvelope(middleware.CurrentScope(c), uint(id), date)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": envelope,
+	})
+}
+
+// SetAllocation 设置预算信封某个周期的分配金额
+func (h *EnvelopeHandler) SetAllocation(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的预算ID"})
+		return
+	}
+
+	var request struct {
+		Date   string          `json:"date"`
+		Amount decimal.Decimal `json:"amount"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	date := time.Now()
+	if request.Date != "" {
+		if date, err = time.ParseInLocation("2006-01-02", request.Date, time.Local); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的日期格式，请使用 2006-01-02 格式"})
+			return
+		}
+	}
+
+	allocation, err := h.envelopeService.SetAllocation(middleware.CurrentScope(c), uint(id), date, request.Amount)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "预算分配更新成功",
+		"data":    allocation,
+	})
+}
+
+// GetFamilyEnvelopes 获取家庭所有预算信封的余额
+func (h *EnvelopeHandler) GetFamilyEnvelopes(c *gin.Context) {
+	familyIDStr := c.Param("id")
+	familyID, err := strconv.ParseUint(familyIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的家庭ID"})
+		return
+	}
+
+	date, ok := progressDate(c)
+	if !ok {
+		return
+	}
+
+	envelopes, err := h.envelopeService.GetFamilyEnvelopes(middleware.CurrentScope(c), uint(familyID), date)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": envelopes,
+	})
+}
+
+// MoveBetweenEnvelopes 在预算信封之间调拨
+func (h *EnvelopeHandler) MoveBetweenEnvelopes(c *gin.Context) {
+	familyIDStr := c.Param("id")
+	familyID, err := strconv.ParseUint(familyIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的家庭ID"})
+		return
+	}
+
+	var request service.MoveEnvelopeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	move, err := h.envelopeService.MoveBetweenEnvelopes(middleware.CurrentScope(c), uint(familyID), request)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "信封调拨成功",
+		"data":    move,
+	})
+}
+
+// GetMoves 获取家庭的信封调拨记录
+func (h *EnvelopeHandler) GetMoves(c *gin.Context) {
+	familyIDStr := c.Param("id")
+	familyID, err := strconv.ParseUint(familyIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的家庭ID"})
+		return
+	}
+
+	// 获取时间范围参数（可选）
+	var startTime, endTime time.Time
+	if startTimeStr := c.Query("startTime"); startTimeStr != "" {
+		startTime, err = time.Parse(time.RFC3339, startTimeStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的开始时间格式，请使用RFC3339格式"})
+			return
+		}
+	}
+	if endTimeStr := c.Query("endTime"); endTimeStr != "" {
+		endTime, err = time.Parse(time.RFC3339, endTimeStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的结束时间格式，请使用RFC3339格式"})
+			return
+		}
+	}
+
+	moves, err := h.envelopeService.GetMoves(middleware.CurrentScope(c), uint(familyID), startTime, endTime)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": moves,
+	})
+}
@@ -0,0 +1,161 @@
+// handler/exchange_rate_handler.go
+package handler
+
+import (
+	"github.com/KQLXK/Family-Finance-System/middleware"
+	"github.com/KQLXK/Family-Finance-System/model"
+	"github.com/KQLXK/Family-Finance-System/service"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxImportSize 汇率 CSV 文件大小上限
+const maxImportSize = 2 << 20
+
+// ExchangeRateHandler 汇率处理器
+type ExchangeRateHandler struct {
+	exchangeRateService service.ExchangeRateService
+}
+
+// NewExchangeRateHandler 创建汇率处理器
+func NewExchangeRateHandler() *ExchangeRateHandler {
+	return &ExchangeRateHandler{
+		exchangeRateService: service.NewExchangeRateService(),
+	}
+}
+
+// SaveExchangeRate 新增或覆盖一条汇率
+func (h *ExchangeRateHandler) SaveExchangeRate(c *gin.Context) {
+	familyIDStr := c.Param("id")
+	familyID, err := strconv.ParseUint(familyIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的家庭ID"})
+		return
+	}
+
+	var rate model.ExchangeRate
+	if err := c.ShouldBindJSON(&rate); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+	rate.FamilyID = uint(familyID)
+
+	if err := h.exchangeRateService.SaveExchangeRate(middleware.CurrentScope(c), &rate); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "汇率保存成功",
+		"data":    rate,
+	})
+}
+
+// ImportExchangeRates 从 CSV 导入汇率，支持 multipart 文件字段 file 或直接提交 CSV 请求体
+func (h *ExchangeRateHandler) ImportExchangeRates(c *gin.Context) {
+	familyIDStr := c.Param("id")
+	familyID, err := strconv.ParseUint(familyIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的家庭ID"})
+		return
+	}
+
+	var reader io.Reader
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "请上传汇率文件"})
+			return
+		}
+		if fileHeader.Size > maxImportSize {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "汇率文件不能超过2MB"})
+			return
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无法读取汇率文件"})
+			return
+		}
+		defer file.Close()
+		reader = file
+	} else {
+		reader = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+	}
+
+	count, err := h.exchangeRateService.ImportExchangeRates(middleware.CurrentScope(c), uint(familyID), reader)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "汇率导入成功",
+		"imported": count,
+	})
+}
+
+// GetExchangeRates 获取家庭汇率列表，可按币种和日期范围过滤，默认最近一年
+func (h *ExchangeRateHandler) GetExchangeRates(c *gin.Context) {
+	familyIDStr := c.Param("id")
+	familyID, err := strconv.ParseUint(familyIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的家庭ID"})
+		return
+	}
+
+	endDate := time.Now()
+	startDate := endDate.AddDate(-1, 0, 0)
+	if startDateStr := c.Query("startDate"); startDateStr != "" {
+		if startDate, err = time.ParseInLocation("2006-01-02", startDateStr, time.Local); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的开始日期格式，请使用 2006-01-02 格式"})
+			return
+		}
+	}
+	if endDateStr := c.Query("endDate"); endDateStr != "" {
+		if endDate, err = time.ParseInLocation("2006-01-02", endDateStr, time.Local); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的结束日期格式，请使用 2006-01-02 格式"})
+			return
+		}
+	}
+
+	rates, err := h.exchangeRateService.GetExchangeRatesByFamilyID(middleware.CurrentScope(c), uint(familyID), c.Query("currency"), startDate, endDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": rates,
+	})
+}
+
+// DeleteExchangeRate 删除汇率
+func (h *ExchangeRateHandler) DeleteExchangeRate(c *gin.Context) {
+	familyIDStr := c.Param("id")
+	familyID, err := strconv.ParseUint(familyIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的家庭ID"})
+		return
+	}
+
+	rateIDStr := c.Param("rateId")
+	rateID, err := strconv.ParseUint(rateIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的汇率ID"})
+		return
+	}
+
+	if err := h.exchangeRateService.DeleteExchangeRate(middleware.CurrentScope(c), uint(familyID), uint(rateID)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "汇率删除成功",
+	})
+}
@@ -2,8 +2,10 @@
 package handler
 
 import (
+	"github.com/KQLXK/Family-Finance-System/middleware"
 	"github.com/KQLXK/Family-Finance-System/model"
 	"github.com/KQLXK/Family-Finance-System/service"
+	"github.com/shopspring/decimal"
 	"net/http"
 	"strconv"
 
@@ -35,7 +37,7 @@ func (h *FamilyHandler) CreateFamily(c *gin.Context) {
 		return
 	}
 
-	if err := h.familyService.CreateFamily(&family); err != nil {
+	if err := h.familyService.CreateFamily(middleware.CurrentScope(c), &family); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建家庭失败"})
 		return
 	}
@@ -55,7 +57,7 @@ func (h *FamilyHandler) GetFamilyByID(c *gin.Context) {
 		return
 	}
 
-	family, err := h.familyService.GetFamilyByID(uint(id))
+	family, err := h.familyService.GetFamilyByID(middleware.CurrentScope(c), uint(id))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "家庭不存在"})
 		return
@@ -68,7 +70,7 @@ func (h *FamilyHandler) GetFamilyByID(c *gin.Context) {
 
 // GetAllFamilies 获取所有家庭
 func (h *FamilyHandler) GetAllFamilies(c *gin.Context) {
-	families, err := h.familyService.GetAllFamilies()
+	families, err := h.familyService.GetAllFamilies(middleware.CurrentScope(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取家庭列表失败"})
 		return
@@ -95,7 +97,7 @@ func (h *FamilyHandler) UpdateFamily(c *gin.Context) {
 	}
 
 	family.ID = uint(id)
-	if err := h.familyService.UpdateFamily(&family); err != nil {
+	if err := h.familyService.UpdateFamily(middleware.CurrentScope(c), &family); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新家庭信息失败"})
 		return
 	}
@@ -106,6 +108,60 @@ func (h *FamilyHandler) UpdateFamily(c *gin.Context) {
 	})
 }
 
+// UpdateApprovalThreshold 设置家庭交易审批阈值
+func (h *FamilyHandler) UpdateApprovalThreshold(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的家庭ID"})
+		return
+	}
+
+	var request struct {
+		ApprovalThreshold decimal.Decimal `json:"approval_threshold"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	if err := h.familyService.UpdateApprovalThreshold(middleware.CurrentScope(c), uint(id), request.ApprovalThreshold); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "审批阈值更新成功",
+	})
+}
+
+// UpdateBaseCurrency 设置家庭本位币
+func (h *FamilyHandler) UpdateBaseCurrency(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的家庭ID"})
+		return
+	}
+
+	var request struct {
+		BaseCurrency string `json:"base_currency"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	if err := h.familyService.UpdateBaseCurrency(middleware.CurrentScope(c), uint(id), request.BaseCurrency); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "本位币更新成功",
+	})
+}
+
 // DeleteFamily 删除家庭
 func (h *FamilyHandler) DeleteFamily(c *gin.Context) {
 	idStr := c.Param("id")
@@ -115,7 +171,7 @@ func (h *FamilyHandler) DeleteFamily(c *gin.Context) {
 		return
 	}
 
-	if err := h.familyService.DeleteFamily(uint(id)); err != nil {
+	if err := h.familyService.DeleteFamily(middleware.CurrentScope(c), uint(id)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除家庭失败"})
 		return
 	}
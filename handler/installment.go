@@ -0,0 +1,119 @@
+// handler/installment_handler.go
+package handler
+
+import (
+	"github.com/KQLXK/Family-Finance-System/middleware"
+	"github.com/KQLXK/Family-Finance-System/model"
+	"github.com/KQLXK/Family-Finance-System/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InstallmentHandler 分期处理器
+type InstallmentHandler struct {
+	installmentService service.InstallmentService
+}
+
+// NewInstallmentHandler 创建分期处理器
+func NewInstallmentHandler() *InstallmentHandler {
+	return &InstallmentHandler{
+		installmentService: service.NewInstallmentService(),
+	}
+}
+
+// CreatePlan 创建分期计划
+func (h *InstallmentHandler) CreatePlan(c *gin.Context) {
+	familyIDStr := c.Param("id")
+	familyID, err := strconv.ParseUint(familyIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的家庭ID"})
+		return
+	}
+
+	var plan model.InstallmentPlan
+	if err := c.ShouldBindJSON(&plan); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+	plan.FamilyID = uint(familyID)
+
+	scope := middleware.CurrentScope(c)
+	if err := h.installmentService.CreatePlan(scope, &plan); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	schedule, err := h.installmentService.GetPlan(scope, plan.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "分期计划创建成功",
+		"data":    schedule,
+	})
+}
+
+// GetPlans 获取家庭的分期计划，可按状态过滤
+func (h *InstallmentHandler) GetPlans(c *gin.Context) {
+	familyIDStr := c.Param("id")
+	familyID, err := strconv.ParseUint(familyIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的家庭ID"})
+		return
+	}
+
+	plans, err := h.installmentService.GetPlans(middleware.CurrentScope(c), uint(familyID), model.InstallmentStatus(c.Query("status")))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": plans,
+	})
+}
+
+// GetPlan 获取分期计划及还款计划
+func (h *InstallmentHandler) GetPlan(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的分期计划ID"})
+		return
+	}
+
+	schedule, err := h.installmentService.GetPlan(middleware.CurrentScope(c), uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": schedule,
+	})
+}
+
+// CancelPlan 提前结清分期
+func (h *InstallmentHandler) CancelPlan(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的分期计划ID"})
+		return
+	}
+
+	schedule, err := h.installmentService.CancelPlan(middleware.CurrentScope(c), uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "分期已提前结清",
+		"data":    schedule,
+	})
+}
@@ -0,0 +1,118 @@
+// handler/invitation_handler.go
+package handler
+
+import (
+	"github.com/KQLXK/Family-Finance-System/middleware"
+	"github.com/KQLXK/Family-Finance-System/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InvitationHandler 邀请码处理器
+type InvitationHandler struct {
+	invitationService service.InvitationService
+}
+
+// NewInvitationHandler 创建邀请码处理器
+func NewInvitationHandler() *InvitationHandler {
+	return &InvitationHandler{
+		invitationService: service.NewInvitationService(),
+	}
+}
+
+// CreateInvitation 创建邀请码
+func (h *InvitationHandler) CreateInvitation(c *gin.Context) {
+	familyIDStr := c.Param("id")
+	familyID, err := strconv.ParseUint(familyIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的家庭ID"})
+		return
+	}
+
+	var request service.CreateInvitationRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	invitation, err := h.invitationService.CreateInvitation(middleware.CurrentScope(c), uint(familyID), &request)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "邀请码创建成功",
+		"data":    invitation,
+	})
+}
+
+// GetInvitationsByFamilyID 获取家庭的邀请码列表，默认只返回仍可使用的邀请码
+func (h *InvitationHandler) GetInvitationsByFamilyID(c *gin.Context) {
+	familyIDStr := c.Param("id")
+	familyID, err := strconv.ParseUint(familyIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的家庭ID"})
+		return
+	}
+
+	onlyActive := c.DefaultQuery("all", "false") != "true"
+
+	invitations, err := h.invitationService.GetInvitationsByFamilyID(middleware.CurrentScope(c), uint(familyID), onlyActive)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": invitations,
+	})
+}
+
+// RevokeInvitation 撤销邀请码
+func (h *InvitationHandler) RevokeInvitation(c *gin.Context) {
+	familyIDStr := c.Param("id")
+	familyID, err := strconv.ParseUint(familyIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的家庭ID"})
+		return
+	}
+
+	invitationIDStr := c.Param("invitationId")
+	invitationID, err := strconv.ParseUint(invitationIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的邀请码ID"})
+		return
+	}
+
+	if err := h.invitationService.RevokeInvitation(middleware.CurrentScope(c), uint(familyID), uint(invitationID)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "邀请码已撤销",
+	})
+}
+
+// RedeemInvitation 兑换邀请码加入家庭
+func (h *InvitationHandler) RedeemInvitation(c *gin.Context) {
+	var request service.RedeemInvitationRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	result, err := h.invitationService.RedeemInvitation(&request)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "已加入家庭",
+		"data":    result,
+	})
+}
@@ -2,6 +2,7 @@
 package handler
 
 import (
+	"github.com/KQLXK/Family-Finance-System/middleware"
 	"github.com/KQLXK/Family-Finance-System/model"
 	"github.com/KQLXK/Family-Finance-System/service"
 	"net/http"
@@ -24,7 +25,10 @@ func NewMemberHandler() *MemberHandler {
 
 // CreateMember 创建成员
 func (h *MemberHandler) CreateMember(c *gin.Context) {
-	var member model.Member
+	var request struct {
+		model.Member
+		Password string `json:"password"` // 可选，设置后成员可以自行登录
+	}
 
 	familyIDstr := c.Param("id")
 	familyID, err := strconv.ParseInt(familyIDstr, 10, 32)
@@ -32,14 +36,24 @@ func (h *MemberHandler) CreateMember(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的家庭ID"})
 		return
 	}
-	member.FamilyID = uint(familyID)
 
-	if err := c.ShouldBindJSON(&member); err != nil {
+	if err := c.ShouldBindJSON(&request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
 		return
 	}
+	member := request.Member
+	member.FamilyID = uint(familyID)
+
+	if request.Password != "" {
+		passwordHash, err := service.HashPassword(request.Password)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		member.PasswordHash = passwordHash
+	}
 
-	if err := h.memberService.CreateMember(&member); err != nil {
+	if err := h.memberService.CreateMember(middleware.CurrentScope(c), &member); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -59,7 +73,7 @@ func (h *MemberHandler) GetMemberByID(c *gin.Context) {
 		return
 	}
 
-	member, err := h.memberService.GetMemberByID(uint(id))
+	member, err := h.memberService.GetMemberByID(middleware.CurrentScope(c), uint(id))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
@@ -79,7 +93,7 @@ func (h *MemberHandler) GetMembersByFamilyID(c *gin.Context) {
 		return
 	}
 
-	members, err := h.memberService.GetMembersByFamilyID(uint(familyID))
+	members, err := h.memberService.GetMembersByFamilyID(middleware.CurrentScope(c), uint(familyID))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -92,7 +106,7 @@ func (h *MemberHandler) GetMembersByFamilyID(c *gin.Context) {
 
 // GetAllMembers 获取所有成员
 func (h *MemberHandler) GetAllMembers(c *gin.Context) {
-	members, err := h.memberService.GetAllMembers()
+	members, err := h.memberService.GetAllMembers(middleware.CurrentScope(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -119,7 +133,7 @@ func (h *MemberHandler) UpdateMember(c *gin.Context) {
 	}
 
 	member.ID = uint(id)
-	if err := h.memberService.UpdateMember(&member); err != nil {
+	if err := h.memberService.UpdateMember(middleware.CurrentScope(c), &member); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -139,7 +153,7 @@ func (h *MemberHandler) DeleteMember(c *gin.Context) {
 		return
 	}
 
-	if err := h.memberService.DeleteMember(uint(id)); err != nil {
+	if err := h.memberService.DeleteMember(middleware.CurrentScope(c), uint(id)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -166,7 +180,7 @@ func (h *MemberHandler) ChangeMemberRole(c *gin.Context) {
 		return
 	}
 
-	if err := h.memberService.ChangeMemberRole(uint(id), request.Role); err != nil {
+	if err := h.memberService.ChangeMemberRole(middleware.CurrentScope(c), uint(id), request.Role); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -185,7 +199,7 @@ func (h *MemberHandler) GetActiveMembersByFamilyID(c *gin.Context) {
 		return
 	}
 
-	members, err := h.memberService.GetActiveMembersByFamilyID(uint(familyID))
+	members, err := h.memberService.GetActiveMembersByFamilyID(middleware.CurrentScope(c), uint(familyID))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -195,3 +209,30 @@ func (h *MemberHandler) GetActiveMembersByFamilyID(c *gin.Context) {
 		"data": members,
 	})
 }
+
+// SetRequireApproval 设置成员交易是否需要审批
+func (h *MemberHandler) SetRequireApproval(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的成员ID"})
+		return
+	}
+
+	var request struct {
+		RequireApproval bool `json:"require_approval"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	if err := h.memberService.SetRequireApproval(middleware.CurrentScope(c), uint(id), request.RequireApproval); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "成员审批设置更新成功",
+	})
+}
@@ -0,0 +1,195 @@
+// handler/recurring_handler.go
+package handler
+
+import (
+	"github.com/KQLXK/Family-Finance-System/middleware"
+	"github.com/KQLXK/Family-Finance-System/model"
+	"github.com/KQLXK/Family-Finance-System/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RecurringHandler 周期交易处理器
+type RecurringHandler struct {
+	recurringService service.RecurringService
+}
+
+// NewRecurringHandler 创建周期交易处理器
+func NewRecurringHandler() *RecurringHandler {
+	return &RecurringHandler{
+		recurringService: service.NewRecurringService(),
+	}
+}
+
+// CreateTemplate 创建周期交易
+func (h *RecurringHandler) CreateTemplate(c *gin.Context) {
+	familyIDStr := c.Param("id")
+	familyID, err := strconv.ParseUint(familyIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的家庭ID"})
+		return
+	}
+
+	var template model.RecurringTemplate
+	if err := c.ShouldBindJSON(&template); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+	template.FamilyID = uint(familyID)
+
+	if err := h.recurringService.CreateTemplate(middleware.CurrentScope(c), &template); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "周期交易创建成功",
+		"data":    template,
+	})
+}
+
+// GetTemplatesByFamilyID 获取家庭的周期交易
+func (h *RecurringHandler) GetTemplatesByFamilyID(c *gin.Context) {
+	familyIDStr := c.Param("id")
+	familyID, err := strconv.ParseUint(familyIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的家庭ID"})
+		return
+	}
+
+	templates, err := h.recurringService.GetTemplatesByFamilyID(middleware.CurrentScope(c), uint(familyID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": templates,
+	})
+}
+
+// GetTemplateByID 获取周期交易
+func (h *RecurringHandler) GetTemplateByID(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的周期交易ID"})
+		return
+	}
+
+	template, err := h.recurringService.GetTemplateByID(middleware.CurrentScope(c), uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": template,
+	})
+}
+
+// UpdateTemplate 更新周期交易
+func (h *RecurringHandler) UpdateTemplate(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的周期交易ID"})
+		return
+	}
+
+	var template model.RecurringTemplate
+	if err := c.ShouldBindJSON(&template); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	template.ID = uint(id)
+	if err := h.recurringService.UpdateTemplate(middleware.CurrentScope(c), &template); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "周期交易更新成功",
+		"data":    template,
+	})
+}
+
+// DeleteTemplate 删除周期交易
+func (h *RecurringHandler) DeleteTemplate(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的周期交易ID"})
+		return
+	}
+
+	if err := h.recurringService.DeleteTemplate(middleware.CurrentScope(c), uint(id)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "周期交易删除成功",
+	})
+}
+
+// PauseTemplate 暂停周期交易
+func (h *RecurringHandler) PauseTemplate(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的周期交易ID"})
+		return
+	}
+
+	if err := h.recurringService.PauseTemplate(middleware.CurrentScope(c), uint(id)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "周期交易已暂停",
+	})
+}
+
+// ResumeTemplate 恢复周期交易
+func (h *RecurringHandler) ResumeTemplate(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的周期交易ID"})
+		return
+	}
+
+	if err := h.recurringService.ResumeTemplate(middleware.CurrentScope(c), uint(id)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "周期交易已恢复",
+	})
+}
+
+// GetOccurrences 获取周期交易的生成记录
+func (h *RecurringHandler) GetOccurrences(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的周期交易ID"})
+		return
+	}
+
+	occurrences, err := h.recurringService.GetOccurrences(middleware.CurrentScope(c), uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": occurrences,
+	})
+}
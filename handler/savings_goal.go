@@ -0,0 +1,209 @@
+// handler/savings_goal_handler.go
+package handler
+
+import (
+	"github.com/KQLXK/Family-Finance-System/middleware"
+	"github.com/KQLXK/Family-Finance-System/model"
+	"github.com/KQLXK/Family-Finance-System/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SavingsGoalHandler 储蓄目标处理器
+type SavingsGoalHandler struct {
+	goalService service.SavingsGoalService
+}
+
+// NewSavingsGoalHandler 创建储蓄目标处理器
+func NewSavingsGoalHandler() *SavingsGoalHandler {
+	return &SavingsGoalHandler{
+		goalService: service.NewSavingsGoalService(),
+	}
+}
+
+// CreateGoal 创建储蓄目标
+func (h *SavingsGoalHandler) CreateGoal(c *gin.Context) {
+	familyIDStr := c.Param("id")
+	familyID, err := strconv.ParseUint(familyIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的家庭ID"})
+		return
+	}
+
+	var goal model.SavingsGoal
+	if err := c.ShouldBindJSON(&goal); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+	goal.FamilyID = uint(familyID)
+
+	if err := h.goalService.CreateGoal(middleware.CurrentScope(c), &goal); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "储蓄目标创建成功",
+		"data":    goal,
+	})
+}
+
+// GetGoalsByFamilyID 获取家庭的储蓄目标及进度
+func (h *SavingsGoalHandler) GetGoalsByFamilyID(c *gin.Context) {
+	familyIDStr := c.Param("id")
+	familyID, err := strconv.ParseUint(familyIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的家庭ID"})
+		return
+	}
+
+	goals, err := h.goalService.GetGoalsByFamilyID(middleware.CurrentScope(c), uint(familyID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": goals,
+	})
+}
+
+// GetGoal 获取储蓄目标进度
+func (h *SavingsGoalHandler) GetGoal(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的储蓄目标ID"})
+		return
+	}
+
+	progress, err := h.goalService.GetGoalProgress(middleware.CurrentScope(c), uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": progress,
+	})
+}
+
+// UpdateGoal 更新储蓄目标
+func (h *SavingsGoalHandler) UpdateGoal(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的储蓄目标ID"})
+		return
+	}
+
+	var goal model.SavingsGoal
+	if err := c.ShouldBindJSON(&goal); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	goal.ID = uint(id)
+	if err := h.goalService.UpdateGoal(middleware.CurrentScope(c), &goal); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "储蓄目标更新成功",
+		"data":    goal,
+	})
+}
+
+// DeleteGoal 删除储蓄目标
+func (h *SavingsGoalHandler) DeleteGoal(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的储蓄目标ID"})
+		return
+	}
+
+	if err := h.goalService.DeleteGoal(middleware.CurrentScope(c), uint(id)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "储蓄目标删除成功",
+	})
+}
+
+// AddContribution 手动登记存入或取出
+func (h *SavingsGoalHandler) AddContribution(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的储蓄目标ID"})
+		return
+	}
+
+	var contribution model.GoalContribution
+	if err := c.ShouldBindJSON(&contribution); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	if err := h.goalService.AddContribution(middleware.CurrentScope(c), uint(id), &contribution); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "存入登记成功",
+		"data":    contribution,
+	})
+}
+
+// GetContributions 获取储蓄目标的手动存入记录
+func (h *SavingsGoalHandler) GetContributions(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的储蓄目标ID"})
+		return
+	}
+
+	contributions, err := h.goalService.GetContributions(middleware.CurrentScope(c), uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": contributions,
+	})
+}
+
+// DeleteContribution 删除手动存入记录
+func (h *SavingsGoalHandler) DeleteContribution(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的储蓄目标ID"})
+		return
+	}
+
+	contributionIDStr := c.Param("contributionId")
+	contributionID, err := strconv.ParseUint(contributionIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的存入记录ID"})
+		return
+	}
+
+	if err := h.goalService.DeleteContribution(middleware.CurrentScope(c), uint(id), uint(contributionID)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "存入记录删除成功",
+	})
+}
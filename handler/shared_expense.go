@@ -0,0 +1,181 @@
+// handler/shared_expense_handler.go
+package handler
+
+import (
+	"github.com/KQLXK/Family-Finance-System/middleware"
+	"github.com/KQLXK/Family-Finance-System/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SharedExpenseHandler 共同支出处理器
+type SharedExpenseHandler struct {
+	sharedExpenseService service.SharedExpenseService
+}
+
+// NewSharedExpenseHandler 创建共同支出处理器
+func NewSharedExpenseHandler() *SharedExpenseHandler {
+	return &SharedExpenseHandler{
+		sharedExpenseService: service.NewSharedExpenseService(),
+	}
+}
+
+// ShareTransaction 设置交易的分摊方案
+func (h *SharedExpenseHandler) ShareTransaction(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的交易ID"})
+		return
+	}
+
+	var request service.ShareRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	expense, err := h.sharedExpenseService.ShareTransaction(middleware.CurrentScope(c), uint(id), request)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "分摊设置成功",
+		"data":    expense,
+	})
+}
+
+// GetSharedExpense 获取交易的分摊方案
+func (h *SharedExpenseHandler) GetSharedExpense(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的交易ID"})
+		return
+	}
+
+	expense, err := h.sharedExpenseService.GetSharedExpense(middleware.CurrentScope(c), uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": expense,
+	})
+}
+
+// RemoveSharing 取消交易的分摊
+func (h *SharedExpenseHandler) RemoveSharing(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的交易ID"})
+		return
+	}
+
+	if err := h.sharedExpenseService.RemoveSharing(middleware.CurrentScope(c), uint(id)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "分摊已取消",
+	})
+}
+
+// GetBalances 获取家庭成员共同支出净额
+func (h *SharedExpenseHandler) GetBalances(c *gin.Context) {
+	familyIDStr := c.Param("id")
+	familyID, err := strconv.ParseUint(familyIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的家庭ID"})
+		return
+	}
+
+	balances, err := h.sharedExpenseService.GetBalances(middleware.CurrentScope(c), uint(familyID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": balances,
+	})
+}
+
+// GetSettlementPlan 获取结算建议
+func (h *SharedExpenseHandler) GetSettlementPlan(c *gin.Context) {
+	familyIDStr := c.Param("id")
+	familyID, err := strconv.ParseUint(familyIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的家庭ID"})
+		return
+	}
+
+	payments, err := h.sharedExpenseService.GetSettlementPlan(middleware.CurrentScope(c), uint(familyID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": payments,
+	})
+}
+
+// RecordSettlements 记录结算并生成转账交易
+func (h *SharedExpenseHandler) RecordSettlements(c *gin.Context) {
+	familyIDStr := c.Param("id")
+	familyID, err := strconv.ParseUint(familyIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的家庭ID"})
+		return
+	}
+
+	var request struct {
+		Payments []service.RecordSettlementRequest `json:"payments"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	settlements, err := h.sharedExpenseService.RecordSettlements(middleware.CurrentScope(c), uint(familyID), request.Payments)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+			"data":  settlements,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "结算记录成功",
+		"data":    settlements,
+	})
+}
+
+// GetSettlements 获取家庭结算记录
+func (h *SharedExpenseHandler) GetSettlements(c *gin.Context) {
+	familyIDStr := c.Param("id")
+	familyID, err := strconv.ParseUint(familyIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的家庭ID"})
+		return
+	}
+
+	settlements, err := h.sharedExpenseService.GetSettlements(middleware.CurrentScope(c), uint(familyID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": settlements,
+	})
+}
@@ -0,0 +1,151 @@
+// handler/spending_limit_handler.go
+package handler
+
+import (
+	"github.com/KQLXK/Family-Finance-System/middleware"
+	"github.com/KQLXK/Family-Finance-System/model"
+	"github.com/KQLXK/Family-Finance-System/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SpendingLimitHandler 成员消费限额处理器
+type SpendingLimitHandler struct {
+	limitService service.SpendingLimitService
+}
+
+// NewSpendingLimitHandler 创建成员消费限额处理器
+func NewSpendingLimitHandler() *SpendingLimitHandler {
+	return &SpendingLimitHandler{
+		limitService: service.NewSpendingLimitService(),
+	}
+}
+
+// CreateLimit 创建成员消费限额
+func (h *SpendingLimitHandler) CreateLimit(c *gin.Context) {
+	familyIDStr := c.Param("id")
+	familyID, err := strconv.ParseUint(familyIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的家庭ID"})
+		return
+	}
+
+	var limit model.SpendingLimit
+	if err := c.ShouldBindJSON(&limit); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+	limit.FamilyID = uint(familyID)
+
+	if err := h.limitService.CreateLimit(middleware.CurrentScope(c), &limit); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "消费限额创建成功",
+		"data":    limit,
+	})
+}
+
+// GetLimitsByFamilyID 获取家庭的消费限额列表
+func (h *SpendingLimitHandler) GetLimitsByFamilyID(c *gin.Context) {
+	familyIDStr := c.Param("id")
+	familyID, err := strconv.ParseUint(familyIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的家庭ID"})
+		return
+	}
+
+	limits, err := h.limitService.GetLimitsByFamilyID(middleware.CurrentScope(c), uint(familyID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": limits,
+	})
+}
+
+// GetAllowances 获取成员本期剩余消费额度，可按 memberId 筛选
+func (h *SpendingLimitHandler) GetAllowances(c *gin.Context) {
+	familyIDStr := c.Param("id")
+	familyID, err := strconv.ParseUint(familyIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的家庭ID"})
+		return
+	}
+
+	var memberID uint64
+	if memberIDStr := c.Query("memberId"); memberIDStr != "" {
+		memberID, err = strconv.ParseUint(memberIDStr, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的成员ID"})
+			return
+		}
+	}
+
+	date, ok := progressDate(c)
+	if !ok {
+		return
+	}
+
+	allowances, err := h.limitService.GetAllowances(middleware.CurrentScope(c), uint(familyID), uint(memberID), date)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": allowances,
+	})
+}
+
+// UpdateLimit 更新消费限额
+func (h *SpendingLimitHandler) UpdateLimit(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的消费限额ID"})
+		return
+	}
+
+	var limit model.SpendingLimit
+	if err := c.ShouldBindJSON(&limit); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	limit.ID = uint(id)
+	if err := h.limitService.UpdateLimit(middleware.CurrentScope(c), &limit); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "消费限额更新成功",
+		"data":    limit,
+	})
+}
+
+// DeleteLimit 删除消费限额
+func (h *SpendingLimitHandler) DeleteLimit(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的消费限额ID"})
+		return
+	}
+
+	if err := h.limitService.DeleteLimit(middleware.CurrentScope(c), uint(id)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "消费限额删除成功",
+	})
+}
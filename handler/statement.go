@@ -0,0 +1,151 @@
+// handler/statement_handler.go
+package handler
+
+import (
+	"github.com/KQLXK/Family-Finance-System/middleware"
+	"github.com/KQLXK/Family-Finance-System/service"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StatementHandler 信用卡账单处理器
+type StatementHandler struct {
+	statementService service.StatementService
+}
+
+// NewStatementHandler 创建信用卡账单处理器
+func NewStatementHandler() *StatementHandler {
+	return &StatementHandler{
+		statementService: service.NewStatementService(),
+	}
+}
+
+// GetStatements 获取信用卡最近若干期账单
+func (h *StatementHandler) GetStatements(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的账户ID"})
+		return
+	}
+
+	count, _ := strconv.Atoi(c.DefaultQuery("count", "6"))
+
+	statements, err := h.statementService.GetStatements(middleware.CurrentScope(c), uint(id), count)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": statements,
+	})
+}
+
+// GetStatement 获取某期账单详情
+func (h *StatementHandler) GetStatement(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的账户ID"})
+		return
+	}
+
+	statementDate, err := time.ParseInLocation("2006-01-02", c.Param("date"), time.Local)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的账单日格式，请使用 2006-01-02 格式"})
+		return
+	}
+
+	statement, err := h.statementService.GetStatement(middleware.CurrentScope(c), uint(id), statementDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": statement,
+	})
+}
+
+// SettleStatement 登记账单还款
+func (h *StatementHandler) SettleStatement(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的账户ID"})
+		return
+	}
+
+	statementDate, err := time.ParseInLocation("2006-01-02", c.Param("date"), time.Local)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的账单日格式，请使用 2006-01-02 格式"})
+		return
+	}
+
+	var request service.SettleStatementRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	payment, err := h.statementService.SettleStatement(middleware.CurrentScope(c), uint(id), statementDate, request)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "账单还款登记成功",
+		"data":    payment,
+	})
+}
+
+// DeleteStatementPayment 删除账单还款记录
+func (h *StatementHandler) DeleteStatementPayment(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的账户ID"})
+		return
+	}
+
+	paymentIDStr := c.Param("paymentId")
+	paymentID, err := strconv.ParseUint(paymentIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的还款记录ID"})
+		return
+	}
+
+	if err := h.statementService.DeleteStatementPayment(middleware.CurrentScope(c), uint(id), uint(paymentID)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "还款记录删除成功",
+	})
+}
+
+// GetUpcomingDues 获取家庭各信用卡待还账单
+func (h *StatementHandler) GetUpcomingDues(c *gin.Context) {
+	familyIDStr := c.Param("id")
+	familyID, err := strconv.ParseUint(familyIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的家庭ID"})
+		return
+	}
+
+	dues, err := h.statementService.GetUpcomingDues(middleware.CurrentScope(c), uint(familyID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": dues,
+	})
+}
@@ -2,6 +2,7 @@
 package handler
 
 import (
+	"github.com/KQLXK/Family-Finance-System/middleware"
 	"github.com/KQLXK/Family-Finance-System/model"
 	"github.com/KQLXK/Family-Finance-System/service"
 	"net/http"
@@ -24,13 +25,21 @@ func NewTagHandler() *TagHandler {
 
 // CreateTag 创建标签
 func (h *TagHandler) CreateTag(c *gin.Context) {
+	familyIDStr := c.Param("id")
+	familyID, err := strconv.ParseUint(familyIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的家庭ID"})
+		return
+	}
+
 	var tag model.Tag
 	if err := c.ShouldBindJSON(&tag); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
 		return
 	}
+	tag.FamilyID = uint(familyID)
 
-	if err := h.tagService.CreateTag(&tag); err != nil {
+	if err := h.tagService.CreateTag(middleware.CurrentScope(c), &tag); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -50,7 +59,7 @@ func (h *TagHandler) GetTagByID(c *gin.Context) {
 		return
 	}
 
-	tag, err := h.tagService.GetTagByID(uint(id))
+	tag, err := h.tagService.GetTagByID(middleware.CurrentScope(c), uint(id))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
@@ -70,7 +79,7 @@ func (h *TagHandler) GetTagsByFamilyID(c *gin.Context) {
 		return
 	}
 
-	tags, err := h.tagService.GetTagsByFamilyID(uint(familyID))
+	tags, err := h.tagService.GetTagsByFamilyID(middleware.CurrentScope(c), uint(familyID))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -96,7 +105,7 @@ func (h *TagHandler) GetTagsByType(c *gin.Context) {
 		return
 	}
 
-	tags, err := h.tagService.GetTagsByType(uint(familyID), tagType)
+	tags, err := h.tagService.GetTagsByType(middleware.CurrentScope(c), uint(familyID), tagType)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -109,7 +118,7 @@ func (h *TagHandler) GetTagsByType(c *gin.Context) {
 
 // GetAllTags 获取所有标签
 func (h *TagHandler) GetAllTags(c *gin.Context) {
-	tags, err := h.tagService.GetAllTags()
+	tags, err := h.tagService.GetAllTags(middleware.CurrentScope(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -136,7 +145,7 @@ func (h *TagHandler) UpdateTag(c *gin.Context) {
 	}
 
 	tag.ID = uint(id)
-	if err := h.tagService.UpdateTag(&tag); err != nil {
+	if err := h.tagService.UpdateTag(middleware.CurrentScope(c), &tag); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -156,7 +165,7 @@ func (h *TagHandler) DeleteTag(c *gin.Context) {
 		return
 	}
 
-	if err := h.tagService.DeleteTag(uint(id)); err != nil {
+	if err := h.tagService.DeleteTag(middleware.CurrentScope(c), uint(id)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -2,6 +2,7 @@
 package handler
 
 import (
+	"github.com/KQLXK/Family-Finance-System/middleware"
 	"github.com/KQLXK/Family-Finance-System/model"
 	"github.com/KQLXK/Family-Finance-System/service"
 	"net/http"
@@ -28,13 +29,17 @@ func (h *TransactionHandler) CreateTransaction(c *gin.Context) {
 	var transaction model.Transaction
 	familyIdstr := c.Param("id")
 	familyId, _ := strconv.ParseUint(familyIdstr, 10, 32)
-	transaction.FamilyID = uint(familyId)
 	if err := c.ShouldBindJSON(&transaction); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
 		return
 	}
+	transaction.FamilyID = uint(familyId)
 
-	if err := h.transactionService.CreateTransaction(&transaction); err != nil {
+	if !h.checkBookingMember(c, &transaction) {
+		return
+	}
+
+	if err := h.transactionService.CreateTransaction(middleware.CurrentScope(c), &transaction); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -54,7 +59,7 @@ func (h *TransactionHandler) GetTransactionByID(c *gin.Context) {
 		return
 	}
 
-	transaction, err := h.transactionService.GetTransactionByID(uint(id))
+	transaction, err := h.transactionService.GetTransactionByID(middleware.CurrentScope(c), uint(id))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
@@ -65,6 +70,26 @@ func (h *TransactionHandler) GetTransactionByID(c *gin.Context) {
 	})
 }
 
+// GetRefunds 获取支出交易的退款记录
+func (h *TransactionHandler) GetRefunds(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的交易ID"})
+		return
+	}
+
+	refunds, err := h.transactionService.GetRefunds(middleware.CurrentScope(c), uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": refunds,
+	})
+}
+
 // GetTransactionsByFamilyID 根据家庭ID获取交易列表
 func (h *TransactionHandler) GetTransactionsByFamilyID(c *gin.Context) {
 	familyIDStr := c.Param("id")
@@ -104,8 +129,14 @@ func (h *TransactionHandler) GetTransactionsByFamilyID(c *gin.Context) {
 	if paymentMethod := c.Query("paymentMethod"); paymentMethod != "" {
 		filters["payment_method"] = paymentMethod
 	}
+	if accountIDStr := c.Query("accountId"); accountIDStr != "" {
+		accountID, err := strconv.ParseUint(accountIDStr, 10, 32)
+		if err == nil {
+			filters["account_id"] = uint(accountID)
+		}
+	}
 
-	transactions, total, err := h.transactionService.GetTransactionsByFamilyID(uint(familyID), page, pageSize, filters)
+	transactions, total, err := h.transactionService.GetTransactionsByFamilyID(middleware.CurrentScope(c), uint(familyID), page, pageSize, filters)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -173,7 +204,7 @@ func (h *TransactionHandler) GetTransactionsByTimeRange(c *gin.Context) {
 		}
 	}
 
-	transactions, err := h.transactionService.GetTransactionsByTimeRange(uint(familyID), startTime, endTime, filters)
+	transactions, err := h.transactionService.GetTransactionsByTimeRange(middleware.CurrentScope(c), uint(familyID), startTime, endTime, filters)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -199,8 +230,12 @@ func (h *TransactionHandler) UpdateTransaction(c *gin.Context) {
 		return
 	}
 
+	if !h.checkBookingMember(c, &transaction) {
+		return
+	}
+
 	transaction.ID = uint(id)
-	if err := h.transactionService.UpdateTransaction(&transaction); err != nil {
+	if err := h.transactionService.UpdateTransaction(middleware.CurrentScope(c), &transaction); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -220,7 +255,7 @@ func (h *TransactionHandler) DeleteTransaction(c *gin.Context) {
 		return
 	}
 
-	if err := h.transactionService.DeleteTransaction(uint(id)); err != nil {
+	if err := h.transactionService.DeleteTransaction(middleware.CurrentScope(c), uint(id)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -230,6 +265,103 @@ func (h *TransactionHandler) DeleteTransaction(c *gin.Context) {
 	})
 }
 
+// GetPendingTransactions 获取家庭待审批的交易
+func (h *TransactionHandler) GetPendingTransactions(c *gin.Context) {
+	familyIDStr := c.Param("id")
+	familyID, err := strconv.ParseUint(familyIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的家庭ID"})
+		return
+	}
+
+	transactions, err := h.transactionService.GetPendingTransactions(middleware.CurrentScope(c), uint(familyID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": transactions,
+	})
+}
+
+// GetPlannedTransactions 获取家庭的计划交易，可用 until 指定截止日期
+func (h *TransactionHandler) GetPlannedTransactions(c *gin.Context) {
+	familyIDStr := c.Param("id")
+	familyID, err := strconv.ParseUint(familyIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的家庭ID"})
+		return
+	}
+
+	until := time.Now().AddDate(2, 0, 0)
+	if untilStr := c.Query("until"); untilStr != "" {
+		date, err := time.ParseInLocation("2006-01-02", untilStr, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的截止日期格式，请使用 2006-01-02 格式"})
+			return
+		}
+		until = date.AddDate(0, 0, 1).Add(-time.Second)
+	}
+
+	transactions, err := h.transactionService.GetPlannedTransactions(middleware.CurrentScope(c), uint(familyID), until)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": transactions,
+	})
+}
+
+// ApproveTransaction 审批通过交易
+func (h *TransactionHandler) ApproveTransaction(c *gin.Context) {
+	h.reviewTransaction(c, true)
+}
+
+// RejectTransaction 拒绝交易
+func (h *TransactionHandler) RejectTransaction(c *gin.Context) {
+	h.reviewTransaction(c, false)
+}
+
+// reviewTransaction 处理审批请求，备注可选
+func (h *TransactionHandler) reviewTransaction(c *gin.Context, approve bool) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的交易ID"})
+		return
+	}
+
+	var request struct {
+		Note string `json:"note"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+			return
+		}
+	}
+
+	scope := middleware.CurrentScope(c)
+	message := "交易已审批通过"
+	if approve {
+		err = h.transactionService.ApproveTransaction(scope, uint(id), request.Note)
+	} else {
+		err = h.transactionService.RejectTransaction(scope, uint(id), request.Note)
+		message = "交易已拒绝"
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+	})
+}
+
 // AddTagToTransaction 为交易添加标签
 func (h *TransactionHandler) AddTagToTransaction(c *gin.Context) {
 	transactionIDStr := c.Param("id")
@@ -247,7 +379,7 @@ func (h *TransactionHandler) AddTagToTransaction(c *gin.Context) {
 		return
 	}
 
-	if err := h.transactionService.AddTagToTransaction(uint(transactionID), request.TagID); err != nil {
+	if err := h.transactionService.AddTagToTransaction(middleware.CurrentScope(c), uint(transactionID), request.TagID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -273,7 +405,7 @@ func (h *TransactionHandler) RemoveTagFromTransaction(c *gin.Context) {
 		return
 	}
 
-	if err := h.transactionService.RemoveTagFromTransaction(uint(transactionID), uint(tagID)); err != nil {
+	if err := h.transactionService.RemoveTagFromTransaction(middleware.CurrentScope(c), uint(transactionID), uint(tagID)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -325,7 +457,7 @@ func (h *TransactionHandler) GetTransactionSummaryByCategory(c *gin.Context) {
 		transactionType = model.Expense // 默认统计支出
 	}
 
-	summary, err := h.transactionService.GetTransactionSummaryByCategory(uint(familyID), startTime, endTime, transactionType)
+	summary, err := h.transactionService.GetTransactionSummaryByCategory(middleware.CurrentScope(c), uint(familyID), startTime, endTime, transactionType)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -375,7 +507,7 @@ func (h *TransactionHandler) GetTransactionSummaryByTime(c *gin.Context) {
 	// 获取分组方式
 	groupBy := c.DefaultQuery("groupBy", "month")
 
-	summary, err := h.transactionService.GetTransactionSummaryByTime(uint(familyID), startTime, endTime, groupBy)
+	summary, err := h.transactionService.GetTransactionSummaryByTime(middleware.CurrentScope(c), uint(familyID), startTime, endTime, groupBy)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -385,3 +517,17 @@ func (h *TransactionHandler) GetTransactionSummaryByTime(c *gin.Context) {
 		"data": summary,
 	})
 }
+
+// checkBookingMember 非管理员只能以自己的名义记账，未指定记账成员时默认为当前成员
+func (h *TransactionHandler) checkBookingMember(c *gin.Context, transaction *model.Transaction) bool {
+	currentMemberID := middleware.CurrentMemberID(c)
+	if transaction.MemberID == 0 {
+		transaction.MemberID = currentMemberID
+	}
+
+	if middleware.CurrentRole(c) != model.RoleAdmin && transaction.MemberID != currentMemberID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "只能以自己的名义记账"})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"github.com/KQLXK/Family-Finance-System/model"
+	"github.com/KQLXK/Family-Finance-System/service"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 上下文中保存当前调用者信息的键
+const (
+	ContextMemberID    = "member_id"
+	ContextFamilyID    = "family_id"
+	ContextRole        = "role"
+	ContextTokenScopes = "token_scopes" // 仅个人访问令牌请求存在
+)
+
+// Auth 校验 Authorization: Bearer <token> 并将调用者信息写入上下文。
+// 令牌可以是登录签发的 JWT，也可以是以 ffs_ 开头的个人访问令牌；
+// 个人访问令牌只能访问通过 RequireScope 声明了权限范围的路由。
+func Auth() gin.HandlerFunc {
+	authService := service.NewAuthService()
+	apiTokenService := service.NewAPITokenService()
+
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		token := strings.TrimSpace(strings.TrimPrefix(header, "Bearer "))
+		if header == "" || token == "" || token == header {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "未登录或缺少令牌"})
+			return
+		}
+
+		var member *model.Member
+		var err error
+		if strings.HasPrefix(token, service.APITokenPrefix) {
+			var scopes []model.TokenScope
+			member, scopes, err = apiTokenService.Authenticate(token)
+			if err == nil {
+				c.Set(ContextTokenScopes, scopes)
+			}
+		} else {
+			member, err = authService.Authenticate(token)
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Set(ContextMemberID, member.ID)
+		c.Set(ContextFamilyID, member.FamilyID)
+		c.Set(ContextRole, member.Role)
+		c.Next()
+	}
+}
+
+// CurrentMemberID 获取当前调用者的成员ID
+func CurrentMemberID(c *gin.Context) uint {
+	return c.GetUint(ContextMemberID)
+}
+
+// CurrentFamilyID 获取当前调用者所属的家庭ID
+func CurrentFamilyID(c *gin.Context) uint {
+	return c.GetUint(ContextFamilyID)
+}
+
+// CurrentRole 获取当前调用者的角色
+func CurrentRole(c *gin.Context) model.MemberRole {
+	role, _ := c.Get(ContextRole)
+	r, _ := role.(model.MemberRole)
+	return r
+}
+
+// CurrentScope 获取当前调用者的数据访问范围
+func CurrentScope(c *gin.Context) service.Scope {
+	return service.Scope{
+		MemberID: CurrentMemberID(c),
+		FamilyID: CurrentFamilyID(c),
+		Role:     CurrentRole(c),
+	}
+}
@@ -0,0 +1,163 @@
+package middleware
+
+import (
+	"github.com/KQLXK/Family-Finance-System/model"
+	"github.com/KQLXK/Family-Finance-System/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 常用角色组合
+var (
+	// AllRoles 所有角色均可访问（只读操作）
+	AllRoles = []model.MemberRole{model.RoleAdmin, model.RoleMember, model.RoleViewer}
+	// WriterRoles 可以记账的角色
+	WriterRoles = []model.MemberRole{model.RoleAdmin, model.RoleMember}
+	// AdminRoles 仅管理员
+	AdminRoles = []model.MemberRole{model.RoleAdmin}
+)
+
+// contextScopeChecked 标记路由已通过 RequireScope 校验
+const contextScopeChecked = "scope_checked"
+
+// RequireScope 声明路由所需的个人访问令牌权限范围，登录会话不受限制；须放在角色校验之前
+func RequireScope(scope model.TokenScope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, isAPIToken := c.Get(ContextTokenScopes)
+		if !isAPIToken {
+			c.Next()
+			return
+		}
+
+		scopes, _ := value.([]model.TokenScope)
+		for _, s := range scopes {
+			if s == scope {
+				c.Set(contextScopeChecked, true)
+				c.Next()
+				return
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "访问令牌缺少权限范围: " + string(scope)})
+	}
+}
+
+// apiTokenDenied 个人访问令牌访问未声明权限范围的路由时拒绝请求
+func apiTokenDenied(c *gin.Context) bool {
+	if _, isAPIToken := c.Get(ContextTokenScopes); isAPIToken && !c.GetBool(contextScopeChecked) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "访问令牌不能访问该接口"})
+		return true
+	}
+	return false
+}
+
+// RequireRole 仅允许指定角色访问，须在 Auth 之后使用
+func RequireRole(roles ...model.MemberRole) gin.HandlerFunc {
+	allowed := make(map[model.MemberRole]bool, len(roles))
+	for _, role := range roles {
+		allowed[role] = true
+	}
+
+	return func(c *gin.Context) {
+		if apiTokenDenied(c) {
+			return
+		}
+		if !allowed[CurrentRole(c)] {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "权限不足"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// Read 只读权限
+func Read() gin.HandlerFunc {
+	return RequireRole(AllRoles...)
+}
+
+// Write 记账权限
+func Write() gin.HandlerFunc {
+	return RequireRole(WriterRoles...)
+}
+
+// Admin 管理权限
+func Admin() gin.HandlerFunc {
+	return RequireRole(AdminRoles...)
+}
+
+// AdminOrSelf 管理员或路径 :id 指向的成员本人可以访问
+func AdminOrSelf() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if apiTokenDenied(c) {
+			return
+		}
+		if CurrentRole(c) == model.RoleAdmin {
+			c.Next()
+			return
+		}
+
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil || uint(id) != CurrentMemberID(c) || CurrentRole(c) == model.RoleViewer {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "权限不足"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// TransactionOwner 管理员可修改任意交易，普通成员只能修改自己记录的交易
+func TransactionOwner() gin.HandlerFunc {
+	transactionService := service.NewTransactionService()
+
+	return func(c *gin.Context) {
+		switch CurrentRole(c) {
+		case model.RoleAdmin:
+			c.Next()
+			return
+		case model.RoleMember:
+		default:
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "权限不足"})
+			return
+		}
+
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "无效的交易ID"})
+			return
+		}
+
+		transaction, err := transactionService.GetTransactionByID(CurrentScope(c), uint(id))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if transaction.MemberID != CurrentMemberID(c) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "只能修改自己记录的交易"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// FamilyScope 路径 :id 指向的家庭必须是调用者所属的家庭，没有 :id 参数的路由直接放行
+func FamilyScope() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		idStr := c.Param("id")
+		if idStr == "" {
+			c.Next()
+			return
+		}
+
+		id, err := strconv.ParseUint(idStr, 10, 32)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "无效的家庭ID"})
+			return
+		}
+		if uint(id) != CurrentFamilyID(c) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": service.ErrForbidden.Error()})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,180 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"github.com/KQLXK/Family-Finance-System/database"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"log"
+	"sync"
+	"time"
+)
+
+// AccountDao 账户数据访问对象
+type AccountDao struct{}
+
+var (
+	accountOnce sync.Once
+	accountDao  *AccountDao
+)
+
+// accountNetAmountExpr 交易对账户余额的净影响：收入和退款增加，支出减少，
+// 转账从转出账户扣除金额和手续费，转入账户增加金额。四个占位符均为账户ID
+const accountNetAmountExpr = `COALESCE(SUM(CASE
+	WHEN account_id = ? AND type IN ('income', 'refund') THEN amount
+	WHEN account_id = ? AND type = 'expense' THEN -amount
+	WHEN account_id = ? AND type = 'transfer' THEN -(amount + fee)
+	WHEN to_account_id = ? THEN amount
+	ELSE 0 END), 0)`
+
+// NewAccountDaoInstance 返回 AccountDao 单例实例
+func NewAccountDaoInstance() *AccountDao {
+	accountOnce.Do(func() {
+		accountDao = &AccountDao{}
+	})
+	return accountDao
+}
+
+// CreateAccount 创建账户
+func (AccountDao) CreateAccount(account *Account) error {
+	if err := database.DB.Create(account).Error; err != nil {
+		log.Printf("创建账户失败: %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetAccountByID 根据ID获取账户
+func (AccountDao) GetAccountByID(id uint) (*Account, error) {
+	var account Account
+	if err := database.DB.First(&account, id).Error; err != nil {
+		log.Printf("获取账户失败 ID=%d: %v", id, err)
+		return nil, err
+	}
+	return &account, nil
+}
+
+// GetAccountByName 根据名称获取家庭账户，不存在时返回 nil
+func (AccountDao) GetAccountByName(familyID uint, name string) (*Account, error) {
+	var account Account
+	err := database.DB.Where("family_id = ? AND name = ?", familyID, name).First(&account).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("获取账户失败 FamilyID=%d, Name=%s: %v", familyID, name, err)
+		return nil, err
+	}
+	return &account, nil
+}
+
+// GetAccountsByFamilyID 根据家庭ID获取账户列表
+func (AccountDao) GetAccountsByFamilyID(familyID uint, includeArchived bool) ([]Account, error) {
+	var accounts []Account
+	query := database.DB.Where("family_id = ?", familyID)
+	if !includeArchived {
+		query = query.Where("archived = ?", false)
+	}
+	if err := query.Order("id").Find(&accounts).Error; err != nil {
+		log.Printf("获取家庭账户失败 FamilyID=%d: %v", familyID, err)
+		return nil, err
+	}
+	return accounts, nil
+}
+
+// UpdateAccount 更新账户信息
+func (AccountDao) UpdateAccount(account *Account) error {
+	if err := database.DB.Model(account).
+		Select("name", "type", "opening_balance", "currency", "archived", "statement_day", "due_day", "updated_at").
+		Updates(account).Error; err != nil {
+		log.Printf("更新账户失败 ID=%d: %v", account.ID, err)
+		return err
+	}
+	return nil
+}
+
+// DeleteAccount 删除账户
+func (AccountDao) DeleteAccount(id uint) error {
+	if err := database.DB.Delete(&Account{}, id).Error; err != nil {
+		log.Printf("删除账户失败 ID=%d: %v", id, err)
+		return err
+	}
+	return nil
+}
+
+// CountTransactionsByAccountID 统计关联该账户的交易数量（包括已删除的交易）
+func (AccountDao) CountTransactionsByAccountID(accountID uint) (int64, error) {
+	var count int64
+	if err := database.DB.Unscoped().Model(&Transaction{}).Where("account_id = ? OR to_account_id = ?", accountID, accountID).Count(&count).Error; err != nil {
+		log.Printf("统计账户交易失败 AccountID=%d: %v", accountID, err)
+		return 0, err
+	}
+	return count, nil
+}
+
+// GetAccountNetAmount 统计截至指定时间（含）有效交易对账户余额的净影响
+func (AccountDao) GetAccountNetAmount(accountID uint, until time.Time) (decimal.Decimal, error) {
+	var net decimal.Decimal
+	if err := database.DB.Model(&Transaction{}).
+		Select(accountNetAmountExpr, accountID, accountID, accountID, accountID).
+		Where("(account_id = ? OR to_account_id = ?) AND status = ? AND transaction_time <= ?", accountID, accountID, Valid, until).
+		Row().Scan(&net); err != nil {
+		log.Printf("统计账户余额失败 AccountID=%d: %v", accountID, err)
+		return decimal.Zero, err
+	}
+	return net, nil
+}
+
+// GetAccountNetAmountByTime 按时间分组统计有效交易对账户余额的净影响
+func (AccountDao) GetAccountNetAmountByTime(accountID uint, startTime, endTime time.Time, groupBy string) (map[string]decimal.Decimal, error) {
+	summary := make(map[string]decimal.Decimal)
+
+	rows, err := database.DB.Model(&Transaction{}).
+		Select(fmt.Sprintf("DATE_FORMAT(transaction_time, '%s') as time_period, %s", groupTimeFormat(groupBy), accountNetAmountExpr),
+			accountID, accountID, accountID, accountID).
+		Where("(account_id = ? OR to_account_id = ?) AND status = ? AND transaction_time BETWEEN ? AND ?", accountID, accountID, Valid, startTime, endTime).
+		Group("time_period").
+		Rows()
+	if err != nil {
+		log.Printf("按时间统计账户余额失败 AccountID=%d: %v", accountID, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var timePeriod string
+		var netAmount decimal.Decimal
+		if err := rows.Scan(&timePeriod, &netAmount); err != nil {
+			log.Printf("扫描统计结果失败: %v", err)
+			continue
+		}
+		summary[timePeriod] = netAmount
+	}
+
+	return summary, nil
+}
+
+// GetUnlinkedPaymentMethods 获取家庭中尚未关联账户的交易所使用的支付方式
+func (AccountDao) GetUnlinkedPaymentMethods(familyID uint) ([]string, error) {
+	var methods []string
+	if err := database.DB.Model(&Transaction{}).
+		Where("family_id = ? AND account_id IS NULL AND payment_method <> ''", familyID).
+		Distinct().Pluck("payment_method", &methods).Error; err != nil {
+		log.Printf("获取支付方式失败 FamilyID=%d: %v", familyID, err)
+		return nil, err
+	}
+	return methods, nil
+}
+
+// LinkTransactionsByPaymentMethod 将尚未关联账户且使用指定支付方式的交易关联到账户，返回关联数量
+func (AccountDao) LinkTransactionsByPaymentMethod(familyID uint, paymentMethod string, accountID uint) (int64, error) {
+	result := database.DB.Model(&Transaction{}).
+		Where("family_id = ? AND account_id IS NULL AND payment_method = ?", familyID, paymentMethod).
+		Update("account_id", accountID)
+	if result.Error != nil {
+		log.Printf("关联交易账户失败 FamilyID=%d, PaymentMethod=%s: %v", familyID, paymentMethod, result.Error)
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
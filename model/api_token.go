@@ -0,0 +1,82 @@
+package model
+
+import (
+	"github.com/KQLXK/Family-Finance-System/database"
+	"log"
+	"sync"
+	"time"
+)
+
+// APITokenDao 个人访问令牌数据访问对象
+type APITokenDao struct{}
+
+var (
+	apiTokenOnce sync.Once
+	apiTokenDao  *APITokenDao
+)
+
+// NewAPITokenDaoInstance 返回 APITokenDao 单例实例
+func NewAPITokenDaoInstance() *APITokenDao {
+	apiTokenOnce.Do(func() {
+		apiTokenDao = &APITokenDao{}
+	})
+	return apiTokenDao
+}
+
+// CreateAPIToken 创建个人访问令牌
+func (APITokenDao) CreateAPIToken(token *APIToken) error {
+	if err := database.DB.Create(token).Error; err != nil {
+		log.Printf("创建访问令牌失败: %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetAPITokenByID 根据ID获取个人访问令牌
+func (APITokenDao) GetAPITokenByID(id uint) (*APIToken, error) {
+	var token APIToken
+	if err := database.DB.First(&token, id).Error; err != nil {
+		log.Printf("获取访问令牌失败 ID=%d: %v", id, err)
+		return nil, err
+	}
+	return &token, nil
+}
+
+// GetAPITokenByHash 根据令牌哈希获取个人访问令牌
+func (APITokenDao) GetAPITokenByHash(tokenHash string) (*APIToken, error) {
+	var token APIToken
+	if err := database.DB.Where("token_hash = ?", tokenHash).First(&token).Error; err != nil {
+		log.Printf("获取访问令牌失败: %v", err)
+		return nil, err
+	}
+	return &token, nil
+}
+
+// GetAPITokensByMemberID 根据成员ID获取个人访问令牌列表
+func (APITokenDao) GetAPITokensByMemberID(memberID uint) ([]APIToken, error) {
+	var tokens []APIToken
+	if err := database.DB.Where("member_id = ?", memberID).Order("created_at DESC").Find(&tokens).Error; err != nil {
+		log.Printf("获取成员访问令牌失败 MemberID=%d: %v", memberID, err)
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// TouchAPIToken 更新令牌最近使用时间
+func (APITokenDao) TouchAPIToken(id uint, usedAt time.Time) error {
+	if err := database.DB.Model(&APIToken{}).Where("id = ?", id).Update("last_used_at", usedAt).Error; err != nil {
+		log.Printf("更新访问令牌使用时间失败 ID=%d: %v", id, err)
+		return err
+	}
+	return nil
+}
+
+// RevokeAPIToken 撤销个人访问令牌
+func (APITokenDao) RevokeAPIToken(id uint) error {
+	if err := database.DB.Model(&APIToken{}).Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		log.Printf("撤销访问令牌失败 ID=%d: %v", id, err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package model
+
+import (
+	"fmt"
+	"github.com/KQLXK/Family-Finance-System/database"
+	"log"
+	"sync"
+	"time"
+)
+
+// AuditLogDao 审计日志数据访问对象
+type AuditLogDao struct{}
+
+var (
+	auditLogOnce sync.Once
+	auditLogDao  *AuditLogDao
+)
+
+// NewAuditLogDaoInstance 返回 AuditLogDao 单例实例
+func NewAuditLogDaoInstance() *AuditLogDao {
+	auditLogOnce.Do(func() {
+		auditLogDao = &AuditLogDao{}
+	})
+	return auditLogDao
+}
+
+// CreateAuditLog 追加审计日志
+func (AuditLogDao) CreateAuditLog(auditLog *AuditLog) error {
+	if err := database.DB.Create(auditLog).Error; err != nil {
+		log.Printf("写入审计日志失败: %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetAuditLogsByFamilyID 分页获取家庭的审计日志，startTime/endTime 为零值时不限制
+func (AuditLogDao) GetAuditLogsByFamilyID(familyID uint, page, pageSize int, filters map[string]interface{}, startTime, endTime time.Time) ([]AuditLog, int64, error) {
+	var auditLogs []AuditLog
+	var total int64
+
+	// 构建查询
+	query := database.DB.Model(&AuditLog{}).Where("family_id = ?", familyID)
+
+	// 添加过滤条件
+	for key, value := range filters {
+		query = query.Where(fmt.Sprintf("%s = ?", key), value)
+	}
+	if !startTime.IsZero() {
+		query = query.Where("created_at >= ?", startTime)
+	}
+	if !endTime.IsZero() {
+		query = query.Where("created_at <= ?", endTime)
+	}
+
+	// 获取总数
+	if err := query.Count(&total).Error; err != nil {
+		log.Printf("获取审计日志总数失败 FamilyID=%d: %v", familyID, err)
+		return nil, 0, err
+	}
+
+	// 获取分页数据
+	offset := (page - 1) * pageSize
+	if err := query.Order("id DESC").Offset(offset).Limit(pageSize).Find(&auditLogs).Error; err != nil {
+		log.Printf("获取审计日志失败 FamilyID=%d: %v", familyID, err)
+		return nil, 0, err
+	}
+
+	return auditLogs, total, nil
+}
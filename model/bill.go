@@ -0,0 +1,176 @@
+package model
+
+import (
+	"errors"
+	"github.com/KQLXK/Family-Finance-System/database"
+	"gorm.io/gorm"
+	"log"
+	"sync"
+	"time"
+)
+
+// BillDao 账单数据访问对象
+type BillDao struct{}
+
+var (
+	billOnce sync.Once
+	billDao  *BillDao
+)
+
+// NewBillDaoInstance 返回 BillDao 单例实例
+func NewBillDaoInstance() *BillDao {
+	billOnce.Do(func() {
+		billDao = &BillDao{}
+	})
+	return billDao
+}
+
+// CreateBill 创建账单
+func (BillDao) CreateBill(bill *Bill) error {
+	if err := database.DB.Create(bill).Error; err != nil {
+		log.Printf("创建账单失败: %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetBillByID 根据ID获取账单
+func (BillDao) GetBillByID(id uint) (*Bill, error) {
+	var bill Bill
+	if err := database.DB.First(&bill, id).Error; err != nil {
+		log.Printf("获取账单失败 ID=%d: %v", id, err)
+		return nil, err
+	}
+	return &bill, nil
+}
+
+// GetBillByTransactionID 获取交易支付的账单，不存在时返回 nil
+func (BillDao) GetBillByTransactionID(transactionID uint) (*Bill, error) {
+	var bill Bill
+	err := database.DB.Where("transaction_id = ?", transactionID).First(&bill).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("获取账单失败 TransactionID=%d: %v", transactionID, err)
+		return nil, err
+	}
+	return &bill, nil
+}
+
+// GetUnpaidBills 获取家庭到期日在 [from, to] 内的未付账单，按到期日排序；from 为零值时不限开始日期
+func (BillDao) GetUnpaidBills(familyID uint, from, to time.Time) ([]Bill, error) {
+	var bills []Bill
+	query := database.DB.Where("family_id = ? AND status = ? AND due_date <= ?", familyID, BillUnpaid, to.Format("2006-01-02"))
+	if !from.IsZero() {
+		query = query.Where("due_date >= ?", from.Format("2006-01-02"))
+	}
+	if err := query.Order("due_date, id").Find(&bills).Error; err != nil {
+		log.Printf("获取未付账单失败 FamilyID=%d: %v", familyID, err)
+		return nil, err
+	}
+	return bills, nil
+}
+
+// GetPaidBills 获取家庭付款时间在 [start, end] 内的已付账单，按付款时间倒序
+func (BillDao) GetPaidBills(familyID uint, start, end time.Time) ([]Bill, error) {
+	var bills []Bill
+	if err := database.DB.Where("family_id = ? AND status = ? AND paid_at BETWEEN ? AND ?", familyID, BillPaid, start, end).
+		Order("paid_at DESC, id DESC").
+		Find(&bills).Error; err != nil {
+		log.Printf("获取已付账单失败 FamilyID=%d: %v", familyID, err)
+		return nil, err
+	}
+	return bills, nil
+}
+
+// UpdateBill 更新账单信息
+func (BillDao) UpdateBill(bill *Bill) error {
+	err := database.DB.Model(bill).
+		Select("name", "payee", "category_id", "expected_amount", "currency", "due_date", "reminder_days", "reminded_at", "note", "updated_at").
+		Updates(bill).Error
+	if err != nil {
+		log.Printf("更新账单失败 ID=%d: %v", bill.ID, err)
+		return err
+	}
+	return nil
+}
+
+// MarkBillPaid 将未付账单标记为已付，账单已付时返回 false
+func (BillDao) MarkBillPaid(id, transactionID uint, paidAt time.Time) (bool, error) {
+	result := database.DB.Model(&Bill{}).
+		Where("id = ? AND status = ?", id, BillUnpaid).
+		Updates(map[string]interface{}{
+			"status":         BillPaid,
+			"transaction_id": transactionID,
+			"paid_at":        paidAt,
+			"updated_at":     time.Now(),
+		})
+	if result.Error != nil {
+		log.Printf("标记账单已付失败 ID=%d: %v", id, result.Error)
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// MarkBillUnpaid 撤销账单付款记录，账单未付时返回 false
+func (BillDao) MarkBillUnpaid(id uint) (bool, error) {
+	result := database.DB.Model(&Bill{}).
+		Where("id = ? AND status = ?", id, BillPaid).
+		Updates(map[string]interface{}{
+			"status":         BillUnpaid,
+			"transaction_id": nil,
+			"paid_at":        nil,
+			"updated_at":     time.Now(),
+		})
+	if result.Error != nil {
+		log.Printf("撤销账单付款失败 ID=%d: %v", id, result.Error)
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// DeleteBill 删除账单
+func (BillDao) DeleteBill(id uint) error {
+	if err := database.DB.Delete(&Bill{}, id).Error; err != nil {
+		log.Printf("删除账单失败 ID=%d: %v", id, err)
+		return err
+	}
+	return nil
+}
+
+// GetBillsToRemind 获取已到提醒日期且尚未提醒的未付账单
+func (BillDao) GetBillsToRemind(now time.Time) ([]Bill, error) {
+	var bills []Bill
+	if err := database.DB.
+		Where("status = ? AND reminded_at IS NULL AND DATE_SUB(due_date, INTERVAL reminder_days DAY) <= ?", BillUnpaid, now.Format("2006-01-02")).
+		Order("due_date, id").
+		Find(&bills).Error; err != nil {
+		log.Printf("获取待提醒账单失败: %v", err)
+		return nil, err
+	}
+	return bills, nil
+}
+
+// MarkBillReminded 记录账单已提醒，已被其他任务提醒时返回 false
+func (BillDao) MarkBillReminded(id uint, remindedAt time.Time) (bool, error) {
+	result := database.DB.Model(&Bill{}).
+		Where("id = ? AND reminded_at IS NULL", id).
+		Update("reminded_at", remindedAt)
+	if result.Error != nil {
+		log.Printf("记录账单提醒失败 ID=%d: %v", id, result.Error)
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// UnmarkBillReminded 提醒发送失败时撤销以 remindedAt 记录的提醒，下次定时任务重新提醒
+func (BillDao) UnmarkBillReminded(id uint, remindedAt time.Time) error {
+	if err := database.DB.Model(&Bill{}).
+		Where("id = ? AND reminded_at = ?", id, remindedAt).
+		Update("reminded_at", nil).Error; err != nil {
+		log.Printf("撤销账单提醒记录失败 ID=%d: %v", id, err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package model
+
+import (
+	"errors"
+	"github.com/KQLXK/Family-Finance-System/database"
+	"gorm.io/gorm"
+	"log"
+	"sync"
+	"time"
+)
+
+// BudgetDao 预算数据访问对象
+type BudgetDao struct{}
+
+var (
+	budgetOnce sync.Once
+	budgetDao  *BudgetDao
+)
+
+// NewBudgetDaoInstance 返回 BudgetDao 单例实例
+func NewBudgetDaoInstance() *BudgetDao {
+	budgetOnce.Do(func() {
+		budgetDao = &BudgetDao{}
+	})
+	return budgetDao
+}
+
+// CreateBudget 创建预算
+func (BudgetDao) CreateBudget(budget *Budget) error {
+	if err := database.DB.Create(budget).Error; err != nil {
+		log.Printf("创建预算失败: %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetBudgetByID 根据ID获取预算
+func (BudgetDao) GetBudgetByID(id uint) (*Budget, error) {
+	var budget Budget
+	if err := database.DB.Preload("Category").First(&budget, id).Error; err != nil {
+		log.Printf("获取预算失败 ID=%d: %v", id, err)
+		return nil, err
+	}
+	return &budget, nil
+}
+
+// GetBudgetByCategory 获取家庭某分类某周期的预算，不存在时返回 nil
+func (BudgetDao) GetBudgetByCategory(familyID, categoryID uint, period Period) (*Budget, error) {
+	var budget Budget
+	err := database.DB.Where("family_id = ? AND category_id = ? AND period = ?", familyID, categoryID, period).First(&budget).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("获取预算失败 FamilyID=%d, CategoryID=%d: %v", familyID, categoryID, err)
+		return nil, err
+	}
+	return &budget, nil
+}
+
+// GetBudgetsByFamilyID 获取家庭的预算列表
+func (BudgetDao) GetBudgetsByFamilyID(familyID uint) ([]Budget, error) {
+	var budgets []Budget
+	if err := database.DB.Where("family_id = ?", familyID).Preload("Category").Order("period, category_id").Find(&budgets).Error; err != nil {
+		log.Printf("获取家庭预算失败 FamilyID=%d: %v", familyID, err)
+		return nil, err
+	}
+	return budgets, nil
+}
+
+// UpdateBudget 更新预算金额、周期和结转设置
+func (BudgetDao) UpdateBudget(budget *Budget) error {
+	if err := database.DB.Model(budget).Select("period", "amount", "rollover", "updated_at").Updates(budget).Error; err != nil {
+		log.Printf("更新预算失败 ID=%d: %v", budget.ID, err)
+		return err
+	}
+	return nil
+}
+
+// DeleteBudget 删除预算及其周期分配，调拨记录保留在另一方信封的历史中
+func (BudgetDao) DeleteBudget(id uint) error {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("budget_id = ?", id).Delete(&BudgetAllocation{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&Budget{}, id).Error
+	})
+	if err != nil {
+		log.Printf("删除预算失败 ID=%d: %v", id, err)
+		return err
+	}
+	return nil
+}
+
+// GetCategoryTreeSpending 统计时间范围 [start, end) 内分类子树的支出，拆分交易按明细统计，退款冲减支出。
+// categoryPath 为子树根分类的 Path，结果按币种和交易日期分组
+func (BudgetDao) GetCategoryTreeSpending(familyID uint, categoryPath string, start, end time.Time) ([]SummaryRow, error) {
+	rows, err := database.DB.Table("transactions").
+		Select("'', transactions.currency, DATE(transactions.transaction_time) as transaction_date, "+
+			"SUM(CASE WHEN transactions.type = ? THEN -transactions.amount ELSE COALESCE(transaction_splits.amount, transactions.amount) END)", Refund).
+		Joins("LEFT JOIN transaction_splits ON transaction_splits.transaction_id = transactions.id").
+		Joins("JOIN categories ON categories.id = COALESCE(transaction_splits.category_id, transactions.category_id)").
+		Where("transactions.family_id = ? AND transactions.status = ? AND transactions.type IN ? AND transactions.transaction_time >= ? AND transactions.transaction_time < ? AND transactions.deleted_at IS NULL",
+			familyID, Valid, []TransactionType{Expense, Refund}, start, end).
+		Where("categories.path = ? OR categories.path LIKE ?", categoryPath, categoryPath+"/%").
+		Group("transactions.currency, transaction_date").
+		Rows()
+	if err != nil {
+		log.Printf("统计分类支出失败 FamilyID=%d, Path=%s: %v", familyID, categoryPath, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanSummaryRows(rows), nil
+}
@@ -0,0 +1,194 @@
+package model
+
+import (
+	"errors"
+	"github.com/KQLXK/Family-Finance-System/database"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"log"
+	"sync"
+	"time"
+)
+
+// DebtDao 借贷数据访问对象
+type DebtDao struct{}
+
+var (
+	debtOnce sync.Once
+	debtDao  *DebtDao
+)
+
+// NewDebtDaoInstance 返回 DebtDao 单例实例
+func NewDebtDaoInstance() *DebtDao {
+	debtOnce.Do(func() {
+		debtDao = &DebtDao{}
+	})
+	return debtDao
+}
+
+// CreateDebt 创建借贷记录
+func (DebtDao) CreateDebt(debt *Debt) error {
+	if err := database.DB.Create(debt).Error; err != nil {
+		log.Printf("创建借贷记录失败: %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetDebtByID 根据ID获取借贷记录
+func (DebtDao) GetDebtByID(id uint) (*Debt, error) {
+	var debt Debt
+	if err := database.DB.First(&debt, id).Error; err != nil {
+		log.Printf("获取借贷记录失败 ID=%d: %v", id, err)
+		return nil, err
+	}
+	return &debt, nil
+}
+
+// GetDebtsByFamilyID 获取家庭的借贷记录，status 为 open、closed 或空（全部）
+func (DebtDao) GetDebtsByFamilyID(familyID uint, status string) ([]Debt, error) {
+	var debts []Debt
+	query := database.DB.Where("family_id = ?", familyID)
+	switch status {
+	case "open":
+		query = query.Where("closed_at IS NULL")
+	case "closed":
+		query = query.Where("closed_at IS NOT NULL")
+	}
+	if err := query.Order("due_date IS NULL, due_date, id").Find(&debts).Error; err != nil {
+		log.Printf("获取家庭借贷记录失败 FamilyID=%d: %v", familyID, err)
+		return nil, err
+	}
+	return debts, nil
+}
+
+// GetOverdueDebts 获取已过到期日仍未还清的借贷记录
+func (DebtDao) GetOverdueDebts(familyID uint, now time.Time) ([]Debt, error) {
+	var debts []Debt
+	if err := database.DB.Where("family_id = ? AND closed_at IS NULL AND due_date < ?", familyID, now).
+		Order("due_date").Find(&debts).Error; err != nil {
+		log.Printf("获取逾期借贷记录失败 FamilyID=%d: %v", familyID, err)
+		return nil, err
+	}
+	return debts, nil
+}
+
+// UpdateDebt 更新借贷信息
+func (DebtDao) UpdateDebt(debt *Debt) error {
+	if err := database.DB.Model(debt).
+		Select("counterparty", "principal", "interest_rate", "start_date", "due_date", "note", "updated_at").
+		Updates(debt).Error; err != nil {
+		log.Printf("更新借贷记录失败 ID=%d: %v", debt.ID, err)
+		return err
+	}
+	return nil
+}
+
+// SetDebtClosedAt 设置或清除借贷的还清时间
+func (DebtDao) SetDebtClosedAt(id uint, closedAt *time.Time) error {
+	if err := database.DB.Model(&Debt{}).Where("id = ?", id).Update("closed_at", closedAt).Error; err != nil {
+		log.Printf("更新借贷状态失败 ID=%d: %v", id, err)
+		return err
+	}
+	return nil
+}
+
+// DeleteDebt 删除借贷记录及其还款记录
+func (DebtDao) DeleteDebt(id uint) error {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("debt_id = ?", id).Delete(&DebtRepayment{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&Debt{}, id).Error
+	})
+	if err != nil {
+		log.Printf("删除借贷记录失败 ID=%d: %v", id, err)
+		return err
+	}
+	return nil
+}
+
+// CreateRepayment 创建还款记录
+func (DebtDao) CreateRepayment(repayment *DebtRepayment) error {
+	if err := database.DB.Create(repayment).Error; err != nil {
+		log.Printf("创建还款记录失败: %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetRepaymentByID 根据ID获取还款记录
+func (DebtDao) GetRepaymentByID(id uint) (*DebtRepayment, error) {
+	var repayment DebtRepayment
+	if err := database.DB.First(&repayment, id).Error; err != nil {
+		log.Printf("获取还款记录失败 ID=%d: %v", id, err)
+		return nil, err
+	}
+	return &repayment, nil
+}
+
+// GetRepaymentByTransactionID 获取交易关联的还款记录，不存在时返回 nil
+func (DebtDao) GetRepaymentByTransactionID(transactionID uint) (*DebtRepayment, error) {
+	var repayment DebtRepayment
+	err := database.DB.Where("transaction_id = ?", transactionID).First(&repayment).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("获取还款记录失败 TransactionID=%d: %v", transactionID, err)
+		return nil, err
+	}
+	return &repayment, nil
+}
+
+// GetRepaymentsByDebtID 获取借贷的还款记录，按还款时间排序
+func (DebtDao) GetRepaymentsByDebtID(debtID uint) ([]DebtRepayment, error) {
+	var repayments []DebtRepayment
+	if err := database.DB.Where("debt_id = ?", debtID).Order("repaid_at, id").Find(&repayments).Error; err != nil {
+		log.Printf("获取还款记录失败 DebtID=%d: %v", debtID, err)
+		return nil, err
+	}
+	return repayments, nil
+}
+
+// GetRepaidAmount 统计借贷已还金额，只计入关联交易仍然有效的还款
+func (DebtDao) GetRepaidAmount(debtID uint) (decimal.Decimal, error) {
+	var repaid decimal.Decimal
+	if err := database.DB.Table("debt_repayments").
+		Select("COALESCE(SUM(debt_repayments.amount), 0)").
+		Joins("JOIN transactions ON transactions.id = debt_repayments.transaction_id").
+		Where("debt_repayments.debt_id = ? AND transactions.status = ? AND transactions.deleted_at IS NULL", debtID, Valid).
+		Row().Scan(&repaid); err != nil {
+		log.Printf("统计已还金额失败 DebtID=%d: %v", debtID, err)
+		return decimal.Zero, err
+	}
+	return repaid, nil
+}
+
+// GetLastRepaidAt 获取借贷最近一次有效还款的时间，没有有效还款时返回 nil
+func (DebtDao) GetLastRepaidAt(debtID uint) (*time.Time, error) {
+	var repayments []DebtRepayment
+	if err := database.DB.Table("debt_repayments").
+		Select("debt_repayments.repaid_at").
+		Joins("JOIN transactions ON transactions.id = debt_repayments.transaction_id").
+		Where("debt_repayments.debt_id = ? AND transactions.status = ? AND transactions.deleted_at IS NULL", debtID, Valid).
+		Order("debt_repayments.repaid_at DESC").
+		Limit(1).
+		Find(&repayments).Error; err != nil {
+		log.Printf("获取最近还款时间失败 DebtID=%d: %v", debtID, err)
+		return nil, err
+	}
+	if len(repayments) == 0 {
+		return nil, nil
+	}
+	return &repayments[0].RepaidAt, nil
+}
+
+// DeleteRepayment 删除还款记录
+func (DebtDao) DeleteRepayment(id uint) error {
+	if err := database.DB.Delete(&DebtRepayment{}, id).Error; err != nil {
+		log.Printf("删除还款记录失败 ID=%d: %v", id, err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package model
+
+import (
+	"github.com/KQLXK/Family-Finance-System/database"
+	"gorm.io/gorm/clause"
+	"log"
+	"sync"
+	"time"
+)
+
+// EnvelopeDao 信封预算分配和调拨数据访问对象
+type EnvelopeDao struct{}
+
+var (
+	envelopeOnce sync.Once
+	envelopeDao  *EnvelopeDao
+)
+
+// NewEnvelopeDaoInstance 返回 EnvelopeDao 单例实例
+func NewEnvelopeDaoInstance() *EnvelopeDao {
+	envelopeOnce.Do(func() {
+		envelopeDao = &EnvelopeDao{}
+	})
+	return envelopeDao
+}
+
+// SaveAllocation 保存周期分配，同一预算同一周期已存在时更新金额
+func (EnvelopeDao) SaveAllocation(allocation *BudgetAllocation) error {
+	err := database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "budget_id"}, {Name: "period_start"}},
+		DoUpdates: clause.AssignmentColumns([]string{"amount", "updated_at"}),
+	}).Create(allocation).Error
+	if err != nil {
+		log.Printf("保存预算分配失败 BudgetID=%d: %v", allocation.BudgetID, err)
+		return err
+	}
+	return nil
+}
+
+// CreateMissingAllocations 批量创建周期分配，已存在的周期保持不变
+func (EnvelopeDao) CreateMissingAllocations(allocations []BudgetAllocation) error {
+	if len(allocations) == 0 {
+		return nil
+	}
+	err := database.DB.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(allocations, 500).Error
+	if err != nil {
+		log.Printf("创建预算分配失败: %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetAllocations 获取预算的周期分配，按周期排序
+func (EnvelopeDao) GetAllocations(budgetID uint) ([]BudgetAllocation, error) {
+	var allocations []BudgetAllocation
+	if err := database.DB.Where("budget_id = ?", budgetID).Order("period_start").Find(&allocations).Error; err != nil {
+		log.Printf("获取预算分配失败 BudgetID=%d: %v", budgetID, err)
+		return nil, err
+	}
+	return allocations, nil
+}
+
+// CountAllocations 统计预算的周期分配数量
+func (EnvelopeDao) CountAllocations(budgetID uint) (int64, error) {
+	var count int64
+	if err := database.DB.Model(&BudgetAllocation{}).Where("budget_id = ?", budgetID).Count(&count).Error; err != nil {
+		log.Printf("统计预算分配失败 BudgetID=%d: %v", budgetID, err)
+		return 0, err
+	}
+	return count, nil
+}
+
+// CreateMove 创建信封调拨记录
+func (EnvelopeDao) CreateMove(move *BudgetMove) error {
+	if err := database.DB.Create(move).Error; err != nil {
+		log.Printf("创建信封调拨记录失败: %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetMovesByBudgetID 获取转入或转出该预算、周期不晚于 until 的调拨记录
+func (EnvelopeDao) GetMovesByBudgetID(budgetID uint, until time.Time) ([]BudgetMove, error) {
+	var moves []BudgetMove
+	if err := database.DB.Where("(from_budget_id = ? OR to_budget_id = ?) AND period_start <= ?", budgetID, budgetID, until.Format("2006-01-02")).
+		Order("period_start, id").Find(&moves).Error; err != nil {
+		log.Printf("获取信封调拨记录失败 BudgetID=%d: %v", budgetID, err)
+		return nil, err
+	}
+	return moves, nil
+}
+
+// GetMovesByFamilyID 获取家庭在时间范围内的调拨记录，按时间倒序
+func (EnvelopeDao) GetMovesByFamilyID(familyID uint, startTime, endTime time.Time) ([]BudgetMove, error) {
+	var moves []BudgetMove
+	if err := database.DB.Where("family_id = ? AND created_at BETWEEN ? AND ?", familyID, startTime, endTime).
+		Order("created_at DESC, id DESC").Find(&moves).Error; err != nil {
+		log.Printf("获取家庭信封调拨记录失败 FamilyID=%d: %v", familyID, err)
+		return nil, err
+	}
+	return moves, nil
+}
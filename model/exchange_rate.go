@@ -0,0 +1,110 @@
+package model
+
+import (
+	"errors"
+	"github.com/KQLXK/Family-Finance-System/database"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"log"
+	"sync"
+	"time"
+)
+
+// ExchangeRateDao 汇率数据访问对象
+type ExchangeRateDao struct{}
+
+var (
+	exchangeRateOnce sync.Once
+	exchangeRateDao  *ExchangeRateDao
+)
+
+// NewExchangeRateDaoInstance 返回 ExchangeRateDao 单例实例
+func NewExchangeRateDaoInstance() *ExchangeRateDao {
+	exchangeRateOnce.Do(func() {
+		exchangeRateDao = &ExchangeRateDao{}
+	})
+	return exchangeRateDao
+}
+
+// SaveExchangeRates 批量保存汇率，同一家庭、币种对和日期已存在时覆盖汇率
+func (ExchangeRateDao) SaveExchangeRates(rates []ExchangeRate) error {
+	if len(rates) == 0 {
+		return nil
+	}
+	err := database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "family_id"}, {Name: "from_currency"}, {Name: "to_currency"}, {Name: "rate_date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rate", "updated_at"}),
+	}).CreateInBatches(rates, 500).Error
+	if err != nil {
+		log.Printf("保存汇率失败: %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetExchangeRateByID 根据ID获取汇率
+func (ExchangeRateDao) GetExchangeRateByID(id uint) (*ExchangeRate, error) {
+	var rate ExchangeRate
+	if err := database.DB.First(&rate, id).Error; err != nil {
+		log.Printf("获取汇率失败 ID=%d: %v", id, err)
+		return nil, err
+	}
+	return &rate, nil
+}
+
+// GetExchangeRateByDate 获取某天的汇率，不存在时返回 nil
+func (ExchangeRateDao) GetExchangeRateByDate(familyID uint, fromCurrency, toCurrency string, date time.Time) (*ExchangeRate, error) {
+	var rate ExchangeRate
+	err := database.DB.Where("family_id = ? AND from_currency = ? AND to_currency = ? AND rate_date = ?",
+		familyID, fromCurrency, toCurrency, date.Format("2006-01-02")).
+		First(&rate).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("获取汇率失败 FamilyID=%d, %s->%s: %v", familyID, fromCurrency, toCurrency, err)
+		return nil, err
+	}
+	return &rate, nil
+}
+
+// GetExchangeRatesByFamilyID 获取家庭的汇率列表，currency 为空时返回全部币种
+func (ExchangeRateDao) GetExchangeRatesByFamilyID(familyID uint, currency string, startDate, endDate time.Time) ([]ExchangeRate, error) {
+	var rates []ExchangeRate
+	query := database.DB.Where("family_id = ? AND rate_date BETWEEN ? AND ?",
+		familyID, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+	if currency != "" {
+		query = query.Where("from_currency = ? OR to_currency = ?", currency, currency)
+	}
+	if err := query.Order("rate_date DESC, from_currency, to_currency").Find(&rates).Error; err != nil {
+		log.Printf("获取家庭汇率失败 FamilyID=%d: %v", familyID, err)
+		return nil, err
+	}
+	return rates, nil
+}
+
+// GetRateOnDate 获取指定日期适用的汇率，即该日及之前最近一天的汇率，不存在时返回 nil
+func (ExchangeRateDao) GetRateOnDate(familyID uint, fromCurrency, toCurrency string, date time.Time) (*ExchangeRate, error) {
+	var rate ExchangeRate
+	err := database.DB.Where("family_id = ? AND from_currency = ? AND to_currency = ? AND rate_date <= ?",
+		familyID, fromCurrency, toCurrency, date.Format("2006-01-02")).
+		Order("rate_date DESC").
+		First(&rate).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("获取汇率失败 FamilyID=%d, %s->%s: %v", familyID, fromCurrency, toCurrency, err)
+		return nil, err
+	}
+	return &rate, nil
+}
+
+// DeleteExchangeRate 删除汇率
+func (ExchangeRateDao) DeleteExchangeRate(id uint) error {
+	if err := database.DB.Delete(&ExchangeRate{}, id).Error; err != nil {
+		log.Printf("删除汇率失败 ID=%d: %v", id, err)
+		return err
+	}
+	return nil
+}
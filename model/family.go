@@ -2,6 +2,7 @@ package model
 
 import (
 	"github.com/KQLXK/Family-Finance-System/database"
+	"github.com/shopspring/decimal"
 	"log"
 	"sync"
 )
@@ -68,3 +69,21 @@ func (FamilyDao) DeleteFamily(id uint) error {
 	}
 	return nil
 }
+
+// UpdateApprovalThreshold 更新家庭的交易审批金额阈值
+func (FamilyDao) UpdateApprovalThreshold(id uint, threshold decimal.Decimal) error {
+	if err := database.DB.Model(&Family{}).Where("id = ?", id).Update("approval_threshold", threshold).Error; err != nil {
+		log.Printf("更新家庭审批阈值失败 ID=%d: %v", id, err)
+		return err
+	}
+	return nil
+}
+
+// UpdateBaseCurrency 更新家庭本位币
+func (FamilyDao) UpdateBaseCurrency(id uint, currency string) error {
+	if err := database.DB.Model(&Family{}).Where("id = ?", id).Update("base_currency", currency).Error; err != nil {
+		log.Printf("更新家庭本位币失败 ID=%d: %v", id, err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package model
+
+import (
+	"github.com/KQLXK/Family-Finance-System/database"
+	"gorm.io/gorm"
+	"log"
+	"sync"
+	"time"
+)
+
+// InstallmentDao 分期计划数据访问对象
+type InstallmentDao struct{}
+
+var (
+	installmentOnce sync.Once
+	installmentDao  *InstallmentDao
+)
+
+// NewInstallmentDaoInstance 返回 InstallmentDao 单例实例
+func NewInstallmentDaoInstance() *InstallmentDao {
+	installmentOnce.Do(func() {
+		installmentDao = &InstallmentDao{}
+	})
+	return installmentDao
+}
+
+// CreatePlan 创建分期计划及其还款计划
+func (InstallmentDao) CreatePlan(plan *InstallmentPlan) error {
+	if err := database.DB.Create(plan).Error; err != nil {
+		log.Printf("创建分期计划失败: %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetPlanByID 根据ID获取分期计划，包含按期数排序的还款计划
+func (InstallmentDao) GetPlanByID(id uint) (*InstallmentPlan, error) {
+	var plan InstallmentPlan
+	if err := database.DB.Preload("Installments", func(db *gorm.DB) *gorm.DB {
+		return db.Order("seq")
+	}).First(&plan, id).Error; err != nil {
+		log.Printf("获取分期计划失败 ID=%d: %v", id, err)
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// GetPlansByFamilyID 获取家庭的分期计划，status 为空时返回全部
+func (InstallmentDao) GetPlansByFamilyID(familyID uint, status InstallmentStatus) ([]InstallmentPlan, error) {
+	var plans []InstallmentPlan
+	query := database.DB.Where("family_id = ?", familyID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Preload("Installments", func(db *gorm.DB) *gorm.DB {
+		return db.Order("seq")
+	}).Order("first_due_date DESC, id DESC").Find(&plans).Error; err != nil {
+		log.Printf("获取家庭分期计划失败 FamilyID=%d: %v", familyID, err)
+		return nil, err
+	}
+	return plans, nil
+}
+
+// GetPlanIDsWithDueInstallments 获取有到期未生成交易的还款计划的进行中分期计划，
+// 登记时间早于 staleBefore 仍未生成交易的期数视为登记的任务已中断
+func (InstallmentDao) GetPlanIDsWithDueInstallments(now, staleBefore time.Time) ([]uint, error) {
+	var ids []uint
+	if err := database.DB.Model(&Installment{}).
+		Distinct("installments.plan_id").
+		Joins("JOIN installment_plans ON installment_plans.id = installments.plan_id").
+		Where("installment_plans.status = ? AND installments.transaction_id IS NULL AND installments.due_date <= ?",
+			InstallmentActive, now.Format("2006-01-02")).
+		Where("installments.posted_at IS NULL OR installments.posted_at < ?", staleBefore).
+		Pluck("installments.plan_id", &ids).Error; err != nil {
+		log.Printf("获取到期分期计划失败: %v", err)
+		return nil, err
+	}
+	return ids, nil
+}
+
+// ClaimInstallment 以登记时间 postedAt 登记某期即将生成交易，登记时间早于 staleBefore 仍未生成交易的登记可被重新登记。
+// 已被登记、已生成交易或分期计划已不是进行中时返回 false
+func (InstallmentDao) ClaimInstallment(id uint, postedAt, staleBefore time.Time) (bool, error) {
+	result := database.DB.Model(&Installment{}).
+		Where("id = ? AND transaction_id IS NULL AND plan_id IN (?)", id,
+			database.DB.Model(&InstallmentPlan{}).Select("id").Where("status = ?", InstallmentActive)).
+		Where("posted_at IS NULL OR posted_at < ?", staleBefore).
+		Update("posted_at", postedAt)
+	if result.Error != nil {
+		log.Printf("登记分期还款计划失败 ID=%d: %v", id, result.Error)
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ClaimUnpostedInstallments 提前结清时以登记时间 postedAt 登记分期计划所有未生成交易的期数，
+// 正在生成交易的任务的登记随之失效
+func (InstallmentDao) ClaimUnpostedInstallments(planID uint, postedAt time.Time) error {
+	if err := database.DB.Model(&Installment{}).
+		Where("plan_id = ? AND transaction_id IS NULL", planID).
+		Update("posted_at", postedAt).Error; err != nil {
+		log.Printf("登记分期还款计划失败 PlanID=%d: %v", planID, err)
+		return err
+	}
+	return nil
+}
+
+// ReleaseInstallments 生成交易失败时撤销分期计划中以登记时间 postedAt 登记且未生成交易的期数
+func (InstallmentDao) ReleaseInstallments(planID uint, postedAt time.Time) error {
+	if err := database.DB.Model(&Installment{}).
+		Where("plan_id = ? AND transaction_id IS NULL AND posted_at = ?", planID, postedAt).
+		Update("posted_at", nil).Error; err != nil {
+		log.Printf("撤销分期还款计划登记失败 PlanID=%d: %v", planID, err)
+		return err
+	}
+	return nil
+}
+
+// MarkInstallmentPosted 记录以登记时间 postedAt 登记的某期生成的交易，登记已失效或已记录过交易时返回 false
+func (InstallmentDao) MarkInstallmentPosted(id uint, postedAt time.Time, transactionID uint) (bool, error) {
+	result := database.DB.Model(&Installment{}).
+		Where("id = ? AND transaction_id IS NULL AND posted_at = ?", id, postedAt).
+		Update("transaction_id", transactionID)
+	if result.Error != nil {
+		log.Printf("更新分期还款计划失败 ID=%d: %v", id, result.Error)
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// UpdatePlanStatus 更新进行中的分期计划状态，计划已不是进行中时返回 false
+func (InstallmentDao) UpdatePlanStatus(id uint, status InstallmentStatus, cancelledAt *time.Time) (bool, error) {
+	result := database.DB.Model(&InstallmentPlan{}).
+		Where("id = ? AND status = ?", id, InstallmentActive).
+		Updates(map[string]interface{}{
+			"status":       status,
+			"cancelled_at": cancelledAt,
+			"updated_at":   time.Now(),
+		})
+	if result.Error != nil {
+		log.Printf("更新分期计划状态失败 ID=%d: %v", id, result.Error)
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// SetSettlementTransaction 记录提前结清生成的交易
+func (InstallmentDao) SetSettlementTransaction(id uint, transactionID uint) error {
+	if err := database.DB.Model(&InstallmentPlan{}).
+		Where("id = ? AND settlement_transaction_id IS NULL", id).
+		Update("settlement_transaction_id", transactionID).Error; err != nil {
+		log.Printf("记录分期结清交易失败 ID=%d: %v", id, err)
+		return err
+	}
+	return nil
+}
+
+// ReopenPlan 生成结清交易失败时将已取消但未记录结清交易的分期计划恢复为进行中
+func (InstallmentDao) ReopenPlan(id uint) error {
+	if err := database.DB.Model(&InstallmentPlan{}).
+		Where("id = ? AND status = ? AND settlement_transaction_id IS NULL", id, InstallmentCancelled).
+		Updates(map[string]interface{}{
+			"status":       InstallmentActive,
+			"cancelled_at": nil,
+			"updated_at":   time.Now(),
+		}).Error; err != nil {
+		log.Printf("恢复分期计划失败 ID=%d: %v", id, err)
+		return err
+	}
+	return nil
+}
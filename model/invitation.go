@@ -0,0 +1,101 @@
+package model
+
+import (
+	"github.com/KQLXK/Family-Finance-System/database"
+	"gorm.io/gorm"
+	"log"
+	"sync"
+	"time"
+)
+
+// InvitationDao 邀请码数据访问对象
+type InvitationDao struct{}
+
+var (
+	invitationOnce sync.Once
+	invitationDao  *InvitationDao
+)
+
+// NewInvitationDaoInstance 返回 InvitationDao 单例实例
+func NewInvitationDaoInstance() *InvitationDao {
+	invitationOnce.Do(func() {
+		invitationDao = &InvitationDao{}
+	})
+	return invitationDao
+}
+
+// CreateInvitation 创建邀请码
+func (InvitationDao) CreateInvitation(invitation *Invitation) error {
+	if err := database.DB.Create(invitation).Error; err != nil {
+		log.Printf("创建邀请码失败: %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetInvitationByID 根据ID获取邀请码
+func (InvitationDao) GetInvitationByID(id uint) (*Invitation, error) {
+	var invitation Invitation
+	if err := database.DB.First(&invitation, id).Error; err != nil {
+		log.Printf("获取邀请码失败 ID=%d: %v", id, err)
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+// GetInvitationByCode 根据邀请码获取邀请
+func (InvitationDao) GetInvitationByCode(code string) (*Invitation, error) {
+	var invitation Invitation
+	if err := database.DB.Where("code = ?", code).First(&invitation).Error; err != nil {
+		log.Printf("获取邀请码失败 Code=%s: %v", code, err)
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+// GetInvitationsByFamilyID 根据家庭ID获取邀请码列表，onlyActive 为 true 时只返回仍可使用的邀请码
+func (InvitationDao) GetInvitationsByFamilyID(familyID uint, onlyActive bool) ([]Invitation, error) {
+	var invitations []Invitation
+	query := database.DB.Where("family_id = ?", familyID)
+	if onlyActive {
+		query = query.Where("revoked_at IS NULL AND expires_at > ? AND used_count < max_uses", time.Now())
+	}
+	if err := query.Order("created_at DESC").Find(&invitations).Error; err != nil {
+		log.Printf("获取家庭邀请码失败 FamilyID=%d: %v", familyID, err)
+		return nil, err
+	}
+	return invitations, nil
+}
+
+// ClaimInvitation 占用一次邀请码使用次数，邀请码不可用时返回 false
+func (InvitationDao) ClaimInvitation(id uint) (bool, error) {
+	result := database.DB.Model(&Invitation{}).
+		Where("id = ? AND revoked_at IS NULL AND expires_at > ? AND used_count < max_uses", id, time.Now()).
+		Update("used_count", gorm.Expr("used_count + 1"))
+	if result.Error != nil {
+		log.Printf("占用邀请码失败 ID=%d: %v", id, result.Error)
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ReleaseInvitation 归还一次邀请码使用次数（兑换失败时调用）
+func (InvitationDao) ReleaseInvitation(id uint) error {
+	if err := database.DB.Model(&Invitation{}).
+		Where("id = ? AND used_count > 0", id).
+		Update("used_count", gorm.Expr("used_count - 1")).Error; err != nil {
+		log.Printf("归还邀请码失败 ID=%d: %v", id, err)
+		return err
+	}
+	return nil
+}
+
+// RevokeInvitation 撤销邀请码
+func (InvitationDao) RevokeInvitation(id uint) error {
+	if err := database.DB.Model(&Invitation{}).Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		log.Printf("撤销邀请码失败 ID=%d: %v", id, err)
+		return err
+	}
+	return nil
+}
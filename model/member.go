@@ -4,6 +4,7 @@ import (
 	"github.com/KQLXK/Family-Finance-System/database"
 	"log"
 	"sync"
+	"time"
 )
 
 // MemberDao 成员数据访问对象
@@ -95,3 +96,46 @@ func (MemberDao) UpdateMemberRole(id uint, role MemberRole) error {
 	}
 	return nil
 }
+
+// GetMemberByAccount 根据邮箱或手机号获取正常状态的成员
+func (MemberDao) GetMemberByAccount(account string) (*Member, error) {
+	var member Member
+	if err := database.DB.Where("(email = ? OR phone = ?) AND status = 1", account, account).
+		First(&member).Error; err != nil {
+		log.Printf("获取成员失败 Account=%s: %v", account, err)
+		return nil, err
+	}
+	return &member, nil
+}
+
+// GetAllMembersByFamilyID 根据家庭ID获取全部成员（包括已移除的成员）
+func (MemberDao) GetAllMembersByFamilyID(familyID uint) ([]Member, error) {
+	var members []Member
+	if err := database.DB.Where("family_id = ?", familyID).Find(&members).Error; err != nil {
+		log.Printf("获取家庭全部成员失败 FamilyID=%d: %v", familyID, err)
+		return nil, err
+	}
+	return members, nil
+}
+
+// UpdatePassword 更新成员密码哈希
+func (MemberDao) UpdatePassword(id uint, passwordHash string) error {
+	if err := database.DB.Model(&Member{}).Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"password_hash":       passwordHash,
+			"password_changed_at": time.Now(),
+		}).Error; err != nil {
+		log.Printf("更新成员密码失败 ID=%d: %v", id, err)
+		return err
+	}
+	return nil
+}
+
+// UpdateRequireApproval 更新成员交易是否需要审批
+func (MemberDao) UpdateRequireApproval(id uint, requireApproval bool) error {
+	if err := database.DB.Model(&Member{}).Where("id = ?", id).Update("require_approval", requireApproval).Error; err != nil {
+		log.Printf("更新成员审批设置失败 ID=%d: %v", id, err)
+		return err
+	}
+	return nil
+}
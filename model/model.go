@@ -1,6 +1,8 @@
 package model
 
 import (
+	"encoding/json"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 	"time"
 )
@@ -9,17 +11,21 @@ import (
 type TransactionType string
 
 const (
-	Income  TransactionType = "income"
-	Expense TransactionType = "expense"
+	Income   TransactionType = "income"
+	Expense  TransactionType = "expense"
+	Transfer TransactionType = "transfer" // 账户间转账，不计入收支统计
+	Refund   TransactionType = "refund"   // 退款，关联原支出，统计时冲减原支出分类
 )
 
 // 交易状态枚举
 type TransactionStatus string
 
 const (
-	Valid   TransactionStatus = "valid"
-	Deleted TransactionStatus = "deleted"
-	Pending TransactionStatus = "pending"
+	Valid    TransactionStatus = "valid"
+	Deleted  TransactionStatus = "deleted"
+	Pending  TransactionStatus = "pending"  // 待管理员审批，不计入统计
+	Rejected TransactionStatus = "rejected" // 审批被拒绝
+	Planned  TransactionStatus = "planned"  // 计划交易，交易时间到达后自动转为正常，之前不计入统计
 )
 
 // 分类类型枚举
@@ -39,13 +45,115 @@ const (
 	RoleViewer MemberRole = "viewer"
 )
 
+// 个人访问令牌权限范围枚举
+type TokenScope string
+
+const (
+	ScopeTransactionsRead  TokenScope = "transactions:read"
+	ScopeTransactionsWrite TokenScope = "transactions:write"
+	ScopeReportsRead       TokenScope = "reports:read"
+)
+
+// 账户类型枚举
+type AccountType string
+
+const (
+	AccountCash       AccountType = "cash"
+	AccountDebitCard  AccountType = "debit_card"
+	AccountCreditCard AccountType = "credit_card"
+	AccountAlipay     AccountType = "alipay"
+	AccountWeChat     AccountType = "wechat"
+	AccountOther      AccountType = "other"
+)
+
+// 共同支出分摊方式枚举
+type ShareMethod string
+
+const (
+	ShareEqual      ShareMethod = "equal"      // 平均分摊
+	SharePercentage ShareMethod = "percentage" // 按比例分摊
+	ShareExact      ShareMethod = "exact"      // 按指定金额分摊
+)
+
+// 借贷方向枚举
+type DebtDirection string
+
+const (
+	DebtLent     DebtDirection = "lent"     // 借出，对方欠家庭
+	DebtBorrowed DebtDirection = "borrowed" // 借入，家庭欠对方
+)
+
+// 预算等按周期统计的周期类型
+type Period string
+
+const (
+	PeriodWeekly  Period = "weekly"  // 自然周，从周一开始
+	PeriodMonthly Period = "monthly" // 自然月
+	PeriodYearly  Period = "yearly"  // 自然年
+)
+
+// 周期交易的重复频率
+type Frequency string
+
+const (
+	FrequencyDaily           Frequency = "daily"             // 每 N 天
+	FrequencyWeekly          Frequency = "weekly"            // 每 N 周，与开始日期同一星期几
+	FrequencyMonthly         Frequency = "monthly"           // 每 N 个月的第几天，超过月末时取月末
+	FrequencyLastBusinessDay Frequency = "last_business_day" // 每 N 个月的最后一个工作日（周一至周五）
+	FrequencyYearly          Frequency = "yearly"            // 每 N 年的某月某日
+)
+
+// 账单状态
+type BillStatus string
+
+const (
+	BillUnpaid BillStatus = "unpaid"
+	BillPaid   BillStatus = "paid"
+)
+
+// 通知渠道类型
+type ChannelType string
+
+const (
+	ChannelInApp   ChannelType = "in_app"  // 站内通知，保存在通知表中
+	ChannelWebhook ChannelType = "webhook" // 以 JSON 推送到指定地址
+)
+
+// 消费限额超出时的处理方式
+type LimitAction string
+
+const (
+	LimitReject  LimitAction = "reject"  // 拒绝记账
+	LimitApprove LimitAction = "approve" // 交易进入待审批状态
+)
+
+// 分期计划状态
+type InstallmentStatus string
+
+const (
+	InstallmentActive    InstallmentStatus = "active"    // 还款中
+	InstallmentCompleted InstallmentStatus = "completed" // 已按期还完
+	InstallmentCancelled InstallmentStatus = "cancelled" // 提前结清
+)
+
+// 审计操作枚举
+type AuditAction string
+
+const (
+	AuditCreate AuditAction = "create"
+	AuditUpdate AuditAction = "update"
+	AuditDelete AuditAction = "delete"
+)
+
 // 家庭表
 type Family struct {
-	ID        uint      `gorm:"primaryKey" json:"id"`
-	Name      string    `gorm:"size:100;not null" json:"name"`
-	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
-	Members   []Member  `gorm:"foreignkey:FamilyID" json:"members,omitempty"`
+	ID                uint            `gorm:"primaryKey" json:"id"`
+	Name              string          `gorm:"size:100;not null" json:"name"`
+	ApprovalThreshold decimal.Decimal `gorm:"type:DECIMAL(12,2);default:0" json:"approval_threshold"` // 超过该金额（本位币）的交易需要审批，0 表示不启用
+	BaseCurrency      string          `gorm:"size:3;default:'CNY'" json:"base_currency"`              // 本位币，统计时换算为该币种
+	CreatedAt         time.Time       `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt         time.Time       `json:"updated_at" gorm:"autoUpdateTime"`
+	Members           []Member        `gorm:"foreignkey:FamilyID" json:"members,omitempty"`
 }
 
 // 成员表
@@ -59,6 +167,66 @@ type Member struct {
 	Email     string     `gorm:"size:100" json:"email"`
 	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
 	Status    int8       `gorm:"default:1" json:"status"` // 1=正常，0=已移除
+
+	RequireApproval bool `gorm:"default:false" json:"require_approval"` // 该成员记录的交易均需审批
+
+	PasswordHash      string     `gorm:"size:100" json:"-"` // bcrypt 哈希，为空表示尚未设置密码
+	PasswordChangedAt *time.Time `json:"-"`                 // 早于该时间签发的令牌失效
+}
+
+// 密码重置令牌表（仅保存令牌哈希）
+type PasswordResetToken struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	MemberID  uint       `json:"member_id" gorm:"index"`
+	TokenHash string     `gorm:"size:64;uniqueIndex;not null" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedBy uint       `json:"created_by"` // 发起重置的管理员
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// 个人访问令牌表（供脚本和集成使用，仅保存令牌哈希）
+type APIToken struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	MemberID   uint       `json:"member_id" gorm:"index"`
+	FamilyID   uint       `json:"family_id" gorm:"index"`
+	Name       string     `gorm:"size:100;not null" json:"name"`
+	TokenHash  string     `gorm:"size:64;uniqueIndex;not null" json:"-"`
+	Prefix     string     `gorm:"size:16" json:"prefix"`  // 令牌开头几位，便于识别
+	Scopes     string     `gorm:"size:200" json:"scopes"` // 逗号分隔的 TokenScope
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// 家庭邀请码表
+type Invitation struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	FamilyID  uint       `json:"family_id" gorm:"index"`
+	Code      string     `gorm:"size:32;uniqueIndex;not null" json:"code"`
+	Role      MemberRole `gorm:"type:ENUM('admin', 'member', 'viewer');default:'member'" json:"role"` // 兑换后成员的角色
+	MaxUses   int        `gorm:"default:1" json:"max_uses"`
+	UsedCount int        `gorm:"default:0" json:"used_count"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	CreatedBy uint       `json:"created_by"`
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// 账户表（银行卡、现金、支付宝、微信等）
+type Account struct {
+	ID             uint            `gorm:"primaryKey" json:"id"`
+	FamilyID       uint            `json:"family_id" gorm:"index"`
+	Name           string          `gorm:"size:100;not null" json:"name"`
+	Type           AccountType     `gorm:"type:ENUM('cash', 'debit_card', 'credit_card', 'alipay', 'wechat', 'other');default:'other'" json:"type"`
+	OpeningBalance decimal.Decimal `gorm:"type:DECIMAL(12,2);default:0" json:"opening_balance"`
+	Currency       string          `gorm:"size:3;default:'CNY'" json:"currency"` // ISO 4217 货币代码
+	Archived       bool            `gorm:"default:false" json:"archived"`        // 归档后不能再记账，历史流水保留
+	StatementDay   int             `gorm:"default:0" json:"statement_day"`       // 信用卡账单日（1-31），超过当月天数时为月末，0 表示未设置
+	DueDay         int             `gorm:"default:0" json:"due_day"`             // 信用卡还款日（1-31），不大于账单日时为次月
+	CreatedAt      time.Time       `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt      time.Time       `json:"updated_at" gorm:"autoUpdateTime"`
 }
 
 // 分类表
@@ -90,24 +258,318 @@ type Tag struct {
 
 // 收支流水表
 type Transaction struct {
-	ID              uint              `gorm:"primaryKey" json:"id"`
-	CreatedAt       time.Time         `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt       time.Time         `json:"updated_at" gorm:"autoUpdateTime"`
-	DeletedAt       gorm.DeletedAt    `gorm:"index" json:"-"`
-	FamilyID        uint              `json:"family_id" gorm:"index"`
-	Family          Family            `json:"family,omitempty" gorm:"foreignKey:FamilyID"`
-	MemberID        uint              `json:"member_id" gorm:"index"`
-	Member          Member            `json:"member,omitempty" gorm:"foreignKey:MemberID"`
-	Amount          float64           `gorm:"type:DECIMAL(12,2);not null" json:"amount"`
-	Type            TransactionType   `gorm:"type:ENUM('income', 'expense');not null" json:"type"`
-	CategoryID      uint              `json:"category_id" gorm:"index"`
-	Category        Category          `json:"category,omitempty" gorm:"foreignKey:CategoryID"`
-	TransactionTime time.Time         `gorm:"not null" json:"transaction_time"`
-	Note            string            `gorm:"type:TEXT" json:"note"`
-	ImageURL        string            `gorm:"size:500" json:"image_url"`
-	Status          TransactionStatus `gorm:"type:ENUM('valid', 'deleted', 'pending');default:'valid'" json:"status"`
-	PaymentMethod   string            `gorm:"size:50" json:"payment_method"` // 支付方式：现金、银行卡、支付宝、微信等
-	Labels          []Tag             `gorm:"many2many:transaction_tags;" json:"labels"`
+	ID              uint               `gorm:"primaryKey" json:"id"`
+	CreatedAt       time.Time          `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt       time.Time          `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt       gorm.DeletedAt     `gorm:"index" json:"-"`
+	FamilyID        uint               `json:"family_id" gorm:"index"`
+	Family          Family             `json:"family,omitempty" gorm:"foreignKey:FamilyID"`
+	MemberID        uint               `json:"member_id" gorm:"index"`
+	Member          Member             `json:"member,omitempty" gorm:"foreignKey:MemberID"`
+	Amount          decimal.Decimal    `gorm:"type:DECIMAL(12,2);not null" json:"amount"`
+	Currency        string             `gorm:"size:3;default:'CNY'" json:"currency"` // 原始币种，金额按该币种记录
+	Type            TransactionType    `gorm:"type:ENUM('income', 'expense', 'transfer', 'refund');not null" json:"type"`
+	CategoryID      *uint              `json:"category_id" gorm:"index"` // 转账没有分类
+	Category        *Category          `json:"category,omitempty" gorm:"foreignKey:CategoryID"`
+	TransactionTime time.Time          `gorm:"not null" json:"transaction_time"`
+	Note            string             `gorm:"type:TEXT" json:"note"`
+	ImageURL        string             `gorm:"size:500" json:"image_url"`
+	Status          TransactionStatus  `gorm:"type:ENUM('valid', 'deleted', 'pending', 'rejected', 'planned');default:'valid'" json:"status"`
+	PaymentMethod   string             `gorm:"size:50" json:"payment_method"`           // 支付方式：现金、银行卡、支付宝、微信等
+	AccountID       *uint              `json:"account_id" gorm:"index"`                 // 资金账户，为空表示未关联账户；转账时为转出账户
+	ToAccountID     *uint              `json:"to_account_id" gorm:"index"`              // 转账的转入账户
+	Fee             decimal.Decimal    `gorm:"type:DECIMAL(12,2);default:0" json:"fee"` // 转账手续费，从转出账户扣除
+	RefundOfID      *uint              `json:"refund_of_id" gorm:"index"`               // 退款对应的原支出交易
+	Labels          []Tag              `gorm:"many2many:transaction_tags;" json:"labels"`
+	Splits          []TransactionSplit `gorm:"foreignKey:TransactionID" json:"splits,omitempty"` // 拆分明细，金额之和等于交易金额
+	ReviewedBy      *uint              `json:"reviewed_by"`                                      // 审批人
+	ReviewedAt      *time.Time         `json:"reviewed_at"`
+	ReviewNote      string             `gorm:"size:500" json:"review_note"`
+}
+
+// 交易拆分明细表：一笔交易按分类拆分为多行
+type TransactionSplit struct {
+	ID            uint            `gorm:"primaryKey" json:"id"`
+	TransactionID uint            `json:"transaction_id" gorm:"index"`
+	CategoryID    uint            `json:"category_id" gorm:"index"`
+	Category      *Category       `json:"category,omitempty" gorm:"foreignKey:CategoryID"`
+	Amount        decimal.Decimal `gorm:"type:DECIMAL(12,2);not null" json:"amount"`
+	Note          string          `gorm:"size:500" json:"note"`
+}
+
+// 共同支出表：一笔支出由付款成员垫付，由多个成员分摊
+type SharedExpense struct {
+	ID            uint           `gorm:"primaryKey" json:"id"`
+	FamilyID      uint           `json:"family_id" gorm:"index"`
+	TransactionID uint           `json:"transaction_id" gorm:"uniqueIndex"`
+	Method        ShareMethod    `gorm:"type:ENUM('equal', 'percentage', 'exact');not null" json:"method"`
+	Shares        []ExpenseShare `gorm:"foreignKey:SharedExpenseID" json:"shares"`
+	CreatedBy     uint           `json:"created_by"`
+	CreatedAt     time.Time      `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// 共同支出分摊明细表
+type ExpenseShare struct {
+	ID              uint            `gorm:"primaryKey" json:"id"`
+	SharedExpenseID uint            `json:"shared_expense_id" gorm:"index"`
+	MemberID        uint            `json:"member_id" gorm:"index"`
+	Percentage      decimal.Decimal `gorm:"type:DECIMAL(7,4);default:0" json:"percentage"` // 按比例分摊时的百分比
+	Amount          decimal.Decimal `gorm:"type:DECIMAL(12,2);not null" json:"amount"`     // 该成员应承担的金额
+}
+
+// 成员间结算记录表，每条结算生成一笔转账交易
+type Settlement struct {
+	ID            uint            `gorm:"primaryKey" json:"id"`
+	FamilyID      uint            `json:"family_id" gorm:"index"`
+	FromMemberID  uint            `json:"from_member_id" gorm:"index"` // 付款成员
+	ToMemberID    uint            `json:"to_member_id" gorm:"index"`   // 收款成员
+	Amount        decimal.Decimal `gorm:"type:DECIMAL(12,2);not null" json:"amount"`
+	Currency      string          `gorm:"size:3;not null" json:"currency"`
+	TransactionID uint            `json:"transaction_id" gorm:"index"` // 生成的转账交易
+	SettledAt     time.Time       `json:"settled_at"`                  // 转账时间，按该日汇率换算
+	Note          string          `gorm:"size:500" json:"note"`
+	CreatedBy     uint            `json:"created_by"`
+	CreatedAt     time.Time       `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// 借贷表：借给亲友或向亲友借入的款项
+type Debt struct {
+	ID           uint            `gorm:"primaryKey" json:"id"`
+	FamilyID     uint            `json:"family_id" gorm:"index"`
+	Counterparty string          `gorm:"size:100;not null" json:"counterparty"` // 对方姓名
+	Direction    DebtDirection   `gorm:"type:ENUM('lent', 'borrowed');not null" json:"direction"`
+	Principal    decimal.Decimal `gorm:"type:DECIMAL(12,2);not null" json:"principal"`
+	Currency     string          `gorm:"size:3;default:'CNY'" json:"currency"`
+	InterestRate decimal.Decimal `gorm:"type:DECIMAL(7,4);default:0" json:"interest_rate"` // 年利率（百分比），按单利计息
+	StartDate    time.Time       `gorm:"not null" json:"start_date"`
+	DueDate      *time.Time      `json:"due_date"`
+	Note         string          `gorm:"size:500" json:"note"`
+	ClosedAt     *time.Time      `json:"closed_at"` // 还清时间
+	CreatedBy    uint            `json:"created_by"`
+	CreatedAt    time.Time       `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt    time.Time       `json:"updated_at" gorm:"autoUpdateTime"`
+	Repayments   []DebtRepayment `gorm:"foreignKey:DebtID" json:"repayments,omitempty"`
+}
+
+// 还款记录表，每笔还款对应一笔交易
+type DebtRepayment struct {
+	ID            uint            `gorm:"primaryKey" json:"id"`
+	DebtID        uint            `json:"debt_id" gorm:"index"`
+	TransactionID uint            `json:"transaction_id" gorm:"uniqueIndex"`
+	Amount        decimal.Decimal `gorm:"type:DECIMAL(12,2);not null" json:"amount"`
+	RepaidAt      time.Time       `json:"repaid_at"`
+	CreatedBy     uint            `json:"created_by"`
+	CreatedAt     time.Time       `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// 信用卡账单还款表：一笔转入信用卡的转账用于偿还某期账单
+type StatementPayment struct {
+	ID            uint            `gorm:"primaryKey" json:"id"`
+	AccountID     uint            `json:"account_id" gorm:"index:idx_statement_payment"`
+	StatementDate time.Time       `gorm:"type:DATE;index:idx_statement_payment" json:"statement_date"` // 账单日
+	TransactionID uint            `json:"transaction_id" gorm:"uniqueIndex"`
+	Amount        decimal.Decimal `gorm:"type:DECIMAL(12,2);not null" json:"amount"`
+	CreatedBy     uint            `json:"created_by"`
+	CreatedAt     time.Time       `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// 分期计划表：一笔大额消费按月分期，每期生成本金和手续费交易
+type InstallmentPlan struct {
+	ID                      uint              `gorm:"primaryKey" json:"id"`
+	FamilyID                uint              `json:"family_id" gorm:"index"`
+	MemberID                uint              `json:"member_id"` // 消费成员，生成的交易记在该成员名下
+	Name                    string            `gorm:"size:100;not null" json:"name"`
+	AccountID               *uint             `json:"account_id"` // 分期账户，如花呗或信用卡
+	CategoryID              uint              `json:"category_id"`
+	FeeCategoryID           *uint             `json:"fee_category_id"` // 手续费分类，为空时使用消费分类
+	Principal               decimal.Decimal   `gorm:"type:DECIMAL(12,2);not null" json:"principal"`
+	Currency                string            `gorm:"size:3;default:'CNY'" json:"currency"`
+	Periods                 int               `gorm:"not null" json:"periods"`
+	FeeRate                 decimal.Decimal   `gorm:"type:DECIMAL(7,4);default:0" json:"fee_rate"` // 每期手续费率（%），按本金计算
+	FirstDueDate            time.Time         `gorm:"type:DATE;not null" json:"first_due_date"`
+	Status                  InstallmentStatus `gorm:"type:ENUM('active', 'completed', 'cancelled');default:'active'" json:"status"`
+	SettlementTransactionID *uint             `json:"settlement_transaction_id"` // 提前结清时生成的交易
+	CancelledAt             *time.Time        `json:"cancelled_at"`
+	CreatedBy               uint              `json:"created_by"`
+	CreatedAt               time.Time         `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt               time.Time         `json:"updated_at" gorm:"autoUpdateTime"`
+	Installments            []Installment     `gorm:"foreignKey:PlanID" json:"installments,omitempty"`
+}
+
+// 分期还款计划表：每期的本金和手续费，到期后生成交易
+type Installment struct {
+	ID            uint            `gorm:"primaryKey" json:"id"`
+	PlanID        uint            `json:"plan_id" gorm:"uniqueIndex:idx_installment_seq"`
+	Seq           int             `json:"seq" gorm:"uniqueIndex:idx_installment_seq"`
+	DueDate       time.Time       `gorm:"type:DATE;not null" json:"due_date"`
+	Principal     decimal.Decimal `gorm:"type:DECIMAL(12,2);not null" json:"principal"`
+	Fee           decimal.Decimal `gorm:"type:DECIMAL(12,2);default:0" json:"fee"`
+	TransactionID *uint           `json:"transaction_id"` // 生成的交易，手续费为交易的拆分明细
+	PostedAt      *time.Time      `json:"posted_at"`      // 登记生成交易的时间
+}
+
+// 分类预算表：预算覆盖分类及其所有子分类，金额以家庭本位币计
+type Budget struct {
+	ID         uint            `gorm:"primaryKey" json:"id"`
+	FamilyID   uint            `json:"family_id" gorm:"uniqueIndex:idx_budget_category"`
+	CategoryID uint            `json:"category_id" gorm:"uniqueIndex:idx_budget_category"`
+	Category   *Category       `json:"category,omitempty" gorm:"foreignKey:CategoryID"`
+	Period     Period          `gorm:"type:ENUM('weekly', 'monthly', 'yearly');default:'monthly';uniqueIndex:idx_budget_category" json:"period"`
+	Amount     decimal.Decimal `gorm:"type:DECIMAL(12,2);not null" json:"amount"` // 每期预算，信封预算中作为新周期的默认分配金额
+	Rollover   bool            `gorm:"default:false" json:"rollover"`             // 信封预算：周期结余或超支结转到下一周期
+	CreatedBy  uint            `json:"created_by"`
+	CreatedAt  time.Time       `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt  time.Time       `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// 预算周期分配表：记录每个周期分配到信封的金额
+type BudgetAllocation struct {
+	ID          uint            `gorm:"primaryKey" json:"id"`
+	BudgetID    uint            `json:"budget_id" gorm:"uniqueIndex:idx_budget_allocation"`
+	PeriodStart time.Time       `gorm:"type:DATE;uniqueIndex:idx_budget_allocation" json:"period_start"`
+	Amount      decimal.Decimal `gorm:"type:DECIMAL(12,2);not null" json:"amount"`
+	CreatedAt   time.Time       `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time       `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// 信封调拨表：周期内在两个预算信封之间移动金额
+type BudgetMove struct {
+	ID           uint            `gorm:"primaryKey" json:"id"`
+	FamilyID     uint            `json:"family_id" gorm:"index"`
+	FromBudgetID uint            `json:"from_budget_id" gorm:"index"`
+	ToBudgetID   uint            `json:"to_budget_id" gorm:"index"`
+	PeriodStart  time.Time       `gorm:"type:DATE" json:"period_start"`
+	Amount       decimal.Decimal `gorm:"type:DECIMAL(12,2);not null" json:"amount"`
+	Note         string          `gorm:"size:500" json:"note"`
+	CreatedBy    uint            `json:"created_by"`
+	CreatedAt    time.Time       `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// 储蓄目标表：进度来自关联账户的余额或关联标签的交易，以及手动登记的存入
+type SavingsGoal struct {
+	ID           uint            `gorm:"primaryKey" json:"id"`
+	FamilyID     uint            `json:"family_id" gorm:"index"`
+	Name         string          `gorm:"size:100;not null" json:"name"`
+	TargetAmount decimal.Decimal `gorm:"type:DECIMAL(12,2);not null" json:"target_amount"` // 以家庭本位币计
+	TargetDate   *time.Time      `gorm:"type:DATE" json:"target_date"`
+	AccountID    *uint           `json:"account_id"` // 关联账户，账户余额计入目标
+	TagID        *uint           `json:"tag_id"`     // 关联标签，开始日期之后带该标签的交易计入目标
+	StartDate    time.Time       `gorm:"type:DATE;not null" json:"start_date"`
+	Note         string          `gorm:"type:TEXT" json:"note"`
+	CreatedBy    uint            `json:"created_by"`
+	CreatedAt    time.Time       `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt    time.Time       `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// 储蓄目标手动存入表：金额为负表示从目标中取出
+type GoalContribution struct {
+	ID            uint            `gorm:"primaryKey" json:"id"`
+	GoalID        uint            `json:"goal_id" gorm:"index"`
+	Amount        decimal.Decimal `gorm:"type:DECIMAL(12,2);not null" json:"amount"` // 以家庭本位币计
+	ContributedAt time.Time       `gorm:"type:DATE;not null" json:"contributed_at"`
+	Note          string          `gorm:"size:500" json:"note"`
+	CreatedBy     uint            `json:"created_by"`
+	CreatedAt     time.Time       `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// 成员消费限额表：成员每个周期的支出（含待审批和计划交易）不超过限额，指定分类时只统计该分类及其子分类，金额以家庭本位币计
+type SpendingLimit struct {
+	ID         uint            `gorm:"primaryKey" json:"id"`
+	FamilyID   uint            `json:"family_id" gorm:"index"`
+	MemberID   uint            `json:"member_id" gorm:"index"`
+	CategoryID *uint           `json:"category_id"` // 为空表示统计所有支出
+	Category   *Category       `json:"category,omitempty" gorm:"foreignKey:CategoryID"`
+	Period     Period          `gorm:"type:ENUM('weekly', 'monthly', 'yearly');default:'monthly'" json:"period"`
+	Amount     decimal.Decimal `gorm:"type:DECIMAL(12,2);not null" json:"amount"`
+	Action     LimitAction     `gorm:"type:ENUM('reject', 'approve');default:'reject'" json:"action"`
+	CreatedBy  uint            `json:"created_by"`
+	CreatedAt  time.Time       `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt  time.Time       `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// 周期交易模板表：按计划定期生成交易，交易字段与流水表含义相同
+type RecurringTemplate struct {
+	ID            uint            `gorm:"primaryKey" json:"id"`
+	FamilyID      uint            `json:"family_id" gorm:"index"`
+	MemberID      uint            `json:"member_id"` // 生成的交易记在该成员名下
+	Name          string          `gorm:"size:100;not null" json:"name"`
+	Type          TransactionType `gorm:"type:ENUM('income', 'expense', 'transfer');not null" json:"type"`
+	Amount        decimal.Decimal `gorm:"type:DECIMAL(12,2);not null" json:"amount"`
+	Currency      string          `gorm:"size:3" json:"currency"` // 为空时按账户币种或家庭本位币
+	CategoryID    *uint           `json:"category_id"`
+	AccountID     *uint           `json:"account_id"`
+	ToAccountID   *uint           `json:"to_account_id"`
+	Fee           decimal.Decimal `gorm:"type:DECIMAL(12,2);default:0" json:"fee"`
+	PaymentMethod string          `gorm:"size:50" json:"payment_method"`
+	Note          string          `gorm:"type:TEXT" json:"note"`
+	Frequency     Frequency       `gorm:"type:ENUM('daily', 'weekly', 'monthly', 'last_business_day', 'yearly');not null" json:"frequency"`
+	Interval      int             `gorm:"column:repeat_interval;default:1" json:"interval"` // 间隔，如每2周
+	DayOfMonth    int             `json:"day_of_month"`                                     // 按月和按年时的日期，默认为开始日期的日
+	MonthOfYear   int             `json:"month_of_year"`                                    // 按年时的月份，默认为开始日期的月
+	StartDate     time.Time       `gorm:"type:DATE;not null" json:"start_date"`
+	EndDate       *time.Time      `gorm:"type:DATE" json:"end_date"`
+	NextRunDate   *time.Time      `gorm:"type:DATE;index" json:"next_run_date"` // 下一次待生成的日期，为空表示已结束
+	IsActive      bool            `gorm:"default:true" json:"is_active"`
+	CreatedBy     uint            `json:"created_by"`
+	CreatedAt     time.Time       `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt     time.Time       `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// 周期交易生成记录表：每个模板每个日期只生成一次，生成失败时记录原因并在下次运行时重试
+type RecurringOccurrence struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	TemplateID     uint       `json:"template_id" gorm:"uniqueIndex:idx_recurring_occurrence"`
+	OccurrenceDate time.Time  `gorm:"type:DATE;uniqueIndex:idx_recurring_occurrence" json:"occurrence_date"`
+	TransactionID  *uint      `json:"transaction_id"`
+	Error          string     `gorm:"size:500" json:"error"`
+	ClaimedAt      *time.Time `json:"claimed_at"` // 登记生成交易的时间，超时仍未生成交易的记录可重新登记
+	CreatedAt      time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// 账单表：到期前按提醒提前天数发送提醒，付款后关联实际交易
+type Bill struct {
+	ID             uint            `gorm:"primaryKey" json:"id"`
+	FamilyID       uint            `json:"family_id" gorm:"index"`
+	Name           string          `gorm:"size:100;not null" json:"name"`
+	Payee          string          `gorm:"size:100" json:"payee"`
+	CategoryID     *uint           `json:"category_id"`
+	ExpectedAmount decimal.Decimal `gorm:"type:DECIMAL(12,2);not null" json:"expected_amount"`
+	Currency       string          `gorm:"size:3;default:'CNY'" json:"currency"`
+	DueDate        time.Time       `gorm:"type:DATE;not null;index" json:"due_date"`
+	ReminderDays   int             `gorm:"not null" json:"reminder_days"` // 到期前几天提醒，0 表示到期当天提醒
+	Status         BillStatus      `gorm:"type:ENUM('unpaid', 'paid');default:'unpaid'" json:"status"`
+	TransactionID  *uint           `json:"transaction_id" gorm:"uniqueIndex"` // 付款交易
+	PaidAt         *time.Time      `json:"paid_at"`
+	RemindedAt     *time.Time      `json:"reminded_at"` // 已发送提醒的时间，修改到期日或提醒天数后重置
+	Note           string          `gorm:"type:TEXT" json:"note"`
+	CreatedBy      uint            `json:"created_by"`
+	CreatedAt      time.Time       `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt      time.Time       `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// 家庭通知渠道表：账单提醒等通知发送到家庭启用的所有渠道
+type NotificationChannel struct {
+	ID        uint        `gorm:"primaryKey" json:"id"`
+	FamilyID  uint        `json:"family_id" gorm:"index"`
+	Name      string      `gorm:"size:100;not null" json:"name"`
+	Type      ChannelType `gorm:"type:ENUM('in_app', 'webhook');not null" json:"type"`
+	URL       string      `gorm:"size:500" json:"url"` // webhook 推送地址
+	Secret    string      `gorm:"size:100" json:"-"`   // webhook 签名密钥，请求头 X-Signature 为请求体的 HMAC-SHA256
+	IsActive  bool        `gorm:"default:true" json:"is_active"`
+	CreatedBy uint        `json:"created_by"`
+	CreatedAt time.Time   `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time   `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// 站内通知表
+type Notification struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	FamilyID   uint      `json:"family_id" gorm:"index"`
+	Title      string    `gorm:"size:200;not null" json:"title"`
+	Content    string    `gorm:"type:TEXT" json:"content"`
+	EntityType string    `gorm:"size:50" json:"entity_type"`
+	EntityID   uint      `json:"entity_id"`
+	CreatedAt  time.Time `json:"created_at" gorm:"autoCreateTime"`
 }
 
 // 流水-标签关联表
@@ -119,3 +581,28 @@ type TransactionTag struct {
 	Tag           Tag         `json:"tag,omitempty" gorm:"foreignKey:TagID"`
 	CreatedAt     time.Time   `json:"created_at" gorm:"autoCreateTime"`
 }
+
+// 汇率表：某日 1 单位 FromCurrency 兑换 ToCurrency 的数量
+type ExchangeRate struct {
+	ID           uint            `gorm:"primaryKey" json:"id"`
+	FamilyID     uint            `json:"family_id" gorm:"uniqueIndex:idx_exchange_rate"`
+	FromCurrency string          `gorm:"size:3;not null;uniqueIndex:idx_exchange_rate" json:"from_currency"`
+	ToCurrency   string          `gorm:"size:3;not null;uniqueIndex:idx_exchange_rate" json:"to_currency"`
+	RateDate     time.Time       `gorm:"type:DATE;not null;uniqueIndex:idx_exchange_rate" json:"rate_date"`
+	Rate         decimal.Decimal `gorm:"type:DECIMAL(18,8);not null" json:"rate"`
+	CreatedAt    time.Time       `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt    time.Time       `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// 审计日志表（只追加，不提供修改和删除）
+type AuditLog struct {
+	ID         uint            `gorm:"primaryKey" json:"id"`
+	FamilyID   uint            `json:"family_id" gorm:"index"`
+	ActorID    uint            `json:"actor_id" gorm:"index"` // 操作成员，0 表示系统或注册流程
+	EntityType string          `gorm:"size:50;index" json:"entity_type"`
+	EntityID   uint            `json:"entity_id" gorm:"index"`
+	Action     AuditAction     `gorm:"size:20;not null" json:"action"`
+	Before     json.RawMessage `gorm:"type:TEXT" json:"before"`
+	After      json.RawMessage `gorm:"type:TEXT" json:"after"`
+	CreatedAt  time.Time       `json:"created_at" gorm:"autoCreateTime;index"`
+}
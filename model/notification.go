@@ -0,0 +1,103 @@
+package model
+
+import (
+	"github.com/KQLXK/Family-Finance-System/database"
+	"log"
+	"sync"
+)
+
+// NotificationDao 通知渠道和站内通知数据访问对象
+type NotificationDao struct{}
+
+var (
+	notificationOnce sync.Once
+	notificationDao  *NotificationDao
+)
+
+// NewNotificationDaoInstance 返回 NotificationDao 单例实例
+func NewNotificationDaoInstance() *NotificationDao {
+	notificationOnce.Do(func() {
+		notificationDao = &NotificationDao{}
+	})
+	return notificationDao
+}
+
+// CreateChannel 创建通知渠道
+func (NotificationDao) CreateChannel(channel *NotificationChannel) error {
+	if err := database.DB.Create(channel).Error; err != nil {
+		log.Printf("创建通知渠道失败: %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetChannelByID 根据ID获取通知渠道
+func (NotificationDao) GetChannelByID(id uint) (*NotificationChannel, error) {
+	var channel NotificationChannel
+	if err := database.DB.First(&channel, id).Error; err != nil {
+		log.Printf("获取通知渠道失败 ID=%d: %v", id, err)
+		return nil, err
+	}
+	return &channel, nil
+}
+
+// GetChannelsByFamilyID 获取家庭的通知渠道，onlyActive 为 true 时只返回启用的渠道
+func (NotificationDao) GetChannelsByFamilyID(familyID uint, onlyActive bool) ([]NotificationChannel, error) {
+	var channels []NotificationChannel
+	query := database.DB.Where("family_id = ?", familyID)
+	if onlyActive {
+		query = query.Where("is_active = ?", true)
+	}
+	if err := query.Order("id").Find(&channels).Error; err != nil {
+		log.Printf("获取家庭通知渠道失败 FamilyID=%d: %v", familyID, err)
+		return nil, err
+	}
+	return channels, nil
+}
+
+// UpdateChannel 更新通知渠道
+func (NotificationDao) UpdateChannel(channel *NotificationChannel) error {
+	err := database.DB.Model(channel).Select("name", "url", "secret", "is_active", "updated_at").Updates(channel).Error
+	if err != nil {
+		log.Printf("更新通知渠道失败 ID=%d: %v", channel.ID, err)
+		return err
+	}
+	return nil
+}
+
+// DeleteChannel 删除通知渠道
+func (NotificationDao) DeleteChannel(id uint) error {
+	if err := database.DB.Delete(&NotificationChannel{}, id).Error; err != nil {
+		log.Printf("删除通知渠道失败 ID=%d: %v", id, err)
+		return err
+	}
+	return nil
+}
+
+// CreateNotification 保存站内通知
+func (NotificationDao) CreateNotification(notification *Notification) error {
+	if err := database.DB.Create(notification).Error; err != nil {
+		log.Printf("保存站内通知失败: %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetNotificationsByFamilyID 分页获取家庭的站内通知，按时间倒序
+func (NotificationDao) GetNotificationsByFamilyID(familyID uint, page, pageSize int) ([]Notification, int64, error) {
+	var notifications []Notification
+	var total int64
+
+	query := database.DB.Model(&Notification{}).Where("family_id = ?", familyID)
+	if err := query.Count(&total).Error; err != nil {
+		log.Printf("获取站内通知总数失败 FamilyID=%d: %v", familyID, err)
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("created_at DESC, id DESC").Offset(offset).Limit(pageSize).Find(&notifications).Error; err != nil {
+		log.Printf("获取站内通知失败 FamilyID=%d: %v", familyID, err)
+		return nil, 0, err
+	}
+	return notifications, total, nil
+}
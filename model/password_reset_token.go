@@ -0,0 +1,66 @@
+package model
+
+import (
+	"github.com/KQLXK/Family-Finance-System/database"
+	"log"
+	"sync"
+	"time"
+)
+
+// PasswordResetTokenDao 密码重置令牌数据访问对象
+type PasswordResetTokenDao struct{}
+
+var (
+	passwordResetTokenOnce sync.Once
+	passwordResetTokenDao  *PasswordResetTokenDao
+)
+
+// NewPasswordResetTokenDaoInstance 返回 PasswordResetTokenDao 单例实例
+func NewPasswordResetTokenDaoInstance() *PasswordResetTokenDao {
+	passwordResetTokenOnce.Do(func() {
+		passwordResetTokenDao = &PasswordResetTokenDao{}
+	})
+	return passwordResetTokenDao
+}
+
+// CreateToken 创建密码重置令牌
+func (PasswordResetTokenDao) CreateToken(token *PasswordResetToken) error {
+	if err := database.DB.Create(token).Error; err != nil {
+		log.Printf("创建密码重置令牌失败: %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetTokenByHash 根据令牌哈希获取密码重置令牌
+func (PasswordResetTokenDao) GetTokenByHash(tokenHash string) (*PasswordResetToken, error) {
+	var token PasswordResetToken
+	if err := database.DB.Where("token_hash = ?", tokenHash).First(&token).Error; err != nil {
+		log.Printf("获取密码重置令牌失败: %v", err)
+		return nil, err
+	}
+	return &token, nil
+}
+
+// MarkTokenUsed 将令牌标记为已使用，令牌已被使用时返回 false
+func (PasswordResetTokenDao) MarkTokenUsed(id uint) (bool, error) {
+	result := database.DB.Model(&PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		log.Printf("标记密码重置令牌失败 ID=%d: %v", id, result.Error)
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// InvalidateTokensByMemberID 作废成员所有未使用的重置令牌
+func (PasswordResetTokenDao) InvalidateTokensByMemberID(memberID uint) error {
+	if err := database.DB.Model(&PasswordResetToken{}).
+		Where("member_id = ? AND used_at IS NULL", memberID).
+		Update("used_at", time.Now()).Error; err != nil {
+		log.Printf("作废密码重置令牌失败 MemberID=%d: %v", memberID, err)
+		return err
+	}
+	return nil
+}
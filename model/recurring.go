@@ -0,0 +1,172 @@
+package model
+
+import (
+	"github.com/KQLXK/Family-Finance-System/database"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"log"
+	"sync"
+	"time"
+)
+
+// RecurringDao 周期交易模板数据访问对象
+type RecurringDao struct{}
+
+var (
+	recurringOnce sync.Once
+	recurringDao  *RecurringDao
+)
+
+// NewRecurringDaoInstance 返回 RecurringDao 单例实例
+func NewRecurringDaoInstance() *RecurringDao {
+	recurringOnce.Do(func() {
+		recurringDao = &RecurringDao{}
+	})
+	return recurringDao
+}
+
+// CreateTemplate 创建周期交易模板
+func (RecurringDao) CreateTemplate(template *RecurringTemplate) error {
+	if err := database.DB.Create(template).Error; err != nil {
+		log.Printf("创建周期交易模板失败: %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetTemplateByID 根据ID获取周期交易模板
+func (RecurringDao) GetTemplateByID(id uint) (*RecurringTemplate, error) {
+	var template RecurringTemplate
+	if err := database.DB.First(&template, id).Error; err != nil {
+		log.Printf("获取周期交易模板失败 ID=%d: %v", id, err)
+		return nil, err
+	}
+	return &template, nil
+}
+
+// GetTemplatesByFamilyID 获取家庭的周期交易模板
+func (RecurringDao) GetTemplatesByFamilyID(familyID uint) ([]RecurringTemplate, error) {
+	var templates []RecurringTemplate
+	if err := database.DB.Where("family_id = ?", familyID).Order("id").Find(&templates).Error; err != nil {
+		log.Printf("获取家庭周期交易模板失败 FamilyID=%d: %v", familyID, err)
+		return nil, err
+	}
+	return templates, nil
+}
+
+// UpdateTemplate 更新周期交易模板，家庭和创建者不能修改
+func (RecurringDao) UpdateTemplate(template *RecurringTemplate) error {
+	err := database.DB.Model(template).
+		Select("member_id", "name", "type", "amount", "currency", "category_id", "account_id", "to_account_id", "fee",
+			"payment_method", "note", "frequency", "repeat_interval", "day_of_month", "month_of_year",
+			"start_date", "end_date", "next_run_date", "is_active", "updated_at").
+		Updates(template).Error
+	if err != nil {
+		log.Printf("更新周期交易模板失败 ID=%d: %v", template.ID, err)
+		return err
+	}
+	return nil
+}
+
+// DeleteTemplate 删除周期交易模板及其生成记录，已生成的交易保留
+func (RecurringDao) DeleteTemplate(id uint) error {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("template_id = ?", id).Delete(&RecurringOccurrence{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&RecurringTemplate{}, id).Error
+	})
+	if err != nil {
+		log.Printf("删除周期交易模板失败 ID=%d: %v", id, err)
+		return err
+	}
+	return nil
+}
+
+// GetDueTemplateIDs 获取下一次生成日期不晚于 now 的启用模板
+func (RecurringDao) GetDueTemplateIDs(now time.Time) ([]uint, error) {
+	var ids []uint
+	if err := database.DB.Model(&RecurringTemplate{}).
+		Where("is_active = ? AND next_run_date IS NOT NULL AND next_run_date <= ?", true, now.Format("2006-01-02")).
+		Order("id").
+		Pluck("id", &ids).Error; err != nil {
+		log.Printf("获取到期周期交易模板失败: %v", err)
+		return nil, err
+	}
+	return ids, nil
+}
+
+// AdvanceNextRun 将模板的下一次生成日期从 from 推进到 next，日期已被其他任务推进时返回 false
+func (RecurringDao) AdvanceNextRun(id uint, from time.Time, next *time.Time) (bool, error) {
+	result := database.DB.Model(&RecurringTemplate{}).
+		Where("id = ? AND next_run_date = ?", id, from.Format("2006-01-02")).
+		Update("next_run_date", next)
+	if result.Error != nil {
+		log.Printf("更新周期交易模板生成日期失败 ID=%d: %v", id, result.Error)
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ReserveOccurrence 登记某个日期的生成记录，该日期已登记过时返回 false
+func (RecurringDao) ReserveOccurrence(occurrence *RecurringOccurrence) (bool, error) {
+	result := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(occurrence)
+	if result.Error != nil {
+		log.Printf("登记周期交易生成记录失败 TemplateID=%d: %v", occurrence.TemplateID, result.Error)
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// GetOccurrence 获取模板某个日期的生成记录
+func (RecurringDao) GetOccurrence(templateID uint, date time.Time) (*RecurringOccurrence, error) {
+	var occurrence RecurringOccurrence
+	if err := database.DB.Where("template_id = ? AND occurrence_date = ?", templateID, date.Format("2006-01-02")).
+		First(&occurrence).Error; err != nil {
+		log.Printf("获取周期交易生成记录失败 TemplateID=%d: %v", templateID, err)
+		return nil, err
+	}
+	return &occurrence, nil
+}
+
+// ReclaimOccurrence 以登记时间 claimedAt 重新登记未生成交易的记录，只有生成失败或登记早于 staleBefore 的记录可重新登记，
+// 记录正由其他任务生成时返回 false
+func (RecurringDao) ReclaimOccurrence(id uint, claimedAt, staleBefore time.Time) (bool, error) {
+	result := database.DB.Model(&RecurringOccurrence{}).
+		Where("id = ? AND transaction_id IS NULL", id).
+		Where("error <> '' OR claimed_at IS NULL OR claimed_at < ?", staleBefore).
+		Updates(map[string]interface{}{
+			"claimed_at": claimedAt,
+			"error":      "",
+		})
+	if result.Error != nil {
+		log.Printf("重新登记周期交易生成记录失败 ID=%d: %v", id, result.Error)
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// FinishOccurrence 记录以登记时间 claimedAt 登记的生成记录生成的交易或失败原因，登记已失效时返回 false
+func (RecurringDao) FinishOccurrence(id uint, claimedAt time.Time, transactionID *uint, errMsg string) (bool, error) {
+	result := database.DB.Model(&RecurringOccurrence{}).
+		Where("id = ? AND transaction_id IS NULL AND claimed_at = ?", id, claimedAt).
+		Updates(map[string]interface{}{
+			"transaction_id": transactionID,
+			"error":          errMsg,
+		})
+	if result.Error != nil {
+		log.Printf("更新周期交易生成记录失败 ID=%d: %v", id, result.Error)
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// GetOccurrencesByTemplateID 获取模板的生成记录，按日期倒序
+func (RecurringDao) GetOccurrencesByTemplateID(templateID uint) ([]RecurringOccurrence, error) {
+	var occurrences []RecurringOccurrence
+	if err := database.DB.Where("template_id = ?", templateID).Order("occurrence_date DESC").Find(&occurrences).Error; err != nil {
+		log.Printf("获取周期交易生成记录失败 TemplateID=%d: %v", templateID, err)
+		return nil, err
+	}
+	return occurrences, nil
+}
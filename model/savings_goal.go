@@ -0,0 +1,152 @@
+package model
+
+import (
+	"github.com/KQLXK/Family-Finance-System/database"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"log"
+	"sync"
+	"time"
+)
+
+// SavingsGoalDao 储蓄目标数据访问对象
+type SavingsGoalDao struct{}
+
+var (
+	savingsGoalOnce sync.Once
+	savingsGoalDao  *SavingsGoalDao
+)
+
+// NewSavingsGoalDaoInstance 返回 SavingsGoalDao 单例实例
+func NewSavingsGoalDaoInstance() *SavingsGoalDao {
+	savingsGoalOnce.Do(func() {
+		savingsGoalDao = &SavingsGoalDao{}
+	})
+	return savingsGoalDao
+}
+
+// CreateGoal 创建储蓄目标
+func (SavingsGoalDao) CreateGoal(goal *SavingsGoal) error {
+	if err := database.DB.Create(goal).Error; err != nil {
+		log.Printf("创建储蓄目标失败: %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetGoalByID 根据ID获取储蓄目标
+func (SavingsGoalDao) GetGoalByID(id uint) (*SavingsGoal, error) {
+	var goal SavingsGoal
+	if err := database.DB.First(&goal, id).Error; err != nil {
+		log.Printf("获取储蓄目标失败 ID=%d: %v", id, err)
+		return nil, err
+	}
+	return &goal, nil
+}
+
+// GetGoalsByFamilyID 获取家庭的储蓄目标
+func (SavingsGoalDao) GetGoalsByFamilyID(familyID uint) ([]SavingsGoal, error) {
+	var goals []SavingsGoal
+	if err := database.DB.Where("family_id = ?", familyID).Order("target_date IS NULL, target_date, id").Find(&goals).Error; err != nil {
+		log.Printf("获取家庭储蓄目标失败 FamilyID=%d: %v", familyID, err)
+		return nil, err
+	}
+	return goals, nil
+}
+
+// UpdateGoal 更新储蓄目标
+func (SavingsGoalDao) UpdateGoal(goal *SavingsGoal) error {
+	if err := database.DB.Model(goal).
+		Select("name", "target_amount", "target_date", "account_id", "tag_id", "start_date", "note", "updated_at").
+		Updates(goal).Error; err != nil {
+		log.Printf("更新储蓄目标失败 ID=%d: %v", goal.ID, err)
+		return err
+	}
+	return nil
+}
+
+// DeleteGoal 删除储蓄目标及其手动存入记录
+func (SavingsGoalDao) DeleteGoal(id uint) error {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("goal_id = ?", id).Delete(&GoalContribution{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&SavingsGoal{}, id).Error
+	})
+	if err != nil {
+		log.Printf("删除储蓄目标失败 ID=%d: %v", id, err)
+		return err
+	}
+	return nil
+}
+
+// CreateContribution 创建手动存入记录
+func (SavingsGoalDao) CreateContribution(contribution *GoalContribution) error {
+	if err := database.DB.Create(contribution).Error; err != nil {
+		log.Printf("创建储蓄存入记录失败: %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetContributionByID 根据ID获取手动存入记录
+func (SavingsGoalDao) GetContributionByID(id uint) (*GoalContribution, error) {
+	var contribution GoalContribution
+	if err := database.DB.First(&contribution, id).Error; err != nil {
+		log.Printf("获取储蓄存入记录失败 ID=%d: %v", id, err)
+		return nil, err
+	}
+	return &contribution, nil
+}
+
+// GetContributionsByGoalID 获取储蓄目标的手动存入记录，按日期排序
+func (SavingsGoalDao) GetContributionsByGoalID(goalID uint) ([]GoalContribution, error) {
+	var contributions []GoalContribution
+	if err := database.DB.Where("goal_id = ?", goalID).Order("contributed_at, id").Find(&contributions).Error; err != nil {
+		log.Printf("获取储蓄存入记录失败 GoalID=%d: %v", goalID, err)
+		return nil, err
+	}
+	return contributions, nil
+}
+
+// GetContributionTotal 统计截至 until 的手动存入总额
+func (SavingsGoalDao) GetContributionTotal(goalID uint, until time.Time) (decimal.Decimal, error) {
+	var total decimal.Decimal
+	if err := database.DB.Model(&GoalContribution{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("goal_id = ? AND contributed_at <= ?", goalID, until.Format("2006-01-02")).
+		Row().Scan(&total); err != nil {
+		log.Printf("统计储蓄存入金额失败 GoalID=%d: %v", goalID, err)
+		return decimal.Zero, err
+	}
+	return total, nil
+}
+
+// DeleteContribution 删除手动存入记录
+func (SavingsGoalDao) DeleteContribution(id uint) error {
+	if err := database.DB.Delete(&GoalContribution{}, id).Error; err != nil {
+		log.Printf("删除储蓄存入记录失败 ID=%d: %v", id, err)
+		return err
+	}
+	return nil
+}
+
+// GetTagNetAmount 统计 [since, until] 内带该标签的有效交易净额：收入、转账和退款计入，支出冲减。
+// 结果按币种和交易日期分组
+func (SavingsGoalDao) GetTagNetAmount(familyID, tagID uint, since, until time.Time) ([]SummaryRow, error) {
+	rows, err := database.DB.Table("transactions").
+		Select("'', transactions.currency, DATE(transactions.transaction_time) as transaction_date, "+
+			"SUM(CASE WHEN transactions.type = ? THEN -transactions.amount ELSE transactions.amount END)", Expense).
+		Joins("JOIN transaction_tags ON transaction_tags.transaction_id = transactions.id").
+		Where("transaction_tags.tag_id = ? AND transactions.family_id = ? AND transactions.status = ? AND transactions.transaction_time BETWEEN ? AND ? AND transactions.deleted_at IS NULL",
+			tagID, familyID, Valid, since, until).
+		Group("transactions.currency, transaction_date").
+		Rows()
+	if err != nil {
+		log.Printf("统计标签交易金额失败 TagID=%d: %v", tagID, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanSummaryRows(rows), nil
+}
@@ -0,0 +1,56 @@
+package model
+
+import (
+	"github.com/KQLXK/Family-Finance-System/database"
+	"log"
+	"sync"
+)
+
+// SettlementDao 结算记录数据访问对象
+type SettlementDao struct{}
+
+var (
+	settlementOnce sync.Once
+	settlementDao  *SettlementDao
+)
+
+// NewSettlementDaoInstance 返回 SettlementDao 单例实例
+func NewSettlementDaoInstance() *SettlementDao {
+	settlementOnce.Do(func() {
+		settlementDao = &SettlementDao{}
+	})
+	return settlementDao
+}
+
+// CreateSettlement 创建结算记录
+func (SettlementDao) CreateSettlement(settlement *Settlement) error {
+	if err := database.DB.Create(settlement).Error; err != nil {
+		log.Printf("创建结算记录失败: %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetSettlementsByFamilyID 获取家庭的结算记录，按时间倒序
+func (SettlementDao) GetSettlementsByFamilyID(familyID uint) ([]Settlement, error) {
+	var settlements []Settlement
+	if err := database.DB.Where("family_id = ?", familyID).Order("created_at DESC").Find(&settlements).Error; err != nil {
+		log.Printf("获取结算记录失败 FamilyID=%d: %v", familyID, err)
+		return nil, err
+	}
+	return settlements, nil
+}
+
+// GetEffectiveSettlements 获取转账交易仍然有效的结算记录，交易被删除或未通过审批的结算不计入余额
+func (SettlementDao) GetEffectiveSettlements(familyID uint) ([]Settlement, error) {
+	var settlements []Settlement
+	if err := database.DB.Table("settlements").
+		Select("settlements.*").
+		Joins("JOIN transactions ON transactions.id = settlements.transaction_id").
+		Where("settlements.family_id = ? AND transactions.status = ? AND transactions.deleted_at IS NULL", familyID, Valid).
+		Find(&settlements).Error; err != nil {
+		log.Printf("获取有效结算记录失败 FamilyID=%d: %v", familyID, err)
+		return nil, err
+	}
+	return settlements, nil
+}
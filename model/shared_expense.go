@@ -0,0 +1,119 @@
+package model
+
+import (
+	"errors"
+	"github.com/KQLXK/Family-Finance-System/database"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"log"
+	"sync"
+	"time"
+)
+
+// SharedExpenseDao 共同支出数据访问对象
+type SharedExpenseDao struct{}
+
+var (
+	sharedExpenseOnce sync.Once
+	sharedExpenseDao  *SharedExpenseDao
+)
+
+// MemberAmountRow 按成员、币种和交易日期汇总的原币金额，由服务层换算为本位币
+type MemberAmountRow struct {
+	MemberID uint
+	Currency string
+	Date     time.Time
+	Amount   decimal.Decimal
+}
+
+// NewSharedExpenseDaoInstance 返回 SharedExpenseDao 单例实例
+func NewSharedExpenseDaoInstance() *SharedExpenseDao {
+	sharedExpenseOnce.Do(func() {
+		sharedExpenseDao = &SharedExpenseDao{}
+	})
+	return sharedExpenseDao
+}
+
+// SaveSharedExpense 保存交易的分摊方案，已有方案时整体替换
+func (SharedExpenseDao) SaveSharedExpense(expense *SharedExpense) error {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var existingIDs []uint
+		if err := tx.Model(&SharedExpense{}).Where("transaction_id = ?", expense.TransactionID).Pluck("id", &existingIDs).Error; err != nil {
+			return err
+		}
+		if len(existingIDs) > 0 {
+			if err := tx.Where("shared_expense_id IN ?", existingIDs).Delete(&ExpenseShare{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Delete(&SharedExpense{}, existingIDs).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Create(expense).Error
+	})
+	if err != nil {
+		log.Printf("保存共同支出失败 TransactionID=%d: %v", expense.TransactionID, err)
+		return err
+	}
+	return nil
+}
+
+// GetSharedExpenseByTransactionID 获取交易的分摊方案，不存在时返回 nil
+func (SharedExpenseDao) GetSharedExpenseByTransactionID(transactionID uint) (*SharedExpense, error) {
+	var expense SharedExpense
+	err := database.DB.Preload("Shares").Where("transaction_id = ?", transactionID).First(&expense).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("获取共同支出失败 TransactionID=%d: %v", transactionID, err)
+		return nil, err
+	}
+	return &expense, nil
+}
+
+// DeleteSharedExpense 删除共同支出及其分摊明细
+func (SharedExpenseDao) DeleteSharedExpense(id uint) error {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("shared_expense_id = ?", id).Delete(&ExpenseShare{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&SharedExpense{}, id).Error
+	})
+	if err != nil {
+		log.Printf("删除共同支出失败 ID=%d: %v", id, err)
+		return err
+	}
+	return nil
+}
+
+// GetPaidAmounts 按付款成员汇总家庭有效共同支出的金额
+func (SharedExpenseDao) GetPaidAmounts(familyID uint) ([]MemberAmountRow, error) {
+	var rows []MemberAmountRow
+	if err := database.DB.Table("shared_expenses").
+		Select("transactions.member_id, transactions.currency, DATE(transactions.transaction_time) as date, SUM(transactions.amount) as amount").
+		Joins("JOIN transactions ON transactions.id = shared_expenses.transaction_id").
+		Where("shared_expenses.family_id = ? AND transactions.status = ? AND transactions.deleted_at IS NULL", familyID, Valid).
+		Group("transactions.member_id, transactions.currency, date").
+		Scan(&rows).Error; err != nil {
+		log.Printf("统计共同支出付款失败 FamilyID=%d: %v", familyID, err)
+		return nil, err
+	}
+	return rows, nil
+}
+
+// GetOwedAmounts 按分摊成员汇总家庭有效共同支出中各成员应承担的金额
+func (SharedExpenseDao) GetOwedAmounts(familyID uint) ([]MemberAmountRow, error) {
+	var rows []MemberAmountRow
+	if err := database.DB.Table("expense_shares").
+		Select("expense_shares.member_id, transactions.currency, DATE(transactions.transaction_time) as date, SUM(expense_shares.amount) as amount").
+		Joins("JOIN shared_expenses ON shared_expenses.id = expense_shares.shared_expense_id").
+		Joins("JOIN transactions ON transactions.id = shared_expenses.transaction_id").
+		Where("shared_expenses.family_id = ? AND transactions.status = ? AND transactions.deleted_at IS NULL", familyID, Valid).
+		Group("expense_shares.member_id, transactions.currency, date").
+		Scan(&rows).Error; err != nil {
+		log.Printf("统计共同支出分摊失败 FamilyID=%d: %v", familyID, err)
+		return nil, err
+	}
+	return rows, nil
+}
@@ -0,0 +1,110 @@
+package model
+
+import (
+	"github.com/KQLXK/Family-Finance-System/database"
+	"log"
+	"sync"
+	"time"
+)
+
+// SpendingLimitDao 成员消费限额数据访问对象
+type SpendingLimitDao struct{}
+
+var (
+	spendingLimitOnce sync.Once
+	spendingLimitDao  *SpendingLimitDao
+)
+
+// NewSpendingLimitDaoInstance 返回 SpendingLimitDao 单例实例
+func NewSpendingLimitDaoInstance() *SpendingLimitDao {
+	spendingLimitOnce.Do(func() {
+		spendingLimitDao = &SpendingLimitDao{}
+	})
+	return spendingLimitDao
+}
+
+// CreateLimit 创建消费限额
+func (SpendingLimitDao) CreateLimit(limit *SpendingLimit) error {
+	if err := database.DB.Create(limit).Error; err != nil {
+		log.Printf("创建消费限额失败: %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetLimitByID 根据ID获取消费限额
+func (SpendingLimitDao) GetLimitByID(id uint) (*SpendingLimit, error) {
+	var limit SpendingLimit
+	if err := database.DB.Preload("Category").First(&limit, id).Error; err != nil {
+		log.Printf("获取消费限额失败 ID=%d: %v", id, err)
+		return nil, err
+	}
+	return &limit, nil
+}
+
+// GetLimitsByFamilyID 获取家庭所有消费限额
+func (SpendingLimitDao) GetLimitsByFamilyID(familyID uint) ([]SpendingLimit, error) {
+	var limits []SpendingLimit
+	if err := database.DB.Preload("Category").Where("family_id = ?", familyID).Order("member_id, id").Find(&limits).Error; err != nil {
+		log.Printf("获取家庭消费限额失败 FamilyID=%d: %v", familyID, err)
+		return nil, err
+	}
+	return limits, nil
+}
+
+// GetLimitsByMemberID 获取成员的消费限额
+func (SpendingLimitDao) GetLimitsByMemberID(memberID uint) ([]SpendingLimit, error) {
+	var limits []SpendingLimit
+	if err := database.DB.Preload("Category").Where("member_id = ?", memberID).Order("id").Find(&limits).Error; err != nil {
+		log.Printf("获取成员消费限额失败 MemberID=%d: %v", memberID, err)
+		return nil, err
+	}
+	return limits, nil
+}
+
+// UpdateLimit 更新消费限额的金额、周期和处理方式
+func (SpendingLimitDao) UpdateLimit(limit *SpendingLimit) error {
+	err := database.DB.Model(limit).Select("period", "amount", "action", "updated_at").Updates(limit).Error
+	if err != nil {
+		log.Printf("更新消费限额失败 ID=%d: %v", limit.ID, err)
+		return err
+	}
+	return nil
+}
+
+// DeleteLimit 删除消费限额
+func (SpendingLimitDao) DeleteLimit(id uint) error {
+	if err := database.DB.Delete(&SpendingLimit{}, id).Error; err != nil {
+		log.Printf("删除消费限额失败 ID=%d: %v", id, err)
+		return err
+	}
+	return nil
+}
+
+// GetMemberSpending 统计成员在时间范围 [start, end) 内的支出，待审批和计划交易也计入，退款冲减支出。
+// categoryPath 不为空时只统计该分类子树，拆分交易按明细统计；excludeID 用于修改交易时排除交易本身
+func (SpendingLimitDao) GetMemberSpending(memberID uint, categoryPath string, start, end time.Time, excludeID uint) ([]SummaryRow, error) {
+	query := database.DB.Table("transactions").
+		Where("transactions.member_id = ? AND transactions.status IN ? AND transactions.type IN ? AND transactions.transaction_time >= ? AND transactions.transaction_time < ? AND transactions.deleted_at IS NULL AND transactions.id <> ?",
+			memberID, []TransactionStatus{Valid, Pending, Planned}, []TransactionType{Expense, Refund}, start, end, excludeID)
+
+	if categoryPath == "" {
+		query = query.Select("'', transactions.currency, DATE(transactions.transaction_time) as transaction_date, "+
+			"SUM(CASE WHEN transactions.type = ? THEN -transactions.amount ELSE transactions.amount END)", Refund)
+	} else {
+		query = query.Select("'', transactions.currency, DATE(transactions.transaction_time) as transaction_date, "+
+			"SUM(CASE WHEN transactions.type = ? THEN -transactions.amount ELSE COALESCE(transaction_splits.amount, transactions.amount) END)", Refund).
+			Joins("LEFT JOIN transaction_splits ON transaction_splits.transaction_id = transactions.id").
+			Joins("JOIN categories ON categories.id = COALESCE(transaction_splits.category_id, transactions.category_id)").
+			Where("categories.path = ? OR categories.path LIKE ?", categoryPath, categoryPath+"/%")
+	}
+
+	rows, err := query.Group("transactions.currency, transaction_date").Rows()
+	if err != nil {
+		log.Printf("统计成员支出失败 MemberID=%d, Path=%s: %v", memberID, categoryPath, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanSummaryRows(rows), nil
+}
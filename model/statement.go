@@ -0,0 +1,142 @@
+package model
+
+import (
+	"errors"
+	"github.com/KQLXK/Family-Finance-System/database"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"log"
+	"sync"
+	"time"
+)
+
+// StatementDao 信用卡账单数据访问对象
+type StatementDao struct{}
+
+var (
+	statementOnce sync.Once
+	statementDao  *StatementDao
+)
+
+// NewStatementDaoInstance 返回 StatementDao 单例实例
+func NewStatementDaoInstance() *StatementDao {
+	statementOnce.Do(func() {
+		statementDao = &StatementDao{}
+	})
+	return statementDao
+}
+
+// GetStatementCharges 统计账单周期 [start, end) 内信用卡的应还金额：支出和转出（含手续费）增加，
+// 收入和退款冲减，转入信用卡的还款不计入
+func (StatementDao) GetStatementCharges(accountID uint, start, end time.Time) (decimal.Decimal, error) {
+	var total decimal.Decimal
+	if err := database.DB.Model(&Transaction{}).
+		Select(`COALESCE(SUM(CASE
+			WHEN type = ? THEN amount
+			WHEN type = ? THEN amount + fee
+			ELSE -amount END), 0)`, Expense, Transfer).
+		Where("account_id = ? AND status = ? AND transaction_time >= ? AND transaction_time < ?", accountID, Valid, start, end).
+		Row().Scan(&total); err != nil {
+		log.Printf("统计信用卡账单金额失败 AccountID=%d: %v", accountID, err)
+		return decimal.Zero, err
+	}
+	return total, nil
+}
+
+// GetStatementTransactions 获取账单周期 [start, end) 内信用卡的消费记录
+func (StatementDao) GetStatementTransactions(accountID uint, start, end time.Time) ([]Transaction, error) {
+	var transactions []Transaction
+	if err := database.DB.Where("account_id = ? AND status = ? AND transaction_time >= ? AND transaction_time < ?", accountID, Valid, start, end).
+		Preload("Member").Preload("Category").Preload("Labels").Preload("Splits.Category").
+		Order("transaction_time").
+		Find(&transactions).Error; err != nil {
+		log.Printf("获取信用卡账单明细失败 AccountID=%d: %v", accountID, err)
+		return nil, err
+	}
+	return transactions, nil
+}
+
+// CreateStatementPayment 创建账单还款记录
+func (StatementDao) CreateStatementPayment(payment *StatementPayment) error {
+	if err := database.DB.Create(payment).Error; err != nil {
+		log.Printf("创建账单还款记录失败: %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetStatementPaymentByID 根据ID获取账单还款记录
+func (StatementDao) GetStatementPaymentByID(id uint) (*StatementPayment, error) {
+	var payment StatementPayment
+	if err := database.DB.First(&payment, id).Error; err != nil {
+		log.Printf("获取账单还款记录失败 ID=%d: %v", id, err)
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// GetStatementPaymentByTransactionID 根据交易ID获取账单还款记录，不存在时返回 nil
+func (StatementDao) GetStatementPaymentByTransactionID(transactionID uint) (*StatementPayment, error) {
+	var payment StatementPayment
+	err := database.DB.Where("transaction_id = ?", transactionID).First(&payment).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("获取账单还款记录失败 TransactionID=%d: %v", transactionID, err)
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// GetStatementPayments 获取某期账单的还款记录，只包含关联交易仍然有效的记录
+func (StatementDao) GetStatementPayments(accountID uint, statementDate time.Time) ([]StatementPayment, error) {
+	var payments []StatementPayment
+	if err := database.DB.Model(&StatementPayment{}).
+		Joins("JOIN transactions ON transactions.id = statement_payments.transaction_id").
+		Where("statement_payments.account_id = ? AND statement_payments.statement_date = ? AND transactions.status = ? AND transactions.deleted_at IS NULL",
+			accountID, statementDate.Format("2006-01-02"), Valid).
+		Order("statement_payments.id").
+		Find(&payments).Error; err != nil {
+		log.Printf("获取账单还款记录失败 AccountID=%d: %v", accountID, err)
+		return nil, err
+	}
+	return payments, nil
+}
+
+// GetStatementPaidAmounts 按账单日统计信用卡已还金额，只统计关联交易仍然有效的还款
+func (StatementDao) GetStatementPaidAmounts(accountID uint) (map[string]decimal.Decimal, error) {
+	paid := make(map[string]decimal.Decimal)
+
+	rows, err := database.DB.Model(&StatementPayment{}).
+		Select("DATE_FORMAT(statement_payments.statement_date, '%Y-%m-%d'), SUM(statement_payments.amount)").
+		Joins("JOIN transactions ON transactions.id = statement_payments.transaction_id").
+		Where("statement_payments.account_id = ? AND transactions.status = ? AND transactions.deleted_at IS NULL", accountID, Valid).
+		Group("statement_payments.statement_date").
+		Rows()
+	if err != nil {
+		log.Printf("统计信用卡已还金额失败 AccountID=%d: %v", accountID, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var date string
+		var amount decimal.Decimal
+		if err := rows.Scan(&date, &amount); err != nil {
+			log.Printf("扫描统计结果失败: %v", err)
+			continue
+		}
+		paid[date] = amount
+	}
+	return paid, nil
+}
+
+// DeleteStatementPayment 删除账单还款记录
+func (StatementDao) DeleteStatementPayment(id uint) error {
+	if err := database.DB.Delete(&StatementPayment{}, id).Error; err != nil {
+		log.Printf("删除账单还款记录失败 ID=%d: %v", id, err)
+		return err
+	}
+	return nil
+}
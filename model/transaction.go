@@ -1,8 +1,11 @@
 package model
 
 import (
+	"database/sql"
 	"fmt"
 	"github.com/KQLXK/Family-Finance-System/database"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
 	"log"
 	"sync"
 	"time"
@@ -36,7 +39,7 @@ func (TransactionDao) CreateTransaction(transaction *Transaction) error {
 func (TransactionDao) GetTransactionByID(id uint) (*Transaction, error) {
 	var transaction Transaction
 	if err := database.DB.Preload("Family").Preload("Member").Preload("Category").
-		Preload("Labels").First(&transaction, id).Error; err != nil {
+		Preload("Labels").Preload("Splits.Category").First(&transaction, id).Error; err != nil {
 		log.Printf("获取交易失败 ID=%d: %v", id, err)
 		return nil, err
 	}
@@ -63,7 +66,7 @@ func (TransactionDao) GetTransactionsByFamilyID(familyID uint, page, pageSize in
 
 	// 获取分页数据
 	offset := (page - 1) * pageSize
-	if err := query.Preload("Member").Preload("Category").Preload("Labels").
+	if err := query.Preload("Member").Preload("Category").Preload("Labels").Preload("Splits.Category").
 		Order("transaction_time DESC").
 		Offset(offset).Limit(pageSize).
 		Find(&transactions).Error; err != nil {
@@ -88,7 +91,7 @@ func (TransactionDao) GetTransactionsByTimeRange(familyID uint, startTime, endTi
 	}
 
 	// 获取数据
-	if err := query.Preload("Member").Preload("Category").Preload("Labels").
+	if err := query.Preload("Member").Preload("Category").Preload("Labels").Preload("Splits.Category").
 		Order("transaction_time DESC").
 		Find(&transactions).Error; err != nil {
 		log.Printf("获取时间段交易失败 FamilyID=%d: %v", familyID, err)
@@ -100,7 +103,25 @@ func (TransactionDao) GetTransactionsByTimeRange(familyID uint, startTime, endTi
 
 // UpdateTransaction 更新交易信息
 func (TransactionDao) UpdateTransaction(transaction *Transaction) error {
-	if err := database.DB.Save(transaction).Error; err != nil {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Splits").Save(transaction).Error; err != nil {
+			return err
+		}
+
+		// 拆分明细整体替换
+		if err := tx.Where("transaction_id = ?", transaction.ID).Delete(&TransactionSplit{}).Error; err != nil {
+			return err
+		}
+		for i := range transaction.Splits {
+			transaction.Splits[i].ID = 0
+			transaction.Splits[i].TransactionID = transaction.ID
+		}
+		if len(transaction.Splits) > 0 {
+			return tx.Create(&transaction.Splits).Error
+		}
+		return nil
+	})
+	if err != nil {
 		log.Printf("更新交易失败 ID=%d: %v", transaction.ID, err)
 		return err
 	}
@@ -139,16 +160,32 @@ func (TransactionDao) RemoveTagFromTransaction(transactionID, tagID uint) error
 	return nil
 }
 
-func (TransactionDao) GetTransactionSummaryByCategory(familyID uint, startTime, endTime time.Time, transactionType TransactionType) (map[string]float64, error) {
-	summary := make(map[string]float64)
+// SummaryRow 统计结果中按分组键、币种和交易日期汇总的原币金额，由服务层换算为本位币
+type SummaryRow struct {
+	Key      string
+	Currency string
+	Date     time.Time
+	Amount   decimal.Decimal
+}
+
+// GetTransactionSummaryByCategory 按分类统计交易金额（拆分交易按明细统计），结果按分类名称、币种和交易日期分组。
+// 统计支出时退款以负数计入其分类，冲减原支出
+func (TransactionDao) GetTransactionSummaryByCategory(familyID uint, startTime, endTime time.Time, transactionType TransactionType) ([]SummaryRow, error) {
+	types := []TransactionType{transactionType}
+	if transactionType == Expense {
+		types = append(types, Refund)
+	}
 
 	// 执行SQL查询
+	// 有拆分明细的交易按明细行的分类和金额统计
 	rows, err := database.DB.Table("transactions").
-		Select("categories.name, SUM(transactions.amount)").
-		Joins("LEFT JOIN categories ON transactions.category_id = categories.id").
-		Where("transactions.family_id = ? AND transactions.status = ? AND transactions.type = ? AND transactions.transaction_time BETWEEN ? AND ?",
-			familyID, Valid, transactionType, startTime, endTime).
-		Group("categories.name").
+		Select("categories.name, transactions.currency, DATE(transactions.transaction_time) as transaction_date, "+
+			"SUM(CASE WHEN transactions.type = ? THEN -transactions.amount ELSE COALESCE(transaction_splits.amount, transactions.amount) END)", Refund).
+		Joins("LEFT JOIN transaction_splits ON transaction_splits.transaction_id = transactions.id").
+		Joins("LEFT JOIN categories ON categories.id = COALESCE(transaction_splits.category_id, transactions.category_id)").
+		Where("transactions.family_id = ? AND transactions.status = ? AND transactions.type IN ? AND transactions.transaction_time BETWEEN ? AND ? AND transactions.deleted_at IS NULL",
+			familyID, Valid, types, startTime, endTime).
+		Group("categories.name, transactions.currency, transaction_date").
 		Rows()
 	if err != nil {
 		log.Printf("按分类统计交易金额失败: %v", err)
@@ -156,44 +193,20 @@ func (TransactionDao) GetTransactionSummaryByCategory(familyID uint, startTime,
 	}
 	defer rows.Close()
 
-	// 处理查询结果
-	for rows.Next() {
-		var categoryName string
-		var totalAmount float64
-		if err := rows.Scan(&categoryName, &totalAmount); err != nil {
-			log.Printf("扫描统计结果失败: %v", err)
-			continue
-		}
-		log.Println(categoryName, ":", totalAmount)
-		summary[categoryName] = totalAmount
-	}
-
-	return summary, nil
+	return scanSummaryRows(rows), nil
 }
 
-// GetTransactionSummaryByTime 按时间统计交易金额
-func (TransactionDao) GetTransactionSummaryByTime(familyID uint, startTime, endTime time.Time, groupBy string) (map[string]float64, error) {
-	summary := make(map[string]float64)
-
+// GetTransactionSummaryByTime 按时间统计交易金额，结果按时间段、币种和交易日期分组，退款冲减支出金额
+func (TransactionDao) GetTransactionSummaryByTime(familyID uint, startTime, endTime time.Time, groupBy string) ([]SummaryRow, error) {
 	// 根据分组方式构建SQL
-	var timeFormat string
-	switch groupBy {
-	case "day":
-		timeFormat = "%Y-%m-%d"
-	case "month":
-		timeFormat = "%Y-%m"
-	case "year":
-		timeFormat = "%Y"
-	default:
-		timeFormat = "%Y-%m"
-	}
+	timeFormat := groupTimeFormat(groupBy)
 
 	// 执行SQL查询
 	rows, err := database.DB.Table("transactions").
-		Select(fmt.Sprintf("DATE_FORMAT(transaction_time, '%s') as time_period, SUM(amount)", timeFormat)).
-		Where("family_id = ? AND status = ? AND transaction_time BETWEEN ? AND ?",
-			familyID, Valid, startTime, endTime).
-		Group("time_period").
+		Select(fmt.Sprintf("DATE_FORMAT(transaction_time, '%s') as time_period, currency, DATE(transaction_time) as transaction_date, SUM(CASE WHEN type = ? THEN -amount ELSE amount END)", timeFormat), Refund).
+		Where("family_id = ? AND status = ? AND type <> ? AND transaction_time BETWEEN ? AND ? AND deleted_at IS NULL",
+			familyID, Valid, Transfer, startTime, endTime).
+		Group("time_period, currency, transaction_date").
 		Rows()
 	if err != nil {
 		log.Printf("按时间统计交易金额失败: %v", err)
@@ -201,18 +214,50 @@ func (TransactionDao) GetTransactionSummaryByTime(familyID uint, startTime, endT
 	}
 	defer rows.Close()
 
-	// 处理查询结果
+	return scanSummaryRows(rows), nil
+}
+
+// scanSummaryRows 读取统计查询结果
+func scanSummaryRows(rows *sql.Rows) []SummaryRow {
+	var result []SummaryRow
 	for rows.Next() {
-		var timePeriod string
-		var totalAmount float64
-		if err := rows.Scan(&timePeriod, &totalAmount); err != nil {
+		var key, currency sql.NullString
+		var row SummaryRow
+		if err := rows.Scan(&key, &currency, &row.Date, &row.Amount); err != nil {
 			log.Printf("扫描统计结果失败: %v", err)
 			continue
 		}
-		summary[timePeriod] = totalAmount
+		row.Key = key.String
+		row.Currency = currency.String
+		result = append(result, row)
 	}
+	return result
+}
 
-	return summary, nil
+// GetRefundedAmount 统计原支出已退款金额，包含待审批的退款，excludeID 为修改中的退款
+func (TransactionDao) GetRefundedAmount(originalID, excludeID uint) (decimal.Decimal, error) {
+	var total decimal.Decimal
+	if err := database.DB.Model(&Transaction{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("refund_of_id = ? AND id <> ? AND type = ? AND status IN ?", originalID, excludeID, Refund, []TransactionStatus{Valid, Pending}).
+		Row().Scan(&total); err != nil {
+		log.Printf("统计退款金额失败 TransactionID=%d: %v", originalID, err)
+		return decimal.Zero, err
+	}
+	return total, nil
+}
+
+// GetRefundsByTransactionID 获取原支出的退款记录，不含已删除的退款
+func (TransactionDao) GetRefundsByTransactionID(originalID uint) ([]Transaction, error) {
+	var refunds []Transaction
+	if err := database.DB.Where("refund_of_id = ? AND type = ? AND status <> ?", originalID, Refund, Deleted).
+		Preload("Member").Preload("Category").
+		Order("transaction_time").
+		Find(&refunds).Error; err != nil {
+		log.Printf("获取退款记录失败 TransactionID=%d: %v", originalID, err)
+		return nil, err
+	}
+	return refunds, nil
 }
 
 // TagExistsInTransaction 检查标签是否存在于交易
@@ -226,3 +271,70 @@ func (TransactionDao) TagExistsInTransaction(transactionID, tagID uint) (bool, e
 	}
 	return count > 0, nil
 }
+
+// GetTransactionsByStatus 根据状态获取家庭的交易列表
+func (TransactionDao) GetTransactionsByStatus(familyID uint, status TransactionStatus) ([]Transaction, error) {
+	var transactions []Transaction
+	if err := database.DB.Where("family_id = ? AND status = ?", familyID, status).
+		Preload("Member").Preload("Category").Preload("Labels").Preload("Splits.Category").
+		Order("transaction_time DESC").
+		Find(&transactions).Error; err != nil {
+		log.Printf("获取交易列表失败 FamilyID=%d, Status=%s: %v", familyID, status, err)
+		return nil, err
+	}
+	return transactions, nil
+}
+
+// GetPlannedTransactions 获取家庭交易时间不晚于 until 的计划交易，按交易时间排序
+func (TransactionDao) GetPlannedTransactions(familyID uint, until time.Time) ([]Transaction, error) {
+	var transactions []Transaction
+	if err := database.DB.Where("family_id = ? AND status = ? AND transaction_time <= ?", familyID, Planned, until).
+		Preload("Member").Preload("Category").Preload("Labels").Preload("Splits.Category").
+		Order("transaction_time, id").
+		Find(&transactions).Error; err != nil {
+		log.Printf("获取计划交易失败 FamilyID=%d: %v", familyID, err)
+		return nil, err
+	}
+	return transactions, nil
+}
+
+// PromotePlannedTransactions 将交易时间已到的计划交易转为正常交易，返回转换的数量
+func (TransactionDao) PromotePlannedTransactions(now time.Time) (int64, error) {
+	result := database.DB.Model(&Transaction{}).
+		Where("status = ? AND transaction_time <= ?", Planned, now).
+		Updates(map[string]interface{}{"status": Valid, "updated_at": now})
+	if result.Error != nil {
+		log.Printf("转换到期计划交易失败: %v", result.Error)
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
+
+// ReviewTransaction 记录审批结果，只有待审批的交易会被更新，交易不是待审批状态时返回 false
+func (TransactionDao) ReviewTransaction(id uint, status TransactionStatus, reviewerID uint, note string) (bool, error) {
+	result := database.DB.Model(&Transaction{}).
+		Where("id = ? AND status = ?", id, Pending).
+		Updates(map[string]interface{}{
+			"status":      status,
+			"reviewed_by": reviewerID,
+			"reviewed_at": time.Now(),
+			"review_note": note,
+		})
+	if result.Error != nil {
+		log.Printf("审批交易失败 ID=%d: %v", id, result.Error)
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// groupTimeFormat 返回分组方式对应的 DATE_FORMAT 格式，默认按月
+func groupTimeFormat(groupBy string) string {
+	switch groupBy {
+	case "day":
+		return "%Y-%m-%d"
+	case "year":
+		return "%Y"
+	default:
+		return "%Y-%m"
+	}
+}
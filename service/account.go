@@ -0,0 +1,402 @@
+// service/account_service.go
+package service
+
+import (
+	"errors"
+	"fmt"
+	"github.com/KQLXK/Family-Finance-System/model"
+	"github.com/shopspring/decimal"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// currencyPattern ISO 4217 货币代码
+var currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// defaultCurrency 未指定币种时使用的默认货币
+const defaultCurrency = "CNY"
+
+// AccountBalance 账户及其当前余额
+type AccountBalance struct {
+	Account model.Account   `json:"account"`
+	Balance decimal.Decimal `json:"balance"`
+}
+
+// AccountBalancePoint 账户在某个时间段结束时的余额
+type AccountBalancePoint struct {
+	Period  string          `json:"period"`
+	Balance decimal.Decimal `json:"balance"`
+}
+
+// PaymentMethodMigrationResult 支付方式迁移结果
+type PaymentMethodMigrationResult struct {
+	CreatedAccounts    []model.Account `json:"created_accounts"`
+	LinkedTransactions int64           `json:"linked_transactions"`
+}
+
+// AccountService 账户服务接口
+type AccountService interface {
+	CreateAccount(scope Scope, account *model.Account) error
+	GetAccountByID(scope Scope, id uint) (*model.Account, error)
+	GetAccountsByFamilyID(scope Scope, familyID uint, includeArchived bool) ([]model.Account, error)
+	UpdateAccount(scope Scope, account *model.Account) error
+	DeleteAccount(scope Scope, id uint) error
+	GetAccountBalance(scope Scope, id uint) (*AccountBalance, error)
+	GetAccountBalanceHistory(scope Scope, id uint, startTime, endTime time.Time, groupBy string) ([]AccountBalancePoint, error)
+	MigratePaymentMethods(scope Scope, familyID uint) (*PaymentMethodMigrationResult, error)
+}
+
+// accountService 账户服务实现
+type accountService struct {
+	accountDao model.AccountDao
+	familyDao  model.FamilyDao
+}
+
+// NewAccountService 创建账户服务实例
+func NewAccountService() AccountService {
+	return &accountService{
+		accountDao: *model.NewAccountDaoInstance(),
+		familyDao:  *model.NewFamilyDaoInstance(),
+	}
+}
+
+// CreateAccount 创建账户
+func (s *accountService) CreateAccount(scope Scope, account *model.Account) error {
+	// 验证账户数据
+	if err := s.validateAccount(account); err != nil {
+		return err
+	}
+
+	// 只能为自己所属的家庭创建账户
+	if err := scope.checkFamily(account.FamilyID); err != nil {
+		return err
+	}
+
+	// 检查家庭是否存在
+	family, err := s.familyDao.GetFamilyByID(account.FamilyID)
+	if err != nil || family == nil {
+		return errors.New("关联的家庭不存在")
+	}
+
+	// 检查账户名称是否已存在（同一家庭下）
+	if err := s.checkNameAvailable(account.FamilyID, account.Name, 0); err != nil {
+		return err
+	}
+
+	if err := s.accountDao.CreateAccount(account); err != nil {
+		return fmt.Errorf("创建账户失败: %v", err)
+	}
+
+	recordAudit(scope, account.FamilyID, EntityAccount, account.ID, model.AuditCreate, nil, account)
+
+	return nil
+}
+
+// GetAccountByID 根据ID获取账户
+func (s *accountService) GetAccountByID(scope Scope, id uint) (*model.Account, error) {
+	if id == 0 {
+		return nil, errors.New("无效的账户ID")
+	}
+
+	account, err := s.accountDao.GetAccountByID(id)
+	if err != nil || account == nil || !scope.owns(account.FamilyID) {
+		return nil, errors.New("账户不存在")
+	}
+
+	return account, nil
+}
+
+// GetAccountsByFamilyID 根据家庭ID获取账户列表
+func (s *accountService) GetAccountsByFamilyID(scope Scope, familyID uint, includeArchived bool) ([]model.Account, error) {
+	if familyID == 0 {
+		return nil, errors.New("无效的家庭ID")
+	}
+
+	if err := scope.checkFamily(familyID); err != nil {
+		return nil, err
+	}
+
+	accounts, err := s.accountDao.GetAccountsByFamilyID(familyID, includeArchived)
+	if err != nil {
+		return nil, fmt.Errorf("获取账户列表失败: %v", err)
+	}
+
+	return accounts, nil
+}
+
+// UpdateAccount 更新账户信息，已有交易的账户不能修改币种
+func (s *accountService) UpdateAccount(scope Scope, account *model.Account) error {
+	existing, err := s.GetAccountByID(scope, account.ID)
+	if err != nil {
+		return err
+	}
+	account.FamilyID = existing.FamilyID
+	account.CreatedAt = existing.CreatedAt
+
+	if err := s.validateAccount(account); err != nil {
+		return err
+	}
+
+	if err := s.checkNameAvailable(account.FamilyID, account.Name, account.ID); err != nil {
+		return err
+	}
+
+	if account.Currency != existing.Currency {
+		count, err := s.accountDao.CountTransactionsByAccountID(account.ID)
+		if err != nil {
+			return fmt.Errorf("检查账户交易时出错: %v", err)
+		}
+		if count > 0 {
+			return errors.New("账户已有交易，不能修改币种")
+		}
+	}
+
+	account.UpdatedAt = time.Now()
+	if err := s.accountDao.UpdateAccount(account); err != nil {
+		return fmt.Errorf("更新账户信息失败: %v", err)
+	}
+
+	recordAudit(scope, account.FamilyID, EntityAccount, account.ID, model.AuditUpdate, existing, account)
+
+	return nil
+}
+
+// DeleteAccount 删除账户，已有交易的账户只能归档
+func (s *accountService) DeleteAccount(scope Scope, id uint) error {
+	existing, err := s.GetAccountByID(scope, id)
+	if err != nil {
+		return err
+	}
+
+	count, err := s.accountDao.CountTransactionsByAccountID(id)
+	if err != nil {
+		return fmt.Errorf("检查账户交易时出错: %v", err)
+	}
+	if count > 0 {
+		return errors.New("账户已有交易，请改为归档")
+	}
+
+	if err := s.accountDao.DeleteAccount(id); err != nil {
+		return fmt.Errorf("删除账户失败: %v", err)
+	}
+
+	recordAudit(scope, existing.FamilyID, EntityAccount, id, model.AuditDelete, existing, nil)
+
+	return nil
+}
+
+// GetAccountBalance 获取账户当前余额：期初余额加上所有有效交易的净额
+func (s *accountService) GetAccountBalance(scope Scope, id uint) (*AccountBalance, error) {
+	account, err := s.GetAccountByID(scope, id)
+	if err != nil {
+		return nil, err
+	}
+
+	net, err := s.accountDao.GetAccountNetAmount(id, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("获取账户余额失败: %v", err)
+	}
+
+	return &AccountBalance{
+		Account: *account,
+		Balance: account.OpeningBalance.Add(net),
+	}, nil
+}
+
+// GetAccountBalanceHistory 获取账户在时间范围内每个时间段结束时的余额
+func (s *accountService) GetAccountBalanceHistory(scope Scope, id uint, startTime, endTime time.Time, groupBy string) ([]AccountBalancePoint, error) {
+	account, err := s.GetAccountByID(scope, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// 验证分组方式
+	if groupBy != "day" && groupBy != "month" && groupBy != "year" {
+		return nil, errors.New("无效的分组方式，支持: day, month, year")
+	}
+	if endTime.Before(startTime) {
+		return nil, errors.New("结束时间不能早于开始时间")
+	}
+	if groupBy == "day" && endTime.Sub(startTime) > 366*24*time.Hour {
+		return nil, errors.New("按天统计的时间范围不能超过一年")
+	}
+
+	// 开始时间之前的余额
+	before, err := s.accountDao.GetAccountNetAmount(id, startTime.Add(-time.Nanosecond))
+	if err != nil {
+		return nil, fmt.Errorf("获取账户余额失败: %v", err)
+	}
+
+	changes, err := s.accountDao.GetAccountNetAmountByTime(id, startTime, endTime, groupBy)
+	if err != nil {
+		return nil, fmt.Errorf("获取账户余额变化失败: %v", err)
+	}
+
+	balance := account.OpeningBalance.Add(before)
+	var points []AccountBalancePoint
+	for _, period := range periodLabels(startTime, endTime, groupBy) {
+		balance = balance.Add(changes[period])
+		points = append(points, AccountBalancePoint{Period: period, Balance: balance})
+	}
+
+	return points, nil
+}
+
+// MigratePaymentMethods 将历史交易的支付方式迁移为账户：同名账户直接关联，否则按名称推断类型新建账户
+func (s *accountService) MigratePaymentMethods(scope Scope, familyID uint) (*PaymentMethodMigrationResult, error) {
+	if err := scope.checkFamily(familyID); err != nil {
+		return nil, err
+	}
+
+	methods, err := s.accountDao.GetUnlinkedPaymentMethods(familyID)
+	if err != nil {
+		return nil, fmt.Errorf("获取支付方式失败: %v", err)
+	}
+
+	result := &PaymentMethodMigrationResult{CreatedAccounts: []model.Account{}}
+	for _, method := range methods {
+		name := strings.TrimSpace(method)
+		if name == "" {
+			continue
+		}
+
+		account, err := s.accountDao.GetAccountByName(familyID, name)
+		if err != nil {
+			return result, fmt.Errorf("查找账户失败: %v", err)
+		}
+		if account == nil {
+			account = &model.Account{
+				FamilyID: familyID,
+				Name:     name,
+				Type:     guessAccountType(name),
+				Currency: defaultCurrency,
+			}
+			if err := s.accountDao.CreateAccount(account); err != nil {
+				return result, fmt.Errorf("创建账户失败: %v", err)
+			}
+			recordAudit(scope, familyID, EntityAccount, account.ID, model.AuditCreate, nil, account)
+			result.CreatedAccounts = append(result.CreatedAccounts, *account)
+		}
+
+		linked, err := s.accountDao.LinkTransactionsByPaymentMethod(familyID, method, account.ID)
+		if err != nil {
+			return result, fmt.Errorf("关联交易账户失败: %v", err)
+		}
+		result.LinkedTransactions += linked
+	}
+
+	return result, nil
+}
+
+// validateAccount 验证账户数据，并补全默认值
+func (s *accountService) validateAccount(account *model.Account) error {
+	account.Name = strings.TrimSpace(account.Name)
+	if account.Name == "" {
+		return errors.New("账户名称不能为空")
+	}
+	if len(account.Name) > 100 {
+		return errors.New("账户名称长度不能超过100个字符")
+	}
+
+	if account.Type == "" {
+		account.Type = model.AccountOther
+	}
+	if !isValidAccountType(account.Type) {
+		return errors.New("无效的账户类型")
+	}
+
+	if !isCents(account.OpeningBalance) {
+		return errors.New("期初余额最多保留两位小数")
+	}
+
+	if account.Currency == "" {
+		account.Currency = defaultCurrency
+	}
+	account.Currency = strings.ToUpper(account.Currency)
+	if !currencyPattern.MatchString(account.Currency) {
+		return errors.New("无效的货币代码")
+	}
+
+	// 账单日和还款日只适用于信用卡，需同时设置
+	if account.StatementDay != 0 || account.DueDay != 0 {
+		if account.Type != model.AccountCreditCard {
+			return errors.New("只有信用卡可以设置账单日和还款日")
+		}
+		if account.StatementDay < 1 || account.StatementDay > 31 || account.DueDay < 1 || account.DueDay > 31 {
+			return errors.New("账单日和还款日必须在1到31之间")
+		}
+	}
+
+	return nil
+}
+
+// checkNameAvailable 检查账户名称在家庭内是否可用
+func (s *accountService) checkNameAvailable(familyID uint, name string, excludeID uint) error {
+	account, err := s.accountDao.GetAccountByName(familyID, name)
+	if err != nil {
+		return fmt.Errorf("检查账户名称是否存在时出错: %v", err)
+	}
+	if account != nil && account.ID != excludeID {
+		return errors.New("账户名称已存在")
+	}
+	return nil
+}
+
+// isValidAccountType 验证账户类型是否有效
+func isValidAccountType(accountType model.AccountType) bool {
+	switch accountType {
+	case model.AccountCash, model.AccountDebitCard, model.AccountCreditCard,
+		model.AccountAlipay, model.AccountWeChat, model.AccountOther:
+		return true
+	default:
+		return false
+	}
+}
+
+// guessAccountType 根据支付方式名称推断账户类型
+func guessAccountType(name string) model.AccountType {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.Contains(lower, "现金") || strings.Contains(lower, "cash"):
+		return model.AccountCash
+	case strings.Contains(lower, "支付宝") || strings.Contains(lower, "花呗") || strings.Contains(lower, "alipay"):
+		return model.AccountAlipay
+	case strings.Contains(lower, "微信") || strings.Contains(lower, "wechat"):
+		return model.AccountWeChat
+	case strings.Contains(lower, "信用卡") || strings.Contains(lower, "credit"):
+		return model.AccountCreditCard
+	case strings.Contains(lower, "银行卡") || strings.Contains(lower, "储蓄卡") || strings.Contains(lower, "借记卡") || strings.Contains(lower, "debit"):
+		return model.AccountDebitCard
+	default:
+		return model.AccountOther
+	}
+}
+
+// periodLabels 生成时间范围内各时间段的标签，格式与统计查询的 DATE_FORMAT 一致
+func periodLabels(startTime, endTime time.Time, groupBy string) []string {
+	var layout string
+	var current time.Time
+	var next func(time.Time) time.Time
+	switch groupBy {
+	case "day":
+		layout = "2006-01-02"
+		current = time.Date(startTime.Year(), startTime.Month(), startTime.Day(), 0, 0, 0, 0, startTime.Location())
+		next = func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }
+	case "year":
+		layout = "2006"
+		current = time.Date(startTime.Year(), 1, 1, 0, 0, 0, 0, startTime.Location())
+		next = func(t time.Time) time.Time { return t.AddDate(1, 0, 0) }
+	default:
+		layout = "2006-01"
+		current = time.Date(startTime.Year(), startTime.Month(), 1, 0, 0, 0, 0, startTime.Location())
+		next = func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }
+	}
+
+	var labels []string
+	for !current.After(endTime) {
+		labels = append(labels, current.Format(layout))
+		current = next(current)
+	}
+	return labels
+}
@@ -0,0 +1,196 @@
+// service/api_token_service.go
+package service
+
+import (
+	"errors"
+	"fmt"
+	"github.com/KQLXK/Family-Finance-System/model"
+	"strings"
+	"time"
+)
+
+const (
+	// APITokenPrefix 个人访问令牌的固定前缀，用于和 JWT 区分
+	APITokenPrefix = "ffs_"
+
+	apiTokenTouchInterval = time.Minute // 最近使用时间的最小更新间隔
+	maxAPITokenDays       = 3650
+)
+
+// CreateAPITokenRequest 创建个人访问令牌请求
+type CreateAPITokenRequest struct {
+	Name          string             `json:"name"`
+	Scopes        []model.TokenScope `json:"scopes"`
+	ExpiresInDays int                `json:"expires_in_days"` // 0 表示永不过期
+}
+
+// CreateAPITokenResult 创建结果，明文令牌只返回这一次
+type CreateAPITokenResult struct {
+	Token    string          `json:"token"`
+	APIToken *model.APIToken `json:"api_token"`
+}
+
+// APITokenService 个人访问令牌服务接口
+type APITokenService interface {
+	CreateAPIToken(scope Scope, request *CreateAPITokenRequest) (*CreateAPITokenResult, error)
+	GetAPITokens(scope Scope) ([]model.APIToken, error)
+	RevokeAPIToken(scope Scope, id uint) error
+	Authenticate(token string) (*model.Member, []model.TokenScope, error)
+}
+
+// apiTokenService 个人访问令牌服务实现
+type apiTokenService struct {
+	apiTokenDao model.APITokenDao
+	memberDao   model.MemberDao
+}
+
+// NewAPITokenService 创建个人访问令牌服务实例
+func NewAPITokenService() APITokenService {
+	return &apiTokenService{
+		apiTokenDao: *model.NewAPITokenDaoInstance(),
+		memberDao:   *model.NewMemberDaoInstance(),
+	}
+}
+
+// CreateAPIToken 为当前成员创建个人访问令牌
+func (s *apiTokenService) CreateAPIToken(scope Scope, request *CreateAPITokenRequest) (*CreateAPITokenResult, error) {
+	name := strings.TrimSpace(request.Name)
+	if name == "" {
+		return nil, errors.New("令牌名称不能为空")
+	}
+	if len(name) > 100 {
+		return nil, errors.New("令牌名称长度不能超过100个字符")
+	}
+
+	scopes, err := s.validateScopes(scope.Role, request.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.ExpiresInDays < 0 || request.ExpiresInDays > maxAPITokenDays {
+		return nil, fmt.Errorf("有效天数必须在0到%d之间", maxAPITokenDays)
+	}
+
+	secret, secretHash, err := generateSecret(APITokenPrefix, 20)
+	if err != nil {
+		return nil, err
+	}
+
+	token := &model.APIToken{
+		MemberID:  scope.MemberID,
+		FamilyID:  scope.FamilyID,
+		Name:      name,
+		TokenHash: secretHash,
+		Prefix:    secret[:len(APITokenPrefix)+6],
+		Scopes:    joinScopes(scopes),
+	}
+	if request.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, request.ExpiresInDays)
+		token.ExpiresAt = &expiresAt
+	}
+
+	if err := s.apiTokenDao.CreateAPIToken(token); err != nil {
+		return nil, fmt.Errorf("创建访问令牌失败: %v", err)
+	}
+
+	return &CreateAPITokenResult{Token: secret, APIToken: token}, nil
+}
+
+// GetAPITokens 获取当前成员的个人访问令牌列表
+func (s *apiTokenService) GetAPITokens(scope Scope) ([]model.APIToken, error) {
+	tokens, err := s.apiTokenDao.GetAPITokensByMemberID(scope.MemberID)
+	if err != nil {
+		return nil, fmt.Errorf("获取访问令牌列表失败: %v", err)
+	}
+	return tokens, nil
+}
+
+// RevokeAPIToken 撤销当前成员的个人访问令牌
+func (s *apiTokenService) RevokeAPIToken(scope Scope, id uint) error {
+	token, err := s.apiTokenDao.GetAPITokenByID(id)
+	if err != nil || token == nil || token.MemberID != scope.MemberID {
+		return errors.New("访问令牌不存在")
+	}
+	if token.RevokedAt != nil {
+		return errors.New("访问令牌已被撤销")
+	}
+
+	if err := s.apiTokenDao.RevokeAPIToken(id); err != nil {
+		return fmt.Errorf("撤销访问令牌失败: %v", err)
+	}
+	return nil
+}
+
+// Authenticate 校验个人访问令牌，返回令牌所属成员及其权限范围
+func (s *apiTokenService) Authenticate(secret string) (*model.Member, []model.TokenScope, error) {
+	token, err := s.apiTokenDao.GetAPITokenByHash(hashSecret(secret))
+	if err != nil || token == nil {
+		return nil, nil, errors.New("访问令牌无效")
+	}
+
+	now := time.Now()
+	if token.RevokedAt != nil {
+		return nil, nil, errors.New("访问令牌已被撤销")
+	}
+	if token.ExpiresAt != nil && now.After(*token.ExpiresAt) {
+		return nil, nil, errors.New("访问令牌已过期")
+	}
+
+	member, err := s.memberDao.GetMemberByID(token.MemberID)
+	if err != nil || member == nil || member.Status != 1 || member.FamilyID != token.FamilyID {
+		return nil, nil, errors.New("令牌所属成员不存在或已被移除")
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) > apiTokenTouchInterval {
+		// 使用时间仅用于展示，更新失败不影响本次请求
+		_ = s.apiTokenDao.TouchAPIToken(token.ID, now)
+	}
+
+	return member, splitScopes(token.Scopes), nil
+}
+
+// validateScopes 校验权限范围，只读角色不能申请写权限
+func (s *apiTokenService) validateScopes(role model.MemberRole, scopes []model.TokenScope) ([]model.TokenScope, error) {
+	if len(scopes) == 0 {
+		return nil, errors.New("至少需要一个权限范围")
+	}
+
+	seen := make(map[model.TokenScope]bool)
+	var result []model.TokenScope
+	for _, scope := range scopes {
+		switch scope {
+		case model.ScopeTransactionsRead, model.ScopeReportsRead:
+		case model.ScopeTransactionsWrite:
+			if role == model.RoleViewer {
+				return nil, errors.New("只读成员不能创建带写权限的令牌")
+			}
+		default:
+			return nil, fmt.Errorf("无效的权限范围: %s", scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			result = append(result, scope)
+		}
+	}
+	return result, nil
+}
+
+// joinScopes 将权限范围拼接为逗号分隔的字符串
+func joinScopes(scopes []model.TokenScope) string {
+	parts := make([]string, len(scopes))
+	for i, scope := range scopes {
+		parts[i] = string(scope)
+	}
+	return strings.Join(parts, ",")
+}
+
+// splitScopes 解析逗号分隔的权限范围
+func splitScopes(scopes string) []model.TokenScope {
+	var result []model.TokenScope
+	for _, part := range strings.Split(scopes, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			result = append(result, model.TokenScope(part))
+		}
+	}
+	return result
+}
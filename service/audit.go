@@ -0,0 +1,118 @@
+// service/audit_service.go
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/KQLXK/Family-Finance-System/model"
+	"log"
+	"time"
+)
+
+// 审计日志中的实体类型
+const (
+	EntityFamily           = "family"
+	EntityMember           = "member"
+	EntityCategory         = "category"
+	EntityTag              = "tag"
+	EntityTransaction      = "transaction"
+	EntityAccount          = "account"
+	EntityExchangeRate     = "exchange_rate"
+	EntitySharedExpense    = "shared_expense"
+	EntitySettlement       = "settlement"
+	EntityDebt             = "debt"
+	EntityDebtRepayment    = "debt_repayment"
+	EntityStatementPayment = "statement_payment"
+	EntityInstallmentPlan  = "installment_plan"
+	EntityBudget           = "budget"
+	EntityBudgetMove       = "budget_move"
+	EntitySavingsGoal      = "savings_goal"
+	EntityGoalContribution = "goal_contribution"
+	EntitySpendingLimit    = "spending_limit"
+	EntityRecurring        = "recurring_template"
+	EntityBill             = "bill"
+	EntityChannel          = "notification_channel"
+)
+
+// auditOmittedKeys 快照中省略的关联对象，只保留实体自身字段
+var auditOmittedKeys = []string{"family", "member", "members", "category", "parent", "children"}
+
+// AuditService 审计日志服务接口
+type AuditService interface {
+	GetAuditLogs(scope Scope, familyID uint, page, pageSize int, filters map[string]interface{}, startTime, endTime time.Time) ([]model.AuditLog, int64, error)
+}
+
+// auditService 审计日志服务实现
+type auditService struct {
+	auditLogDao model.AuditLogDao
+}
+
+// NewAuditService 创建审计日志服务实例
+func NewAuditService() AuditService {
+	return &auditService{
+		auditLogDao: *model.NewAuditLogDaoInstance(),
+	}
+}
+
+// GetAuditLogs 分页查询家庭的审计日志
+func (s *auditService) GetAuditLogs(scope Scope, familyID uint, page, pageSize int, filters map[string]interface{}, startTime, endTime time.Time) ([]model.AuditLog, int64, error) {
+	if familyID == 0 {
+		return nil, 0, errors.New("无效的家庭ID")
+	}
+
+	if err := scope.checkFamily(familyID); err != nil {
+		return nil, 0, err
+	}
+
+	auditLogs, total, err := s.auditLogDao.GetAuditLogsByFamilyID(familyID, page, pageSize, filters, startTime, endTime)
+	if err != nil {
+		return nil, 0, fmt.Errorf("获取审计日志失败: %v", err)
+	}
+
+	return auditLogs, total, nil
+}
+
+// recordAudit 记录一次变更。变更已经落库，写日志失败只记录错误，不影响业务结果
+func recordAudit(scope Scope, familyID uint, entityType string, entityID uint, action model.AuditAction, before, after interface{}) {
+	auditLog := &model.AuditLog{
+		FamilyID:   familyID,
+		ActorID:    scope.MemberID,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Action:     action,
+		Before:     auditSnapshot(before),
+		After:      auditSnapshot(after),
+	}
+	if err := model.NewAuditLogDaoInstance().CreateAuditLog(auditLog); err != nil {
+		log.Printf("记录审计日志失败 Entity=%s ID=%d Action=%s: %v", entityType, entityID, action, err)
+	}
+}
+
+// auditSnapshot 将实体序列化为 JSON 快照并去掉关联对象
+func auditSnapshot(v interface{}) json.RawMessage {
+	if v == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		log.Printf("序列化审计快照失败: %v", err)
+		return nil
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		// 不是对象（例如标签ID列表），直接保存
+		return data
+	}
+	for _, key := range auditOmittedKeys {
+		delete(fields, key)
+	}
+
+	snapshot, err := json.Marshal(fields)
+	if err != nil {
+		return data
+	}
+	return snapshot
+}
@@ -0,0 +1,246 @@
+// service/auth_service.go
+package service
+
+import (
+	"errors"
+	"fmt"
+	"github.com/KQLXK/Family-Finance-System/commen/auth"
+	"github.com/KQLXK/Family-Finance-System/model"
+	"strings"
+	"time"
+)
+
+// passwordResetTTL 密码重置令牌有效期
+const passwordResetTTL = 24 * time.Hour
+
+// LoginResult 登录结果
+type LoginResult struct {
+	Token     string        `json:"token"`
+	ExpiresAt time.Time     `json:"expires_at"`
+	Member    *model.Member `json:"member"`
+}
+
+// RegisterRequest 注册请求：创建新家庭并成为该家庭的管理员
+type RegisterRequest struct {
+	FamilyName string `json:"family_name"`
+	Name       string `json:"name"`
+	Email      string `json:"email"`
+	Phone      string `json:"phone"`
+	Password   string `json:"password"`
+}
+
+// PasswordResetResult 管理员发起密码重置后返回的一次性令牌
+type PasswordResetResult struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// AuthService 认证服务接口
+type AuthService interface {
+	Register(request *RegisterRequest) (*LoginResult, error)
+	Login(account, password string) (*LoginResult, error)
+	Authenticate(token string) (*model.Member, error)
+	ChangePassword(scope Scope, oldPassword, newPassword string) error
+	CreatePasswordResetToken(scope Scope, memberID uint) (*PasswordResetResult, error)
+	ResetPassword(token, newPassword string) error
+}
+
+// authService 认证服务实现
+type authService struct {
+	memberDao             model.MemberDao
+	passwordResetTokenDao model.PasswordResetTokenDao
+	familyService         FamilyService
+	memberService         MemberService
+}
+
+// NewAuthService 创建认证服务实例
+func NewAuthService() AuthService {
+	return &authService{
+		memberDao:             *model.NewMemberDaoInstance(),
+		passwordResetTokenDao: *model.NewPasswordResetTokenDaoInstance(),
+		familyService:         NewFamilyService(),
+		memberService:         NewMemberService(),
+	}
+}
+
+// Register 注册新家庭及其管理员账号
+func (s *authService) Register(request *RegisterRequest) (*LoginResult, error) {
+	passwordHash, err := HashPassword(request.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	family := &model.Family{Name: request.FamilyName}
+	if err := s.familyService.CreateFamily(Scope{}, family); err != nil {
+		return nil, err
+	}
+
+	// 新家庭的第一个成员即为管理员
+	member := &model.Member{
+		FamilyID:     family.ID,
+		Name:         request.Name,
+		Email:        strings.TrimSpace(request.Email),
+		Phone:        strings.TrimSpace(request.Phone),
+		Role:         model.RoleAdmin,
+		PasswordHash: passwordHash,
+	}
+	if err := s.memberService.CreateMember(Scope{FamilyID: family.ID, Role: model.RoleAdmin}, member); err != nil {
+		// 成员创建失败时回收刚创建的空家庭
+		if delErr := s.familyService.DeleteFamily(Scope{FamilyID: family.ID}, family.ID); delErr != nil {
+			return nil, fmt.Errorf("%v（清理家庭失败: %v）", err, delErr)
+		}
+		return nil, err
+	}
+
+	return issueLoginToken(member)
+}
+
+// Login 使用邮箱或手机号及密码登录并签发令牌
+func (s *authService) Login(account, password string) (*LoginResult, error) {
+	account = strings.TrimSpace(account)
+	if account == "" || password == "" {
+		return nil, errors.New("账号和密码不能为空")
+	}
+
+	member, err := s.memberDao.GetMemberByAccount(account)
+	if err != nil || member == nil || !checkPassword(member.PasswordHash, password) {
+		return nil, errors.New("账号或密码错误")
+	}
+
+	return issueLoginToken(member)
+}
+
+// Authenticate 校验令牌并返回当前成员
+func (s *authService) Authenticate(token string) (*model.Member, error) {
+	claims, err := auth.ParseToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	// 每次请求重新加载成员，使角色变更和移除立即生效
+	member, err := s.memberDao.GetMemberByID(claims.MemberID)
+	if err != nil || member == nil {
+		return nil, errors.New("成员不存在")
+	}
+	if member.Status != 1 {
+		return nil, errors.New("成员已被移除")
+	}
+	if member.FamilyID != claims.FamilyID {
+		return nil, errors.New("令牌与成员所属家庭不一致")
+	}
+
+	// 修改密码后，之前签发的令牌全部失效
+	if member.PasswordChangedAt != nil && claims.IssuedAt != nil &&
+		claims.IssuedAt.Time.Before(member.PasswordChangedAt.Truncate(time.Second)) {
+		return nil, errors.New("密码已修改，请重新登录")
+	}
+
+	return member, nil
+}
+
+// ChangePassword 修改当前成员的密码
+func (s *authService) ChangePassword(scope Scope, oldPassword, newPassword string) error {
+	member, err := s.memberDao.GetMemberByID(scope.MemberID)
+	if err != nil || member == nil {
+		return errors.New("成员不存在")
+	}
+
+	if !checkPassword(member.PasswordHash, oldPassword) {
+		return errors.New("原密码错误")
+	}
+
+	passwordHash, err := HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	if err := s.memberDao.UpdatePassword(member.ID, passwordHash); err != nil {
+		return fmt.Errorf("修改密码失败: %v", err)
+	}
+
+	return nil
+}
+
+// CreatePasswordResetToken 管理员为本家庭成员生成一次性密码重置令牌
+func (s *authService) CreatePasswordResetToken(scope Scope, memberID uint) (*PasswordResetResult, error) {
+	// 检查成员是否存在且属于操作者所属家庭
+	member, err := s.memberService.GetMemberByID(scope, memberID)
+	if err != nil {
+		return nil, err
+	}
+	if member.Status != 1 {
+		return nil, errors.New("成员已被移除")
+	}
+	if member.Email == "" && member.Phone == "" {
+		return nil, errors.New("成员没有邮箱或手机号，无法登录")
+	}
+
+	// 同一成员只保留最新的重置令牌
+	if err := s.passwordResetTokenDao.InvalidateTokensByMemberID(member.ID); err != nil {
+		return nil, fmt.Errorf("作废旧的重置令牌失败: %v", err)
+	}
+
+	secret, secretHash, err := generateSecret("", 24)
+	if err != nil {
+		return nil, err
+	}
+
+	token := &model.PasswordResetToken{
+		MemberID:  member.ID,
+		TokenHash: secretHash,
+		ExpiresAt: time.Now().Add(passwordResetTTL),
+		CreatedBy: scope.MemberID,
+	}
+	if err := s.passwordResetTokenDao.CreateToken(token); err != nil {
+		return nil, fmt.Errorf("创建重置令牌失败: %v", err)
+	}
+
+	return &PasswordResetResult{Token: secret, ExpiresAt: token.ExpiresAt}, nil
+}
+
+// ResetPassword 使用重置令牌设置新密码
+func (s *authService) ResetPassword(token, newPassword string) error {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return errors.New("重置令牌不能为空")
+	}
+
+	passwordHash, err := HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	resetToken, err := s.passwordResetTokenDao.GetTokenByHash(hashSecret(token))
+	if err != nil || resetToken == nil || resetToken.UsedAt != nil || time.Now().After(resetToken.ExpiresAt) {
+		return errors.New("重置令牌无效或已过期")
+	}
+
+	// 先占用令牌，防止并发重复使用
+	ok, err := s.passwordResetTokenDao.MarkTokenUsed(resetToken.ID)
+	if err != nil {
+		return fmt.Errorf("使用重置令牌失败: %v", err)
+	}
+	if !ok {
+		return errors.New("重置令牌无效或已过期")
+	}
+
+	if err := s.memberDao.UpdatePassword(resetToken.MemberID, passwordHash); err != nil {
+		return fmt.Errorf("重置密码失败: %v", err)
+	}
+
+	return nil
+}
+
+// issueLoginToken 为成员签发登录令牌
+func issueLoginToken(member *model.Member) (*LoginResult, error) {
+	token, expiresAt, err := auth.GenerateToken(member.ID, member.FamilyID, string(member.Role))
+	if err != nil {
+		return nil, err
+	}
+
+	return &LoginResult{
+		Token:     token,
+		ExpiresAt: expiresAt,
+		Member:    member,
+	}, nil
+}
@@ -0,0 +1,384 @@
+// service/bill_service.go
+package service
+
+import (
+	"errors"
+	"fmt"
+	"github.com/KQLXK/Family-Finance-System/model"
+	"log"
+	"strings"
+	"time"
+)
+
+// PayBillRequest 标记账单已付请求
+type PayBillRequest struct {
+	TransactionID uint `json:"transaction_id"` // 实际付款的交易
+}
+
+// BillService 账单服务接口
+type BillService interface {
+	CreateBill(scope Scope, bill *model.Bill) error
+	GetBillByID(scope Scope, id uint) (*model.Bill, error)
+	UpdateBill(scope Scope, bill *model.Bill) error
+	DeleteBill(scope Scope, id uint) error
+	GetUpcomingBills(scope Scope, familyID uint, days int) ([]model.Bill, error)
+	GetOverdueBills(scope Scope, familyID uint) ([]model.Bill, error)
+	GetPaidBills(scope Scope, familyID uint, startTime, endTime time.Time) ([]model.Bill, error)
+	PayBill(scope Scope, id uint, request *PayBillRequest) (*model.Bill, error)
+	UnpayBill(scope Scope, id uint) (*model.Bill, error)
+	SendDueReminders(now time.Time) error
+}
+
+// billService 账单服务实现
+type billService struct {
+	billDao        model.BillDao
+	familyDao      model.FamilyDao
+	categoryDao    model.CategoryDao
+	transactionDao model.TransactionDao
+}
+
+// NewBillService 创建账单服务实例
+func NewBillService() BillService {
+	return &billService{
+		billDao:        *model.NewBillDaoInstance(),
+		familyDao:      *model.NewFamilyDaoInstance(),
+		categoryDao:    *model.NewCategoryDaoInstance(),
+		transactionDao: *model.NewTransactionDaoInstance(),
+	}
+}
+
+// CreateBill 创建待付账单，未指定币种时使用家庭本位币
+func (s *billService) CreateBill(scope Scope, bill *model.Bill) error {
+	if err := scope.checkFamily(bill.FamilyID); err != nil {
+		return err
+	}
+
+	family, err := s.familyDao.GetFamilyByID(bill.FamilyID)
+	if err != nil || family == nil {
+		return errors.New("关联的家庭不存在")
+	}
+	if bill.Currency == "" {
+		bill.Currency = family.BaseCurrency
+	}
+	if bill.Currency == "" {
+		bill.Currency = defaultCurrency
+	}
+
+	if err := s.validateBill(bill); err != nil {
+		return err
+	}
+
+	bill.ID = 0
+	bill.Status = model.BillUnpaid
+	bill.TransactionID = nil
+	bill.PaidAt = nil
+	bill.RemindedAt = nil
+	bill.CreatedBy = scope.MemberID
+	if err := s.billDao.CreateBill(bill); err != nil {
+		return fmt.Errorf("创建账单失败: %v", err)
+	}
+
+	recordAudit(scope, bill.FamilyID, EntityBill, bill.ID, model.AuditCreate, nil, bill)
+
+	return nil
+}
+
+// GetBillByID 获取账单详情
+func (s *billService) GetBillByID(scope Scope, id uint) (*model.Bill, error) {
+	return s.getBill(scope, id)
+}
+
+// UpdateBill 更新未付账单，修改到期日或提醒天数后重新提醒
+func (s *billService) UpdateBill(scope Scope, bill *model.Bill) error {
+	existing, err := s.getBill(scope, bill.ID)
+	if err != nil {
+		return err
+	}
+	if existing.Status != model.BillUnpaid {
+		return errors.New("账单已付，请先撤销付款再修改")
+	}
+	bill.FamilyID = existing.FamilyID
+	bill.Status = existing.Status
+	bill.TransactionID = existing.TransactionID
+	bill.PaidAt = existing.PaidAt
+	bill.RemindedAt = existing.RemindedAt
+	bill.CreatedBy = existing.CreatedBy
+	bill.CreatedAt = existing.CreatedAt
+	if bill.Currency == "" {
+		bill.Currency = existing.Currency
+	}
+
+	if err := s.validateBill(bill); err != nil {
+		return err
+	}
+	if !bill.DueDate.Equal(existing.DueDate) || bill.ReminderDays != existing.ReminderDays {
+		bill.RemindedAt = nil
+	}
+
+	bill.UpdatedAt = time.Now()
+	if err := s.billDao.UpdateBill(bill); err != nil {
+		return fmt.Errorf("更新账单失败: %v", err)
+	}
+
+	recordAudit(scope, bill.FamilyID, EntityBill, bill.ID, model.AuditUpdate, existing, bill)
+
+	return nil
+}
+
+// DeleteBill 删除账单，付款交易保留
+func (s *billService) DeleteBill(scope Scope, id uint) error {
+	existing, err := s.getBill(scope, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.billDao.DeleteBill(id); err != nil {
+		return fmt.Errorf("删除账单失败: %v", err)
+	}
+
+	recordAudit(scope, existing.FamilyID, EntityBill, id, model.AuditDelete, existing, nil)
+
+	return nil
+}
+
+// GetUpcomingBills 获取今天起 days 天内到期的未付账单
+func (s *billService) GetUpcomingBills(scope Scope, familyID uint, days int) ([]model.Bill, error) {
+	if familyID == 0 {
+		return nil, errors.New("无效的家庭ID")
+	}
+	if days < 1 || days > 366 {
+		return nil, errors.New("天数必须在1到366之间")
+	}
+
+	if err := scope.checkFamily(familyID); err != nil {
+		return nil, err
+	}
+
+	from := today(time.Now())
+	bills, err := s.billDao.GetUnpaidBills(familyID, from, from.AddDate(0, 0, days))
+	if err != nil {
+		return nil, fmt.Errorf("获取即将到期账单失败: %v", err)
+	}
+
+	return bills, nil
+}
+
+// GetOverdueBills 获取已过到期日仍未付的账单
+func (s *billService) GetOverdueBills(scope Scope, familyID uint) ([]model.Bill, error) {
+	if familyID == 0 {
+		return nil, errors.New("无效的家庭ID")
+	}
+
+	if err := scope.checkFamily(familyID); err != nil {
+		return nil, err
+	}
+
+	bills, err := s.billDao.GetUnpaidBills(familyID, time.Time{}, today(time.Now()).AddDate(0, 0, -1))
+	if err != nil {
+		return nil, fmt.Errorf("获取逾期账单失败: %v", err)
+	}
+
+	return bills, nil
+}
+
+// GetPaidBills 获取付款时间在时间范围内的已付账单
+func (s *billService) GetPaidBills(scope Scope, familyID uint, startTime, endTime time.Time) ([]model.Bill, error) {
+	if familyID == 0 {
+		return nil, errors.New("无效的家庭ID")
+	}
+	if endTime.Before(startTime) {
+		return nil, errors.New("结束时间不能早于开始时间")
+	}
+
+	if err := scope.checkFamily(familyID); err != nil {
+		return nil, err
+	}
+
+	bills, err := s.billDao.GetPaidBills(familyID, startTime, endTime)
+	if err != nil {
+		return nil, fmt.Errorf("获取已付账单失败: %v", err)
+	}
+
+	return bills, nil
+}
+
+// PayBill 将账单关联到实际付款的交易并标记为已付，付款时间取交易时间。
+// 交易必须是本家庭的支出或转账，且未支付其他账单
+func (s *billService) PayBill(scope Scope, id uint, request *PayBillRequest) (*model.Bill, error) {
+	bill, err := s.getBill(scope, id)
+	if err != nil {
+		return nil, err
+	}
+	if bill.Status != model.BillUnpaid {
+		return nil, errors.New("账单已付")
+	}
+	if request.TransactionID == 0 {
+		return nil, errors.New("需要指定付款交易")
+	}
+
+	transaction, err := s.transactionDao.GetTransactionByID(request.TransactionID)
+	if err != nil || transaction == nil || transaction.FamilyID != bill.FamilyID {
+		return nil, errors.New("交易不存在")
+	}
+	if transaction.Status != model.Valid && transaction.Status != model.Planned {
+		return nil, errors.New("只能关联正常或计划中的交易")
+	}
+	if transaction.Type != model.Expense && transaction.Type != model.Transfer {
+		return nil, errors.New("只能关联支出或转账交易")
+	}
+
+	linked, err := s.billDao.GetBillByTransactionID(transaction.ID)
+	if err != nil {
+		return nil, fmt.Errorf("检查交易是否已关联账单时出错: %v", err)
+	}
+	if linked != nil {
+		return nil, errors.New("该交易已用于支付其他账单")
+	}
+
+	paid, err := s.billDao.MarkBillPaid(bill.ID, transaction.ID, transaction.TransactionTime)
+	if err != nil {
+		return nil, fmt.Errorf("标记账单已付失败: %v", err)
+	}
+	if !paid {
+		return nil, errors.New("账单已付")
+	}
+
+	before := *bill
+	bill.Status = model.BillPaid
+	bill.TransactionID = &transaction.ID
+	bill.PaidAt = &transaction.TransactionTime
+	recordAudit(scope, bill.FamilyID, EntityBill, bill.ID, model.AuditUpdate, &before, bill)
+
+	return bill, nil
+}
+
+// UnpayBill 撤销账单付款，解除与交易的关联，交易本身保留
+func (s *billService) UnpayBill(scope Scope, id uint) (*model.Bill, error) {
+	bill, err := s.getBill(scope, id)
+	if err != nil {
+		return nil, err
+	}
+
+	unpaid, err := s.billDao.MarkBillUnpaid(bill.ID)
+	if err != nil {
+		return nil, fmt.Errorf("撤销账单付款失败: %v", err)
+	}
+	if !unpaid {
+		return nil, errors.New("账单尚未付款")
+	}
+
+	before := *bill
+	bill.Status = model.BillUnpaid
+	bill.TransactionID = nil
+	bill.PaidAt = nil
+	recordAudit(scope, bill.FamilyID, EntityBill, bill.ID, model.AuditUpdate, &before, bill)
+
+	return bill, nil
+}
+
+// SendDueReminders 为已到提醒日期的未付账单发送提醒，由定时任务调用。
+// 先记录已提醒再发送，多个任务同时运行时每张账单只提醒一次；发送失败时撤销记录，下次定时任务重试
+func (s *billService) SendDueReminders(now time.Time) error {
+	bills, err := s.billDao.GetBillsToRemind(now)
+	if err != nil {
+		return fmt.Errorf("获取待提醒账单失败: %v", err)
+	}
+
+	remindedAt := claimTime(now)
+	for i := range bills {
+		bill := &bills[i]
+		reminded, err := s.billDao.MarkBillReminded(bill.ID, remindedAt)
+		if err != nil {
+			log.Printf("记录账单提醒失败 BillID=%d: %v", bill.ID, err)
+			continue
+		}
+		if !reminded {
+			continue
+		}
+		if err := notifyFamily(bill.FamilyID, billReminder(bill, now)); err != nil {
+			log.Printf("发送账单提醒失败 BillID=%d: %v", bill.ID, err)
+			if err := s.billDao.UnmarkBillReminded(bill.ID, remindedAt); err != nil {
+				log.Printf("撤销账单提醒记录失败 BillID=%d: %v", bill.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// billReminder 构造账单提醒消息
+func billReminder(bill *model.Bill, now time.Time) *NotificationMessage {
+	days := int(bill.DueDate.Sub(today(now)).Hours() / 24)
+	var when string
+	switch {
+	case days > 0:
+		when = fmt.Sprintf("将于%d天后（%s）到期", days, bill.DueDate.Format("2006-01-02"))
+	case days == 0:
+		when = "今天到期"
+	default:
+		when = fmt.Sprintf("已逾期%d天（%s 到期）", -days, bill.DueDate.Format("2006-01-02"))
+	}
+
+	content := fmt.Sprintf("账单「%s」%s，预计金额 %s %s", bill.Name, when, bill.ExpectedAmount.StringFixed(2), bill.Currency)
+	if bill.Payee != "" {
+		content += "，收款方：" + bill.Payee
+	}
+
+	return &NotificationMessage{
+		Title:      "账单提醒：" + bill.Name,
+		Content:    content,
+		EntityType: EntityBill,
+		EntityID:   bill.ID,
+	}
+}
+
+// getBill 获取属于操作者家庭的账单
+func (s *billService) getBill(scope Scope, id uint) (*model.Bill, error) {
+	if id == 0 {
+		return nil, errors.New("无效的账单ID")
+	}
+
+	bill, err := s.billDao.GetBillByID(id)
+	if err != nil || bill == nil || !scope.owns(bill.FamilyID) {
+		return nil, errors.New("账单不存在")
+	}
+	return bill, nil
+}
+
+// validateBill 验证账单数据，到期日只保留日期部分
+func (s *billService) validateBill(bill *model.Bill) error {
+	bill.Name = strings.TrimSpace(bill.Name)
+	if bill.Name == "" {
+		return errors.New("账单名称不能为空")
+	}
+	if len([]rune(bill.Name)) > 100 {
+		return errors.New("账单名称不能超过100个字符")
+	}
+	bill.Payee = strings.TrimSpace(bill.Payee)
+	if len([]rune(bill.Payee)) > 100 {
+		return errors.New("收款方不能超过100个字符")
+	}
+	if !bill.ExpectedAmount.IsPositive() || !isCents(bill.ExpectedAmount) {
+		return errors.New("预计金额必须大于0且最多保留两位小数")
+	}
+	if !currencyPattern.MatchString(bill.Currency) {
+		return errors.New("无效的币种代码")
+	}
+	if bill.DueDate.IsZero() {
+		return errors.New("账单需要指定到期日")
+	}
+	bill.DueDate = today(bill.DueDate)
+	if bill.ReminderDays < 0 || bill.ReminderDays > 90 {
+		return errors.New("提醒提前天数必须在0到90之间")
+	}
+
+	if bill.CategoryID != nil {
+		category, err := s.categoryDao.GetCategoryByID(*bill.CategoryID)
+		if err != nil || category == nil || category.IsDeleted {
+			return errors.New("分类不存在")
+		}
+		if category.Type != model.CategoryExpense {
+			return errors.New("账单只能使用支出分类")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,325 @@
+// service/budget_service.go
+package service
+
+import (
+	"errors"
+	"fmt"
+	"github.com/KQLXK/Family-Finance-System/model"
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+// BudgetProgress 预算在某个周期内的执行情况，金额以家庭本位币计
+type BudgetProgress struct {
+	Budget      model.Budget    `json:"budget"`
+	Currency    string          `json:"currency"`
+	PeriodStart time.Time       `json:"period_start"`
+	PeriodEnd   time.Time       `json:"period_end"` // 不包含
+	Budgeted    decimal.Decimal `json:"budgeted"`
+	Spent       decimal.Decimal `json:"spent"`
+	Remaining   decimal.Decimal `json:"remaining"`
+	Projected   decimal.Decimal `json:"projected"` // 按当前支出速度推算的周期末支出
+	Overspent   bool            `json:"overspent"`
+}
+
+// BudgetService 预算服务接口
+type BudgetService interface {
+	CreateBudget(scope Scope, budget *model.Budget) error
+	GetBudgetByID(scope Scope, id uint) (*model.Budget, error)
+	GetBudgetsByFamilyID(scope Scope, familyID uint) ([]model.Budget, error)
+	UpdateBudget(scope Scope, budget *model.Budget) error
+	DeleteBudget(scope Scope, id uint) error
+	GetBudgetProgress(scope Scope, id uint, date time.Time) (*BudgetProgress, error)
+	GetFamilyBudgetProgress(scope Scope, familyID uint, date time.Time) ([]BudgetProgress, error)
+}
+
+// budgetService 预算服务实现
+type budgetService struct {
+	budgetDao   model.BudgetDao
+	envelopeDao model.EnvelopeDao
+	familyDao   model.FamilyDao
+	categoryDao model.CategoryDao
+}
+
+// NewBudgetService 创建预算服务实例
+func NewBudgetService() BudgetService {
+	return &budgetService{
+		budgetDao:   *model.NewBudgetDaoInstance(),
+		envelopeDao: *model.NewEnvelopeDaoInstance(),
+		familyDao:   *model.NewFamilyDaoInstance(),
+		categoryDao: *model.NewCategoryDaoInstance(),
+	}
+}
+
+// CreateBudget 创建分类预算，同一分类每种周期只能有一个预算
+func (s *budgetService) CreateBudget(scope Scope, budget *model.Budget) error {
+	if err := s.validateBudget(budget); err != nil {
+		return err
+	}
+
+	if err := scope.checkFamily(budget.FamilyID); err != nil {
+		return err
+	}
+
+	family, err := s.familyDao.GetFamilyByID(budget.FamilyID)
+	if err != nil || family == nil {
+		return errors.New("关联的家庭不存在")
+	}
+
+	category, err := s.categoryDao.GetCategoryByID(budget.CategoryID)
+	if err != nil || category == nil || category.IsDeleted {
+		return errors.New("分类不存在")
+	}
+	if category.Type != model.CategoryExpense {
+		return errors.New("只能为支出分类设置预算")
+	}
+
+	if err := s.checkBudgetAvailable(budget.FamilyID, budget.CategoryID, budget.Period, 0); err != nil {
+		return err
+	}
+
+	budget.ID = 0
+	budget.Category = nil
+	budget.CreatedBy = scope.MemberID
+	if err := s.budgetDao.CreateBudget(budget); err != nil {
+		return fmt.Errorf("创建预算失败: %v", err)
+	}
+
+	recordAudit(scope, budget.FamilyID, EntityBudget, budget.ID, model.AuditCreate, nil, budget)
+
+	return nil
+}
+
+// GetBudgetByID 根据ID获取预算
+func (s *budgetService) GetBudgetByID(scope Scope, id uint) (*model.Budget, error) {
+	if id == 0 {
+		return nil, errors.New("无效的预算ID")
+	}
+
+	budget, err := s.budgetDao.GetBudgetByID(id)
+	if err != nil || budget == nil || !scope.owns(budget.FamilyID) {
+		return nil, errors.New("预算不存在")
+	}
+
+	return budget, nil
+}
+
+// GetBudgetsByFamilyID 获取家庭的预算列表
+func (s *budgetService) GetBudgetsByFamilyID(scope Scope, familyID uint) ([]model.Budget, error) {
+	if familyID == 0 {
+		return nil, errors.New("无效的家庭ID")
+	}
+
+	if err := scope.checkFamily(familyID); err != nil {
+		return nil, err
+	}
+
+	budgets, err := s.budgetDao.GetBudgetsByFamilyID(familyID)
+	if err != nil {
+		return nil, fmt.Errorf("获取预算列表失败: %v", err)
+	}
+
+	return budgets, nil
+}
+
+// UpdateBudget 更新预算金额、周期和结转设置，分类不能修改。修改金额前先按原金额保存已结束周期的分配，
+// 新金额只影响当前及之后尚未分配的周期；已有历史周期或周期分配的预算不能修改周期
+func (s *budgetService) UpdateBudget(scope Scope, budget *model.Budget) error {
+	existing, err := s.GetBudgetByID(scope, budget.ID)
+	if err != nil {
+		return err
+	}
+	budget.FamilyID = existing.FamilyID
+	budget.CategoryID = existing.CategoryID
+	budget.CreatedBy = existing.CreatedBy
+	budget.CreatedAt = existing.CreatedAt
+
+	if err := s.validateBudget(budget); err != nil {
+		return err
+	}
+
+	first, _ := periodRange(existing.Period, existing.CreatedAt)
+	current, _ := periodRange(existing.Period, time.Now())
+
+	if budget.Period != existing.Period {
+		if first.Before(current) {
+			return errors.New("预算已有历史周期，不能修改周期")
+		}
+		count, err := s.envelopeDao.CountAllocations(budget.ID)
+		if err != nil {
+			return fmt.Errorf("检查预算分配时出错: %v", err)
+		}
+		if count > 0 {
+			return errors.New("预算已有周期分配记录，不能修改周期")
+		}
+		if err := s.checkBudgetAvailable(budget.FamilyID, budget.CategoryID, budget.Period, budget.ID); err != nil {
+			return err
+		}
+	}
+
+	if !budget.Amount.Equal(existing.Amount) {
+		if err := s.savePastAllocations(existing, first, current); err != nil {
+			return err
+		}
+	}
+
+	budget.Category = existing.Category
+	budget.UpdatedAt = time.Now()
+	if err := s.budgetDao.UpdateBudget(budget); err != nil {
+		return fmt.Errorf("更新预算失败: %v", err)
+	}
+
+	recordAudit(scope, budget.FamilyID, EntityBudget, budget.ID, model.AuditUpdate, existing, budget)
+
+	return nil
+}
+
+// DeleteBudget 删除预算
+func (s *budgetService) DeleteBudget(scope Scope, id uint) error {
+	existing, err := s.GetBudgetByID(scope, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.budgetDao.DeleteBudget(id); err != nil {
+		return fmt.Errorf("删除预算失败: %v", err)
+	}
+
+	recordAudit(scope, existing.FamilyID, EntityBudget, id, model.AuditDelete, existing, nil)
+
+	return nil
+}
+
+// GetBudgetProgress 获取预算在 date 所在周期的执行情况
+func (s *budgetService) GetBudgetProgress(scope Scope, id uint, date time.Time) (*BudgetProgress, error) {
+	budget, err := s.GetBudgetByID(scope, id)
+	if err != nil {
+		return nil, err
+	}
+
+	family, err := s.familyDao.GetFamilyByID(budget.FamilyID)
+	if err != nil || family == nil {
+		return nil, errors.New("家庭不存在")
+	}
+
+	return s.progress(budget, newCurrencyConverter(family), date, time.Now())
+}
+
+// GetFamilyBudgetProgress 获取家庭所有预算在 date 所在周期的执行情况
+func (s *budgetService) GetFamilyBudgetProgress(scope Scope, familyID uint, date time.Time) ([]BudgetProgress, error) {
+	budgets, err := s.GetBudgetsByFamilyID(scope, familyID)
+	if err != nil {
+		return nil, err
+	}
+
+	family, err := s.familyDao.GetFamilyByID(familyID)
+	if err != nil || family == nil {
+		return nil, errors.New("家庭不存在")
+	}
+
+	converter := newCurrencyConverter(family)
+	now := time.Now()
+	result := make([]BudgetProgress, 0, len(budgets))
+	for i := range budgets {
+		progress, err := s.progress(&budgets[i], converter, date, now)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *progress)
+	}
+
+	return result, nil
+}
+
+// progress 统计预算在 date 所在周期内分类子树的支出，并按已过时间的支出速度推算周期末支出
+func (s *budgetService) progress(budget *model.Budget, converter *currencyConverter, date, now time.Time) (*BudgetProgress, error) {
+	start, end := periodRange(budget.Period, date)
+
+	path := ""
+	if budget.Category != nil {
+		path = budget.Category.Path
+	}
+	if path == "" {
+		return nil, errors.New("预算分类不存在")
+	}
+
+	rows, err := s.budgetDao.GetCategoryTreeSpending(budget.FamilyID, path, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("统计预算支出失败: %v", err)
+	}
+	totals, err := converter.sum(rows)
+	if err != nil {
+		return nil, err
+	}
+	spent := totals[""]
+
+	projected := spent
+	if now.After(start) && now.Before(end) {
+		elapsed := decimal.NewFromInt(int64(now.Sub(start) / time.Second))
+		total := decimal.NewFromInt(int64(end.Sub(start) / time.Second))
+		if elapsed.IsPositive() {
+			projected = spent.Mul(total).Div(elapsed).Round(2)
+		}
+	} else if !now.After(start) {
+		projected = decimal.Zero
+	}
+
+	return &BudgetProgress{
+		Budget:      *budget,
+		Currency:    converter.baseCurrency,
+		PeriodStart: start,
+		PeriodEnd:   end,
+		Budgeted:    budget.Amount,
+		Spent:       spent,
+		Remaining:   budget.Amount.Sub(spent),
+		Projected:   projected,
+		Overspent:   spent.GreaterThan(budget.Amount),
+	}, nil
+}
+
+// validateBudget 验证预算数据，未指定周期时按月
+func (s *budgetService) validateBudget(budget *model.Budget) error {
+	if budget.CategoryID == 0 {
+		return errors.New("预算需要指定分类")
+	}
+	if !budget.Amount.IsPositive() || !isCents(budget.Amount) {
+		return errors.New("预算金额必须大于0且最多保留两位小数")
+	}
+	if budget.Period == "" {
+		budget.Period = model.PeriodMonthly
+	}
+	if !isValidPeriod(budget.Period) {
+		return errors.New("无效的预算周期，支持: weekly, monthly, yearly")
+	}
+	return nil
+}
+
+// checkBudgetAvailable 检查分类在该周期是否已有预算
+func (s *budgetService) checkBudgetAvailable(familyID, categoryID uint, period model.Period, excludeID uint) error {
+	existing, err := s.budgetDao.GetBudgetByCategory(familyID, categoryID, period)
+	if err != nil {
+		return fmt.Errorf("检查预算是否存在时出错: %v", err)
+	}
+	if existing != nil && existing.ID != excludeID {
+		return errors.New("该分类在此周期已有预算")
+	}
+	return nil
+}
+
+// savePastAllocations 为预算在 [first, current) 内尚未分配的周期按预算当前金额保存分配记录，已有分配的周期不变
+func (s *budgetService) savePastAllocations(budget *model.Budget, first, current time.Time) error {
+	var allocations []model.BudgetAllocation
+	for periodStart := first; periodStart.Before(current); {
+		allocations = append(allocations, model.BudgetAllocation{
+			BudgetID:    budget.ID,
+			PeriodStart: periodStart,
+			Amount:      budget.Amount,
+		})
+		_, periodStart = periodRange(budget.Period, periodStart)
+	}
+
+	if err := s.envelopeDao.CreateMissingAllocations(allocations); err != nil {
+		return fmt.Errorf("保存预算分配失败: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,295 @@
+// service/cashflow_service.go
+package service
+
+import (
+	"errors"
+	"fmt"
+	"github.com/KQLXK/Family-Finance-System/model"
+	"github.com/shopspring/decimal"
+	"sort"
+	"time"
+)
+
+// CashFlowItem 预计发生的一笔现金流，来自计划交易或周期交易
+type CashFlowItem struct {
+	Date        time.Time             `json:"date"`
+	Source      string                `json:"source"`    // planned: 计划交易，recurring: 周期交易
+	SourceID    uint                  `json:"source_id"` // 计划交易ID或周期交易模板ID
+	Type        model.TransactionType `json:"type"`
+	Description string                `json:"description"`
+	Amount      decimal.Decimal       `json:"amount"` // 原币种金额
+	Currency    string                `json:"currency"`
+	NetAmount   decimal.Decimal       `json:"net_amount"` // 对家庭资金的影响（本位币），流入为正；转账只计手续费
+}
+
+// CashFlowPoint 某个时间段的预计现金流和期末余额
+type CashFlowPoint struct {
+	Period  string          `json:"period"`
+	Inflow  decimal.Decimal `json:"inflow"`
+	Outflow decimal.Decimal `json:"outflow"`
+	Net     decimal.Decimal `json:"net"`
+	Balance decimal.Decimal `json:"balance"`
+}
+
+// CashFlowProjection 家庭现金流预测，以各账户当前余额为起点，金额以家庭本位币计
+type CashFlowProjection struct {
+	Currency       string          `json:"currency"`
+	From           time.Time       `json:"from"`
+	To             time.Time       `json:"to"`
+	OpeningBalance decimal.Decimal `json:"opening_balance"`
+	ClosingBalance decimal.Decimal `json:"closing_balance"`
+	Points         []CashFlowPoint `json:"points"`
+	Items          []CashFlowItem  `json:"items"`
+}
+
+// CashFlowService 现金流预测服务接口
+type CashFlowService interface {
+	GetProjection(scope Scope, familyID uint, days int, groupBy string) (*CashFlowProjection, error)
+}
+
+// cashFlowService 现金流预测服务实现
+type cashFlowService struct {
+	transactionDao model.TransactionDao
+	familyDao      model.FamilyDao
+	accountDao     model.AccountDao
+	recurringDao   model.RecurringDao
+}
+
+// NewCashFlowService 创建现金流预测服务实例
+func NewCashFlowService() CashFlowService {
+	return &cashFlowService{
+		transactionDao: *model.NewTransactionDaoInstance(),
+		familyDao:      *model.NewFamilyDaoInstance(),
+		accountDao:     *model.NewAccountDaoInstance(),
+		recurringDao:   *model.NewRecurringDaoInstance(),
+	}
+}
+
+// GetProjection 预测未来 days 天的现金流，按 day、week 或 month 分组
+func (s *cashFlowService) GetProjection(scope Scope, familyID uint, days int, groupBy string) (*CashFlowProjection, error) {
+	if familyID == 0 {
+		return nil, errors.New("无效的家庭ID")
+	}
+	if days < 1 || days > 366 {
+		return nil, errors.New("预测天数必须在1到366之间")
+	}
+	if groupBy != "day" && groupBy != "week" && groupBy != "month" {
+		return nil, errors.New("无效的分组方式，支持: day, week, month")
+	}
+
+	if err := scope.checkFamily(familyID); err != nil {
+		return nil, err
+	}
+
+	family, err := s.familyDao.GetFamilyByID(familyID)
+	if err != nil || family == nil {
+		return nil, errors.New("家庭不存在")
+	}
+	converter := newCurrencyConverter(family)
+
+	now := time.Now()
+	end := today(now).AddDate(0, 0, days+1).Add(-time.Second)
+
+	opening, err := s.openingBalance(familyID, converter, now)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := s.plannedItems(familyID, converter, end)
+	if err != nil {
+		return nil, err
+	}
+	recurring, err := s.recurringItems(familyID, converter, end)
+	if err != nil {
+		return nil, err
+	}
+	items = append(items, recurring...)
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Date.Before(items[j].Date) })
+
+	// 逐个时间段累计，没有现金流的时间段也输出以便绘制余额曲线
+	points := make([]CashFlowPoint, 0)
+	index := make(map[string]int)
+	for start := cashFlowPeriodStart(groupBy, now); !start.After(end); start = cashFlowNextPeriod(groupBy, start) {
+		key := cashFlowPeriodKey(groupBy, start)
+		index[key] = len(points)
+		points = append(points, CashFlowPoint{Period: key})
+	}
+	for _, item := range items {
+		i, ok := index[cashFlowPeriodKey(groupBy, cashFlowPeriodStart(groupBy, item.Date))]
+		if !ok {
+			// 到期但尚未生成的周期交易计入第一个时间段
+			i = 0
+		}
+		if item.NetAmount.IsPositive() {
+			points[i].Inflow = points[i].Inflow.Add(item.NetAmount)
+		} else {
+			points[i].Outflow = points[i].Outflow.Sub(item.NetAmount)
+		}
+		points[i].Net = points[i].Net.Add(item.NetAmount)
+	}
+	balance := opening
+	for i := range points {
+		balance = balance.Add(points[i].Net)
+		points[i].Balance = balance
+	}
+
+	return &CashFlowProjection{
+		Currency:       converter.baseCurrency,
+		From:           now,
+		To:             end,
+		OpeningBalance: opening,
+		ClosingBalance: balance,
+		Points:         points,
+		Items:          items,
+	}, nil
+}
+
+// openingBalance 统计家庭未归档账户的当前余额之和
+func (s *cashFlowService) openingBalance(familyID uint, converter *currencyConverter, now time.Time) (decimal.Decimal, error) {
+	accounts, err := s.accountDao.GetAccountsByFamilyID(familyID, false)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("获取账户失败: %v", err)
+	}
+
+	total := decimal.Zero
+	for _, account := range accounts {
+		net, err := s.accountDao.GetAccountNetAmount(account.ID, now)
+		if err != nil {
+			return decimal.Zero, fmt.Errorf("获取账户余额失败: %v", err)
+		}
+		balance, err := converter.convert(account.OpeningBalance.Add(net), account.Currency, now)
+		if err != nil {
+			return decimal.Zero, err
+		}
+		total = total.Add(balance)
+	}
+	return total.Round(2), nil
+}
+
+// plannedItems 列出 end 之前的计划交易
+func (s *cashFlowService) plannedItems(familyID uint, converter *currencyConverter, end time.Time) ([]CashFlowItem, error) {
+	transactions, err := s.transactionDao.GetPlannedTransactions(familyID, end)
+	if err != nil {
+		return nil, fmt.Errorf("获取计划交易失败: %v", err)
+	}
+
+	items := make([]CashFlowItem, 0, len(transactions))
+	for _, transaction := range transactions {
+		net, err := cashFlowNet(converter, transaction.Type, transaction.Amount, transaction.Fee, transaction.Currency, transaction.TransactionTime)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, CashFlowItem{
+			Date:        transaction.TransactionTime,
+			Source:      "planned",
+			SourceID:    transaction.ID,
+			Type:        transaction.Type,
+			Description: transaction.Note,
+			Amount:      transaction.Amount,
+			Currency:    transaction.Currency,
+			NetAmount:   net,
+		})
+	}
+	return items, nil
+}
+
+// recurringItems 列出启用的周期交易在 end 之前将要生成的各期
+func (s *cashFlowService) recurringItems(familyID uint, converter *currencyConverter, end time.Time) ([]CashFlowItem, error) {
+	templates, err := s.recurringDao.GetTemplatesByFamilyID(familyID)
+	if err != nil {
+		return nil, fmt.Errorf("获取周期交易失败: %v", err)
+	}
+
+	items := make([]CashFlowItem, 0)
+	for i := range templates {
+		template := &templates[i]
+		if !template.IsActive {
+			continue
+		}
+
+		// 未指定币种时按账户币种或家庭本位币
+		currency := template.Currency
+		if currency == "" && template.AccountID != nil {
+			if account, err := s.accountDao.GetAccountByID(*template.AccountID); err == nil && account != nil {
+				currency = account.Currency
+			}
+		}
+		if currency == "" {
+			currency = converter.baseCurrency
+		}
+
+		for date := template.NextRunDate; date != nil && !date.After(end); date = followingRunDate(template, *date) {
+			net, err := cashFlowNet(converter, template.Type, template.Amount, template.Fee, currency, *date)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, CashFlowItem{
+				Date:        *date,
+				Source:      "recurring",
+				SourceID:    template.ID,
+				Type:        template.Type,
+				Description: template.Name,
+				Amount:      template.Amount,
+				Currency:    currency,
+				NetAmount:   net,
+			})
+		}
+	}
+	return items, nil
+}
+
+// cashFlowNet 计算一笔交易对家庭资金的影响（本位币），转账在家庭账户之间流转，只计手续费
+func cashFlowNet(converter *currencyConverter, transactionType model.TransactionType, amount, fee decimal.Decimal, currency string, date time.Time) (decimal.Decimal, error) {
+	var net decimal.Decimal
+	switch transactionType {
+	case model.Income, model.Refund:
+		net = amount
+	case model.Expense:
+		net = amount.Neg()
+	case model.Transfer:
+		net = fee.Neg()
+	}
+	if net.IsZero() {
+		return decimal.Zero, nil
+	}
+
+	converted, err := converter.convert(net, currency, date)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return converted.Round(2), nil
+}
+
+// cashFlowPeriodStart 返回 t 所在时间段的开始时间
+func cashFlowPeriodStart(groupBy string, t time.Time) time.Time {
+	switch groupBy {
+	case "week":
+		start, _ := periodRange(model.PeriodWeekly, t)
+		return start
+	case "month":
+		start, _ := periodRange(model.PeriodMonthly, t)
+		return start
+	default:
+		return today(t)
+	}
+}
+
+// cashFlowNextPeriod 返回下一个时间段的开始时间
+func cashFlowNextPeriod(groupBy string, start time.Time) time.Time {
+	switch groupBy {
+	case "week":
+		return start.AddDate(0, 0, 7)
+	case "month":
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+// cashFlowPeriodKey 返回时间段的显示名称，按周分组时为周一的日期
+func cashFlowPeriodKey(groupBy string, start time.Time) string {
+	if groupBy == "month" {
+		return start.Format("2006-01")
+	}
+	return start.Format("2006-01-02")
+}
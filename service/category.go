@@ -11,14 +11,14 @@ import (
 
 // CategoryService 分类服务接口
 type CategoryService interface {
-	CreateCategory(category *model.Category) error
+	CreateCategory(scope Scope, category *model.Category) error
 	GetCategoryByID(id uint) (*model.Category, error)
 	GetCategoriesByType(categoryType model.CategoryType) ([]model.Category, error)
 	GetCategoryTreeByType(categoryType model.CategoryType) ([]model.Category, error)
 	GetCategoriesByParentID(parentID uint) ([]model.Category, error)
 	GetAllCategories() ([]model.Category, error)
-	UpdateCategory(category *model.Category) error
-	DeleteCategory(id uint) error
+	UpdateCategory(scope Scope, category *model.Category) error
+	DeleteCategory(scope Scope, id uint) error
 	CategoryExists(id uint) (bool, error)
 	GetFullCategoryPath(id uint) (string, error)
 }
@@ -36,7 +36,7 @@ func NewCategoryService() CategoryService {
 }
 
 // CreateCategory 创建分类
-func (s *categoryService) CreateCategory(category *model.Category) error {
+func (s *categoryService) CreateCategory(scope Scope, category *model.Category) error {
 	// 验证分类数据
 	if err := s.validateCategory(category); err != nil {
 		return err
@@ -90,6 +90,9 @@ func (s *categoryService) CreateCategory(category *model.Category) error {
 		return fmt.Errorf("更新分类路径失败: %v", err)
 	}
 
+	// 分类为全局数据，审计日志记录在操作者所属家庭下
+	recordAudit(scope, scope.FamilyID, EntityCategory, category.ID, model.AuditCreate, nil, category)
+
 	return nil
 }
 
@@ -204,7 +207,7 @@ func (s *categoryService) GetAllCategories() ([]model.Category, error) {
 }
 
 // UpdateCategory 更新分类信息
-func (s *categoryService) UpdateCategory(category *model.Category) error {
+func (s *categoryService) UpdateCategory(scope Scope, category *model.Category) error {
 	// 验证分类ID
 	if category.ID == 0 {
 		return errors.New("无效的分类ID")
@@ -216,16 +219,13 @@ func (s *categoryService) UpdateCategory(category *model.Category) error {
 	}
 
 	// 检查分类是否存在
-	exists, err := s.CategoryExists(category.ID)
-	if err != nil {
-		return fmt.Errorf("检查分类是否存在时出错: %v", err)
-	}
-	if !exists {
+	existing, err := s.categoryDao.GetCategoryByID(category.ID)
+	if err != nil || existing == nil || existing.IsDeleted {
 		return errors.New("分类不存在")
 	}
 
 	// 检查分类名称是否已被其他分类使用（同一父分类下）
-	exists, err = s.categoryNameExists(category.Name, category.Type, category.ParentID, category.ID)
+	exists, err := s.categoryNameExists(category.Name, category.Type, category.ParentID, category.ID)
 	if err != nil {
 		return fmt.Errorf("检查分类名称是否已存在时出错: %v", err)
 	}
@@ -265,11 +265,13 @@ func (s *categoryService) UpdateCategory(category *model.Category) error {
 		return fmt.Errorf("更新分类信息失败: %v", err)
 	}
 
+	recordAudit(scope, scope.FamilyID, EntityCategory, category.ID, model.AuditUpdate, existing, category)
+
 	return nil
 }
 
 // DeleteCategory 删除分类（软删除）
-func (s *categoryService) DeleteCategory(id uint) error {
+func (s *categoryService) DeleteCategory(scope Scope, id uint) error {
 	// 验证ID
 	if id == 0 {
 		return errors.New("无效的分类ID")
@@ -307,6 +309,8 @@ func (s *categoryService) DeleteCategory(id uint) error {
 		return fmt.Errorf("删除分类失败: %v", err)
 	}
 
+	recordAudit(scope, scope.FamilyID, EntityCategory, id, model.AuditDelete, category, nil)
+
 	return nil
 }
 
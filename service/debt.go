@@ -0,0 +1,447 @@
+// service/debt_service.go
+package service
+
+import (
+	"errors"
+	"fmt"
+	"github.com/KQLXK/Family-Finance-System/model"
+	"github.com/shopspring/decimal"
+	"strings"
+	"time"
+)
+
+// DebtStatus 借贷及其当前的利息、已还和未还金额
+type DebtStatus struct {
+	model.Debt
+	Interest    decimal.Decimal `json:"interest"`    // 截至今天（或还清日）的利息
+	Repaid      decimal.Decimal `json:"repaid"`      // 已还金额
+	Outstanding decimal.Decimal `json:"outstanding"` // 未还金额 = 本金 + 利息 - 已还
+	Overdue     bool            `json:"overdue"`
+}
+
+// AddRepaymentRequest 登记还款，amount 为空时使用交易金额
+type AddRepaymentRequest struct {
+	TransactionID uint            `json:"transaction_id"`
+	Amount        decimal.Decimal `json:"amount"`
+}
+
+// DebtService 借贷服务接口
+type DebtService interface {
+	CreateDebt(scope Scope, debt *model.Debt) error
+	GetDebtByID(scope Scope, id uint) (*DebtStatus, error)
+	GetDebts(scope Scope, familyID uint, status string) ([]DebtStatus, error)
+	GetOverdueDebts(scope Scope, familyID uint) ([]DebtStatus, error)
+	UpdateDebt(scope Scope, debt *model.Debt) error
+	DeleteDebt(scope Scope, id uint) error
+	AddRepayment(scope Scope, debtID uint, request AddRepaymentRequest) (*model.DebtRepayment, error)
+	GetRepayments(scope Scope, debtID uint) ([]model.DebtRepayment, error)
+	DeleteRepayment(scope Scope, debtID, repaymentID uint) error
+}
+
+// debtService 借贷服务实现
+type debtService struct {
+	debtDao            model.DebtDao
+	familyDao          model.FamilyDao
+	transactionService TransactionService
+}
+
+// NewDebtService 创建借贷服务实例
+func NewDebtService() DebtService {
+	return &debtService{
+		debtDao:            *model.NewDebtDaoInstance(),
+		familyDao:          *model.NewFamilyDaoInstance(),
+		transactionService: NewTransactionService(),
+	}
+}
+
+// CreateDebt 创建借贷记录
+func (s *debtService) CreateDebt(scope Scope, debt *model.Debt) error {
+	if err := scope.checkFamily(debt.FamilyID); err != nil {
+		return err
+	}
+
+	if err := s.validateDebt(debt); err != nil {
+		return err
+	}
+
+	family, err := s.familyDao.GetFamilyByID(debt.FamilyID)
+	if err != nil || family == nil {
+		return errors.New("关联的家庭不存在")
+	}
+	if debt.Currency == "" {
+		debt.Currency = family.BaseCurrency
+	}
+	if debt.Currency == "" {
+		debt.Currency = defaultCurrency
+	}
+
+	debt.ClosedAt = nil
+	debt.CreatedBy = scope.MemberID
+	debt.Repayments = nil
+	if err := s.debtDao.CreateDebt(debt); err != nil {
+		return fmt.Errorf("创建借贷记录失败: %v", err)
+	}
+
+	recordAudit(scope, debt.FamilyID, EntityDebt, debt.ID, model.AuditCreate, nil, debt)
+
+	return nil
+}
+
+// GetDebtByID 获取借贷记录及其还款状态
+func (s *debtService) GetDebtByID(scope Scope, id uint) (*DebtStatus, error) {
+	debt, err := s.getDebt(scope, id)
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := s.debtStatus(*debt, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return &status, nil
+}
+
+// GetDebts 获取家庭的借贷记录，status 支持 open（默认）、closed、all
+func (s *debtService) GetDebts(scope Scope, familyID uint, status string) ([]DebtStatus, error) {
+	if err := scope.checkFamily(familyID); err != nil {
+		return nil, err
+	}
+
+	switch status {
+	case "":
+		status = "open"
+	case "all":
+		status = ""
+	case "open", "closed":
+	default:
+		return nil, errors.New("无效的状态，支持: open, closed, all")
+	}
+
+	debts, err := s.debtDao.GetDebtsByFamilyID(familyID, status)
+	if err != nil {
+		return nil, fmt.Errorf("获取借贷记录失败: %v", err)
+	}
+
+	return s.debtStatuses(debts)
+}
+
+// GetOverdueDebts 获取家庭已逾期的借贷记录
+func (s *debtService) GetOverdueDebts(scope Scope, familyID uint) ([]DebtStatus, error) {
+	if err := scope.checkFamily(familyID); err != nil {
+		return nil, err
+	}
+
+	debts, err := s.debtDao.GetOverdueDebts(familyID, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("获取逾期借贷记录失败: %v", err)
+	}
+
+	return s.debtStatuses(debts)
+}
+
+// UpdateDebt 更新借贷信息，方向和币种不能修改
+func (s *debtService) UpdateDebt(scope Scope, debt *model.Debt) error {
+	existing, err := s.getDebt(scope, debt.ID)
+	if err != nil {
+		return err
+	}
+	debt.FamilyID = existing.FamilyID
+	debt.Direction = existing.Direction
+	debt.Currency = existing.Currency
+
+	if err := s.validateDebt(debt); err != nil {
+		return err
+	}
+
+	debt.UpdatedAt = time.Now()
+	if err := s.debtDao.UpdateDebt(debt); err != nil {
+		return fmt.Errorf("更新借贷记录失败: %v", err)
+	}
+
+	// 本金或利率变化后重新判断是否已还清
+	if err := refreshDebtClosed(s.debtDao, debt.ID); err != nil {
+		return err
+	}
+
+	recordAudit(scope, existing.FamilyID, EntityDebt, debt.ID, model.AuditUpdate, existing, debt)
+
+	return nil
+}
+
+// DeleteDebt 删除借贷记录及其还款记录，关联的交易保留
+func (s *debtService) DeleteDebt(scope Scope, id uint) error {
+	existing, err := s.getDebt(scope, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.debtDao.DeleteDebt(id); err != nil {
+		return fmt.Errorf("删除借贷记录失败: %v", err)
+	}
+
+	recordAudit(scope, existing.FamilyID, EntityDebt, id, model.AuditDelete, existing, nil)
+
+	return nil
+}
+
+// AddRepayment 登记还款。借出的款项由收入交易收回，借入的款项由支出交易偿还，
+// 交易币种必须与借贷一致，一笔交易只能登记一次还款
+func (s *debtService) AddRepayment(scope Scope, debtID uint, request AddRepaymentRequest) (*model.DebtRepayment, error) {
+	debt, err := s.getDebt(scope, debtID)
+	if err != nil {
+		return nil, err
+	}
+	if debt.ClosedAt != nil {
+		return nil, errors.New("借贷已还清")
+	}
+
+	transaction, err := s.transactionService.GetTransactionByID(scope, request.TransactionID)
+	if err != nil {
+		return nil, err
+	}
+	expectedType := model.Income
+	if debt.Direction == model.DebtBorrowed {
+		expectedType = model.Expense
+	}
+	if transaction.Type != expectedType {
+		return nil, errors.New("交易类型与借贷方向不匹配：借出的款项应关联收入，借入的款项应关联支出")
+	}
+	if transaction.Status != model.Valid {
+		return nil, errors.New("只能关联有效的交易")
+	}
+	if transaction.Currency != debt.Currency {
+		return nil, errors.New("交易币种与借贷币种不一致")
+	}
+
+	linked, err := s.debtDao.GetRepaymentByTransactionID(transaction.ID)
+	if err != nil {
+		return nil, fmt.Errorf("检查交易是否已关联还款时出错: %v", err)
+	}
+	if linked != nil {
+		return nil, errors.New("该交易已登记为还款")
+	}
+
+	amount := request.Amount
+	if amount.IsZero() {
+		amount = transaction.Amount
+	}
+	if !amount.IsPositive() || !isCents(amount) {
+		return nil, errors.New("还款金额必须大于0且最多保留两位小数")
+	}
+	if amount.GreaterThan(transaction.Amount) {
+		return nil, errors.New("还款金额不能超过交易金额")
+	}
+
+	status, err := s.debtStatus(*debt, transaction.TransactionTime)
+	if err != nil {
+		return nil, err
+	}
+	if amount.GreaterThan(status.Outstanding) {
+		return nil, fmt.Errorf("还款金额不能超过未还金额 %s", status.Outstanding.StringFixed(2))
+	}
+
+	repayment := &model.DebtRepayment{
+		DebtID:        debt.ID,
+		TransactionID: transaction.ID,
+		Amount:        amount,
+		RepaidAt:      transaction.TransactionTime,
+		CreatedBy:     scope.MemberID,
+	}
+	if err := s.debtDao.CreateRepayment(repayment); err != nil {
+		return nil, fmt.Errorf("登记还款失败: %v", err)
+	}
+
+	if err := refreshDebtClosed(s.debtDao, debt.ID); err != nil {
+		return nil, err
+	}
+
+	recordAudit(scope, debt.FamilyID, EntityDebtRepayment, repayment.ID, model.AuditCreate, nil, repayment)
+
+	return repayment, nil
+}
+
+// GetRepayments 获取借贷的还款记录
+func (s *debtService) GetRepayments(scope Scope, debtID uint) ([]model.DebtRepayment, error) {
+	if _, err := s.getDebt(scope, debtID); err != nil {
+		return nil, err
+	}
+
+	repayments, err := s.debtDao.GetRepaymentsByDebtID(debtID)
+	if err != nil {
+		return nil, fmt.Errorf("获取还款记录失败: %v", err)
+	}
+
+	return repayments, nil
+}
+
+// DeleteRepayment 删除还款记录，关联的交易保留
+func (s *debtService) DeleteRepayment(scope Scope, debtID, repaymentID uint) error {
+	debt, err := s.getDebt(scope, debtID)
+	if err != nil {
+		return err
+	}
+
+	repayment, err := s.debtDao.GetRepaymentByID(repaymentID)
+	if err != nil || repayment == nil || repayment.DebtID != debt.ID {
+		return errors.New("还款记录不存在")
+	}
+
+	if err := s.debtDao.DeleteRepayment(repaymentID); err != nil {
+		return fmt.Errorf("删除还款记录失败: %v", err)
+	}
+
+	if err := refreshDebtClosed(s.debtDao, debt.ID); err != nil {
+		return err
+	}
+
+	recordAudit(scope, debt.FamilyID, EntityDebtRepayment, repaymentID, model.AuditDelete, repayment, nil)
+
+	return nil
+}
+
+// getDebt 获取操作者所属家庭的借贷记录
+func (s *debtService) getDebt(scope Scope, id uint) (*model.Debt, error) {
+	if id == 0 {
+		return nil, errors.New("无效的借贷ID")
+	}
+
+	debt, err := s.debtDao.GetDebtByID(id)
+	if err != nil || debt == nil || !scope.owns(debt.FamilyID) {
+		return nil, errors.New("借贷记录不存在")
+	}
+
+	return debt, nil
+}
+
+// debtStatuses 批量计算借贷状态
+func (s *debtService) debtStatuses(debts []model.Debt) ([]DebtStatus, error) {
+	now := time.Now()
+	statuses := make([]DebtStatus, 0, len(debts))
+	for _, debt := range debts {
+		status, err := s.debtStatus(debt, now)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// debtStatus 计算借贷截至指定时间的利息、已还和未还金额
+func (s *debtService) debtStatus(debt model.Debt, at time.Time) (DebtStatus, error) {
+	repaid, err := s.debtDao.GetRepaidAmount(debt.ID)
+	if err != nil {
+		return DebtStatus{}, fmt.Errorf("统计已还金额失败: %v", err)
+	}
+
+	interest := debtInterest(debt, at)
+	outstanding := debt.Principal.Add(interest).Sub(repaid)
+	if outstanding.IsNegative() {
+		outstanding = decimal.Zero
+	}
+
+	return DebtStatus{
+		Debt:        debt,
+		Interest:    interest,
+		Repaid:      repaid,
+		Outstanding: outstanding,
+		Overdue:     debt.ClosedAt == nil && debt.DueDate != nil && debt.DueDate.Before(at),
+	}, nil
+}
+
+// debtInterest 按本金单利计算截至指定时间（已还清的借贷截至还清时间）的利息
+func debtInterest(debt model.Debt, until time.Time) decimal.Decimal {
+	if !debt.InterestRate.IsPositive() {
+		return decimal.Zero
+	}
+	if debt.ClosedAt != nil && debt.ClosedAt.Before(until) {
+		until = *debt.ClosedAt
+	}
+
+	days := int64(until.Sub(debt.StartDate).Hours() / 24)
+	if days <= 0 {
+		return decimal.Zero
+	}
+
+	return debt.Principal.Mul(debt.InterestRate).Div(decimal.NewFromInt(100)).
+		Mul(decimal.NewFromInt(days)).Div(decimal.NewFromInt(365)).Round(2)
+}
+
+// validateDebt 验证借贷数据
+func (s *debtService) validateDebt(debt *model.Debt) error {
+	debt.Counterparty = strings.TrimSpace(debt.Counterparty)
+	if debt.Counterparty == "" {
+		return errors.New("对方姓名不能为空")
+	}
+	if len(debt.Counterparty) > 100 {
+		return errors.New("对方姓名长度不能超过100个字符")
+	}
+
+	if debt.Direction != model.DebtLent && debt.Direction != model.DebtBorrowed {
+		return errors.New("无效的借贷方向，支持: lent, borrowed")
+	}
+
+	if !debt.Principal.IsPositive() || !isCents(debt.Principal) {
+		return errors.New("本金必须大于0且最多保留两位小数")
+	}
+
+	if debt.InterestRate.IsNegative() || debt.InterestRate.GreaterThan(decimal.NewFromInt(100)) {
+		return errors.New("年利率必须在0到100之间")
+	}
+
+	if debt.StartDate.IsZero() {
+		debt.StartDate = time.Now()
+	}
+	if debt.DueDate != nil && debt.DueDate.Before(debt.StartDate) {
+		return errors.New("到期日不能早于借款日")
+	}
+
+	if debt.Currency != "" {
+		debt.Currency = strings.ToUpper(debt.Currency)
+		if !currencyPattern.MatchString(debt.Currency) {
+			return errors.New("无效的货币代码")
+		}
+	}
+
+	if len(debt.Note) > 500 {
+		return errors.New("备注长度不能超过500个字符")
+	}
+
+	return nil
+}
+
+// refreshDebtClosed 按最近一次有效还款时的未还金额更新借贷的还清状态：还清时以该次还款时间为还清时间，
+// 之后不再计息；关联交易删除后未还金额为正时重新打开借贷
+func refreshDebtClosed(debtDao model.DebtDao, id uint) error {
+	debt, err := debtDao.GetDebtByID(id)
+	if err != nil {
+		return fmt.Errorf("获取借贷记录失败: %v", err)
+	}
+
+	lastRepaidAt, err := debtDao.GetLastRepaidAt(id)
+	if err != nil {
+		return fmt.Errorf("获取最近还款时间失败: %v", err)
+	}
+
+	var closedAt *time.Time
+	if lastRepaidAt != nil {
+		repaid, err := debtDao.GetRepaidAmount(id)
+		if err != nil {
+			return fmt.Errorf("统计已还金额失败: %v", err)
+		}
+		open := *debt
+		open.ClosedAt = nil
+		if open.Principal.Add(debtInterest(open, *lastRepaidAt)).Sub(repaid).Sign() <= 0 {
+			closedAt = lastRepaidAt
+		}
+	}
+
+	if closedAt == nil && debt.ClosedAt == nil {
+		return nil
+	}
+	if closedAt != nil && debt.ClosedAt != nil && closedAt.Equal(*debt.ClosedAt) {
+		return nil
+	}
+	return debtDao.SetDebtClosedAt(id, closedAt)
+}
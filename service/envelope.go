@@ -0,0 +1,323 @@
+// service/envelope_service.go
+package service
+
+import (
+	"errors"
+	"fmt"
+	"github.com/KQLXK/Family-Finance-System/model"
+	"github.com/shopspring/decimal"
+	"strings"
+	"time"
+)
+
+// EnvelopeStatus 预算信封在某个周期的余额，金额以家庭本位币计。
+// 可用金额 = 本期分配 + 转入 - 转出 + 上期结转，余额 = 可用金额 - 本期支出
+type EnvelopeStatus struct {
+	Budget      model.Budget    `json:"budget"`
+	Currency    string          `json:"currency"`
+	PeriodStart time.Time       `json:"period_start"`
+	PeriodEnd   time.Time       `json:"period_end"` // 不包含
+	Allocated   decimal.Decimal `json:"allocated"`
+	MovedIn     decimal.Decimal `json:"moved_in"`
+	MovedOut    decimal.Decimal `json:"moved_out"`
+	CarriedIn   decimal.Decimal `json:"carried_in"` // 上期结余（为负表示超支）
+	Available   decimal.Decimal `json:"available"`
+	Spent       decimal.Decimal `json:"spent"`
+	Balance     decimal.Decimal `json:"balance"`
+}
+
+// MoveEnvelopeRequest 信封调拨请求，Date 所在周期为调拨周期，为空时为当前周期
+type MoveEnvelopeRequest struct {
+	FromBudgetID uint            `json:"from_budget_id"`
+	ToBudgetID   uint            `json:"to_budget_id"`
+	Amount       decimal.Decimal `json:"amount"`
+	Date         *time.Time      `json:"date"`
+	Note         string          `json:"note"`
+}
+
+// EnvelopeService 信封预算服务接口
+type EnvelopeService interface {
+	GetEnvelope(scope Scope, budgetID uint, date time.Time) (*EnvelopeStatus, error)
+	GetFamilyEnvelopes(scope Scope, familyID uint, date time.Time) ([]EnvelopeStatus, error)
+	SetAllocation(scope Scope, budgetID uint, date time.Time, amount decimal.Decimal) (*model.BudgetAllocation, error)
+	MoveBetweenEnvelopes(scope Scope, familyID uint, request MoveEnvelopeRequest) (*model.BudgetMove, error)
+	GetMoves(scope Scope, familyID uint, startTime, endTime time.Time) ([]model.BudgetMove, error)
+}
+
+// envelopeService 信封预算服务实现
+type envelopeService struct {
+	envelopeDao   model.EnvelopeDao
+	budgetDao     model.BudgetDao
+	familyDao     model.FamilyDao
+	budgetService BudgetService
+}
+
+// NewEnvelopeService 创建信封预算服务实例
+func NewEnvelopeService() EnvelopeService {
+	return &envelopeService{
+		envelopeDao:   *model.NewEnvelopeDaoInstance(),
+		budgetDao:     *model.NewBudgetDaoInstance(),
+		familyDao:     *model.NewFamilyDaoInstance(),
+		budgetService: NewBudgetService(),
+	}
+}
+
+// GetEnvelope 获取预算信封在 date 所在周期的余额
+func (s *envelopeService) GetEnvelope(scope Scope, budgetID uint, date time.Time) (*EnvelopeStatus, error) {
+	budget, err := s.budgetService.GetBudgetByID(scope, budgetID)
+	if err != nil {
+		return nil, err
+	}
+
+	family, err := s.familyDao.GetFamilyByID(budget.FamilyID)
+	if err != nil || family == nil {
+		return nil, errors.New("家庭不存在")
+	}
+
+	return s.envelope(budget, newCurrencyConverter(family), date)
+}
+
+// GetFamilyEnvelopes 获取家庭所有预算信封在 date 所在周期的余额，早于预算创建周期的预算不列出
+func (s *envelopeService) GetFamilyEnvelopes(scope Scope, familyID uint, date time.Time) ([]EnvelopeStatus, error) {
+	budgets, err := s.budgetService.GetBudgetsByFamilyID(scope, familyID)
+	if err != nil {
+		return nil, err
+	}
+
+	family, err := s.familyDao.GetFamilyByID(familyID)
+	if err != nil || family == nil {
+		return nil, errors.New("家庭不存在")
+	}
+
+	converter := newCurrencyConverter(family)
+	result := make([]EnvelopeStatus, 0, len(budgets))
+	for i := range budgets {
+		if first, _ := periodRange(budgets[i].Period, budgets[i].CreatedAt); date.Before(first) {
+			continue
+		}
+		status, err := s.envelope(&budgets[i], converter, date)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *status)
+	}
+
+	return result, nil
+}
+
+// SetAllocation 设置预算在 date 所在周期的分配金额
+func (s *envelopeService) SetAllocation(scope Scope, budgetID uint, date time.Time, amount decimal.Decimal) (*model.BudgetAllocation, error) {
+	budget, err := s.budgetService.GetBudgetByID(scope, budgetID)
+	if err != nil {
+		return nil, err
+	}
+
+	if amount.IsNegative() || !isCents(amount) {
+		return nil, errors.New("分配金额不能为负数且最多保留两位小数")
+	}
+
+	start, _ := periodRange(budget.Period, date)
+	if first, _ := periodRange(budget.Period, budget.CreatedAt); start.Before(first) {
+		return nil, errors.New("不能为预算创建之前的周期分配金额")
+	}
+
+	allocation := &model.BudgetAllocation{
+		BudgetID:    budget.ID,
+		PeriodStart: start,
+		Amount:      amount,
+	}
+	if err := s.envelopeDao.SaveAllocation(allocation); err != nil {
+		return nil, fmt.Errorf("保存预算分配失败: %v", err)
+	}
+
+	recordAudit(scope, budget.FamilyID, EntityBudget, budget.ID, model.AuditUpdate, nil,
+		map[string]interface{}{"period_start": start.Format("2006-01-02"), "allocation": amount})
+
+	return allocation, nil
+}
+
+// MoveBetweenEnvelopes 在同一周期类型的两个预算信封之间调拨，调出金额不能超过调出信封的余额
+func (s *envelopeService) MoveBetweenEnvelopes(scope Scope, familyID uint, request MoveEnvelopeRequest) (*model.BudgetMove, error) {
+	if err := scope.checkFamily(familyID); err != nil {
+		return nil, err
+	}
+
+	if request.FromBudgetID == 0 || request.ToBudgetID == 0 || request.FromBudgetID == request.ToBudgetID {
+		return nil, errors.New("需要指定两个不同的预算")
+	}
+	if !request.Amount.IsPositive() || !isCents(request.Amount) {
+		return nil, errors.New("调拨金额必须大于0且最多保留两位小数")
+	}
+	request.Note = strings.TrimSpace(request.Note)
+	if len(request.Note) > 500 {
+		return nil, errors.New("备注长度不能超过500个字符")
+	}
+
+	from, err := s.budgetService.GetBudgetByID(scope, request.FromBudgetID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.budgetService.GetBudgetByID(scope, request.ToBudgetID)
+	if err != nil {
+		return nil, err
+	}
+	if from.FamilyID != familyID || to.FamilyID != familyID {
+		return nil, errors.New("预算不存在")
+	}
+	if from.Period != to.Period {
+		return nil, errors.New("只能在周期相同的预算之间调拨")
+	}
+
+	date := time.Now()
+	if request.Date != nil {
+		date = request.Date.In(time.Local)
+	}
+	start, _ := periodRange(from.Period, date)
+	for _, budget := range []*model.Budget{from, to} {
+		if first, _ := periodRange(budget.Period, budget.CreatedAt); start.Before(first) {
+			return nil, errors.New("不能调拨预算创建之前的周期")
+		}
+	}
+
+	family, err := s.familyDao.GetFamilyByID(familyID)
+	if err != nil || family == nil {
+		return nil, errors.New("家庭不存在")
+	}
+	status, err := s.envelope(from, newCurrencyConverter(family), date)
+	if err != nil {
+		return nil, err
+	}
+	if request.Amount.GreaterThan(status.Balance) {
+		return nil, fmt.Errorf("调拨金额不能超过信封余额 %s", status.Balance.StringFixed(2))
+	}
+
+	move := &model.BudgetMove{
+		FamilyID:     familyID,
+		FromBudgetID: from.ID,
+		ToBudgetID:   to.ID,
+		PeriodStart:  start,
+		Amount:       request.Amount,
+		Note:         request.Note,
+		CreatedBy:    scope.MemberID,
+	}
+	if err := s.envelopeDao.CreateMove(move); err != nil {
+		return nil, fmt.Errorf("信封调拨失败: %v", err)
+	}
+
+	recordAudit(scope, familyID, EntityBudgetMove, move.ID, model.AuditCreate, nil, move)
+
+	return move, nil
+}
+
+// GetMoves 获取家庭在时间范围内的调拨记录，默认为最近一年
+func (s *envelopeService) GetMoves(scope Scope, familyID uint, startTime, endTime time.Time) ([]model.BudgetMove, error) {
+	if familyID == 0 {
+		return nil, errors.New("无效的家庭ID")
+	}
+
+	if err := scope.checkFamily(familyID); err != nil {
+		return nil, err
+	}
+
+	if endTime.IsZero() {
+		endTime = time.Now()
+	}
+	if startTime.IsZero() {
+		startTime = endTime.AddDate(-1, 0, 0)
+	}
+
+	moves, err := s.envelopeDao.GetMovesByFamilyID(familyID, startTime, endTime)
+	if err != nil {
+		return nil, fmt.Errorf("获取调拨记录失败: %v", err)
+	}
+
+	return moves, nil
+}
+
+// envelope 计算信封在 date 所在周期的余额。从预算创建的周期开始逐期累计，没有分配记录的周期按预算金额，
+// 支出按交易实际记录统计；结转的预算把每期余额带入下一期
+func (s *envelopeService) envelope(budget *model.Budget, converter *currencyConverter, date time.Time) (*EnvelopeStatus, error) {
+	if budget.Category == nil || budget.Category.Path == "" {
+		return nil, errors.New("预算分类不存在")
+	}
+
+	first, _ := periodRange(budget.Period, budget.CreatedAt)
+	target, targetEnd := periodRange(budget.Period, date)
+	if target.Before(first) {
+		return nil, errors.New("日期早于预算创建的周期")
+	}
+
+	allocations, err := s.envelopeDao.GetAllocations(budget.ID)
+	if err != nil {
+		return nil, fmt.Errorf("获取预算分配失败: %v", err)
+	}
+	allocated := make(map[string]decimal.Decimal, len(allocations))
+	for _, allocation := range allocations {
+		allocated[allocation.PeriodStart.Format("2006-01-02")] = allocation.Amount
+	}
+
+	moves, err := s.envelopeDao.GetMovesByBudgetID(budget.ID, target)
+	if err != nil {
+		return nil, fmt.Errorf("获取调拨记录失败: %v", err)
+	}
+	movedIn := make(map[string]decimal.Decimal)
+	movedOut := make(map[string]decimal.Decimal)
+	for _, move := range moves {
+		key := move.PeriodStart.Format("2006-01-02")
+		if move.ToBudgetID == budget.ID {
+			movedIn[key] = movedIn[key].Add(move.Amount)
+		} else {
+			movedOut[key] = movedOut[key].Add(move.Amount)
+		}
+	}
+
+	// 不结转的预算只需要统计目标周期
+	start := target
+	if budget.Rollover {
+		start = first
+	}
+	rows, err := s.budgetDao.GetCategoryTreeSpending(budget.FamilyID, budget.Category.Path, start, targetEnd)
+	if err != nil {
+		return nil, fmt.Errorf("统计信封支出失败: %v", err)
+	}
+	spent := make(map[string]decimal.Decimal)
+	for _, row := range rows {
+		amount, err := converter.convert(row.Amount, row.Currency, row.Date)
+		if err != nil {
+			return nil, err
+		}
+		periodStart, _ := periodRange(budget.Period, row.Date)
+		key := periodStart.Format("2006-01-02")
+		spent[key] = spent[key].Add(amount)
+	}
+
+	var status *EnvelopeStatus
+	carried := decimal.Zero
+	for periodStart := start; !periodStart.After(target); {
+		_, periodEnd := periodRange(budget.Period, periodStart)
+		key := periodStart.Format("2006-01-02")
+
+		amount, ok := allocated[key]
+		if !ok {
+			amount = budget.Amount
+		}
+		status = &EnvelopeStatus{
+			Budget:      *budget,
+			Currency:    converter.baseCurrency,
+			PeriodStart: periodStart,
+			PeriodEnd:   periodEnd,
+			Allocated:   amount,
+			MovedIn:     movedIn[key],
+			MovedOut:    movedOut[key],
+			CarriedIn:   carried,
+			Spent:       spent[key].Round(2),
+		}
+		status.Available = status.Allocated.Add(status.MovedIn).Sub(status.MovedOut).Add(status.CarriedIn)
+		status.Balance = status.Available.Sub(status.Spent)
+
+		carried = status.Balance
+		periodStart = periodEnd
+	}
+
+	return status, nil
+}
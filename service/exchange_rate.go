@@ -0,0 +1,274 @@
+// service/exchange_rate_service.go
+package service
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/KQLXK/Family-Finance-System/model"
+	"github.com/shopspring/decimal"
+	"io"
+	"strings"
+	"time"
+)
+
+// maxImportRows 单次导入汇率的最大行数
+const maxImportRows = 10000
+
+// ExchangeRateService 汇率服务接口
+type ExchangeRateService interface {
+	SaveExchangeRate(scope Scope, rate *model.ExchangeRate) error
+	ImportExchangeRates(scope Scope, familyID uint, reader io.Reader) (int, error)
+	GetExchangeRatesByFamilyID(scope Scope, familyID uint, currency string, startDate, endDate time.Time) ([]model.ExchangeRate, error)
+	DeleteExchangeRate(scope Scope, familyID, id uint) error
+}
+
+// exchangeRateService 汇率服务实现
+type exchangeRateService struct {
+	exchangeRateDao model.ExchangeRateDao
+}
+
+// NewExchangeRateService 创建汇率服务实例
+func NewExchangeRateService() ExchangeRateService {
+	return &exchangeRateService{
+		exchangeRateDao: *model.NewExchangeRateDaoInstance(),
+	}
+}
+
+// SaveExchangeRate 保存一条汇率，同一天的同一币种对已存在时覆盖
+func (s *exchangeRateService) SaveExchangeRate(scope Scope, rate *model.ExchangeRate) error {
+	if err := scope.checkFamily(rate.FamilyID); err != nil {
+		return err
+	}
+
+	if err := validateExchangeRate(rate); err != nil {
+		return err
+	}
+
+	existing, err := s.exchangeRateDao.GetExchangeRateByDate(rate.FamilyID, rate.FromCurrency, rate.ToCurrency, rate.RateDate)
+	if err != nil {
+		return fmt.Errorf("检查汇率是否存在时出错: %v", err)
+	}
+
+	if err := s.exchangeRateDao.SaveExchangeRates([]model.ExchangeRate{*rate}); err != nil {
+		return fmt.Errorf("保存汇率失败: %v", err)
+	}
+
+	// 覆盖已有汇率时数据库不一定返回行ID，重新读取保存后的记录
+	saved, err := s.exchangeRateDao.GetExchangeRateByDate(rate.FamilyID, rate.FromCurrency, rate.ToCurrency, rate.RateDate)
+	if err != nil || saved == nil {
+		return errors.New("获取保存的汇率失败")
+	}
+	*rate = *saved
+
+	if existing != nil {
+		recordAudit(scope, rate.FamilyID, EntityExchangeRate, rate.ID, model.AuditUpdate, existing, rate)
+	} else {
+		recordAudit(scope, rate.FamilyID, EntityExchangeRate, rate.ID, model.AuditCreate, nil, rate)
+	}
+
+	return nil
+}
+
+// ImportExchangeRates 从 CSV 导入汇率，每行格式为 date,from,to,rate（日期格式 2006-01-02），
+// 首行为表头时自动跳过。任一行无效时整个文件都不会导入，返回导入的行数
+func (s *exchangeRateService) ImportExchangeRates(scope Scope, familyID uint, reader io.Reader) (int, error) {
+	if err := scope.checkFamily(familyID); err != nil {
+		return 0, err
+	}
+
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = 4
+	csvReader.TrimLeadingSpace = true
+
+	var rates []model.ExchangeRate
+	for line := 1; ; line++ {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, fmt.Errorf("第%d行格式错误: %v", line, err)
+		}
+
+		// 跳过表头
+		if line == 1 && strings.EqualFold(strings.TrimSpace(record[0]), "date") {
+			continue
+		}
+
+		if len(rates) >= maxImportRows {
+			return 0, fmt.Errorf("单次最多导入%d行汇率", maxImportRows)
+		}
+
+		rateDate, err := time.Parse("2006-01-02", strings.TrimSpace(record[0]))
+		if err != nil {
+			return 0, fmt.Errorf("第%d行日期格式错误，请使用 2006-01-02 格式", line)
+		}
+		value, err := decimal.NewFromString(strings.TrimSpace(record[3]))
+		if err != nil {
+			return 0, fmt.Errorf("第%d行汇率格式错误", line)
+		}
+
+		rate := model.ExchangeRate{
+			FamilyID:     familyID,
+			FromCurrency: record[1],
+			ToCurrency:   record[2],
+			RateDate:     rateDate,
+			Rate:         value,
+		}
+		if err := validateExchangeRate(&rate); err != nil {
+			return 0, fmt.Errorf("第%d行: %v", line, err)
+		}
+		rates = append(rates, rate)
+	}
+
+	if len(rates) == 0 {
+		return 0, errors.New("文件中没有汇率数据")
+	}
+
+	if err := s.exchangeRateDao.SaveExchangeRates(rates); err != nil {
+		return 0, fmt.Errorf("导入汇率失败: %v", err)
+	}
+
+	recordAudit(scope, familyID, EntityExchangeRate, 0, model.AuditCreate, nil, map[string]int{"imported": len(rates)})
+
+	return len(rates), nil
+}
+
+// GetExchangeRatesByFamilyID 获取家庭在日期范围内的汇率
+func (s *exchangeRateService) GetExchangeRatesByFamilyID(scope Scope, familyID uint, currency string, startDate, endDate time.Time) ([]model.ExchangeRate, error) {
+	if familyID == 0 {
+		return nil, errors.New("无效的家庭ID")
+	}
+
+	if err := scope.checkFamily(familyID); err != nil {
+		return nil, err
+	}
+
+	rates, err := s.exchangeRateDao.GetExchangeRatesByFamilyID(familyID, strings.ToUpper(currency), startDate, endDate)
+	if err != nil {
+		return nil, fmt.Errorf("获取汇率列表失败: %v", err)
+	}
+
+	return rates, nil
+}
+
+// DeleteExchangeRate 删除汇率
+func (s *exchangeRateService) DeleteExchangeRate(scope Scope, familyID, id uint) error {
+	if err := scope.checkFamily(familyID); err != nil {
+		return err
+	}
+
+	rate, err := s.exchangeRateDao.GetExchangeRateByID(id)
+	if err != nil || rate == nil || rate.FamilyID != familyID {
+		return errors.New("汇率不存在")
+	}
+
+	if err := s.exchangeRateDao.DeleteExchangeRate(id); err != nil {
+		return fmt.Errorf("删除汇率失败: %v", err)
+	}
+
+	recordAudit(scope, familyID, EntityExchangeRate, id, model.AuditDelete, rate, nil)
+
+	return nil
+}
+
+// validateExchangeRate 验证汇率数据，并规范化币种代码
+func validateExchangeRate(rate *model.ExchangeRate) error {
+	rate.FromCurrency = strings.ToUpper(strings.TrimSpace(rate.FromCurrency))
+	rate.ToCurrency = strings.ToUpper(strings.TrimSpace(rate.ToCurrency))
+	if !currencyPattern.MatchString(rate.FromCurrency) || !currencyPattern.MatchString(rate.ToCurrency) {
+		return errors.New("无效的货币代码")
+	}
+	if rate.FromCurrency == rate.ToCurrency {
+		return errors.New("源币种和目标币种不能相同")
+	}
+	if rate.RateDate.IsZero() {
+		return errors.New("汇率日期不能为空")
+	}
+	rate.RateDate = time.Date(rate.RateDate.Year(), rate.RateDate.Month(), rate.RateDate.Day(), 0, 0, 0, 0, time.Local)
+	if !rate.Rate.IsPositive() {
+		return errors.New("汇率必须大于0")
+	}
+	return nil
+}
+
+// currencyConverter 将金额按交易日期的汇率换算为家庭本位币，同一请求内缓存查到的汇率
+type currencyConverter struct {
+	familyID        uint
+	baseCurrency    string
+	exchangeRateDao model.ExchangeRateDao
+	cache           map[string]decimal.Decimal
+}
+
+// newCurrencyConverter 创建本位币换算器
+func newCurrencyConverter(family *model.Family) *currencyConverter {
+	base := family.BaseCurrency
+	if base == "" {
+		base = defaultCurrency
+	}
+	return &currencyConverter{
+		familyID:        family.ID,
+		baseCurrency:    base,
+		exchangeRateDao: *model.NewExchangeRateDaoInstance(),
+		cache:           make(map[string]decimal.Decimal),
+	}
+}
+
+// convert 将金额换算为本位币。没有正向汇率时使用反向汇率的倒数，都没有时返回错误
+func (c *currencyConverter) convert(amount decimal.Decimal, currency string, date time.Time) (decimal.Decimal, error) {
+	if currency == "" || currency == c.baseCurrency {
+		return amount, nil
+	}
+
+	rate, err := c.rate(currency, date)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return amount.Mul(rate), nil
+}
+
+// rate 获取指定日期 currency 兑换本位币的汇率
+func (c *currencyConverter) rate(currency string, date time.Time) (decimal.Decimal, error) {
+	key := currency + "|" + date.Format("2006-01-02")
+	if rate, ok := c.cache[key]; ok {
+		return rate, nil
+	}
+
+	direct, err := c.exchangeRateDao.GetRateOnDate(c.familyID, currency, c.baseCurrency, date)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("获取汇率失败: %v", err)
+	}
+	var rate decimal.Decimal
+	if direct != nil {
+		rate = direct.Rate
+	} else {
+		inverse, err := c.exchangeRateDao.GetRateOnDate(c.familyID, c.baseCurrency, currency, date)
+		if err != nil {
+			return decimal.Zero, fmt.Errorf("获取汇率失败: %v", err)
+		}
+		if inverse == nil {
+			return decimal.Zero, fmt.Errorf("缺少 %s 在 %s 及之前兑换 %s 的汇率", currency, date.Format("2006-01-02"), c.baseCurrency)
+		}
+		rate = decimal.NewFromInt(1).DivRound(inverse.Rate, 16)
+	}
+
+	c.cache[key] = rate
+	return rate, nil
+}
+
+// sum 将统计行换算为本位币后按分组键汇总，结果保留两位小数
+func (c *currencyConverter) sum(rows []model.SummaryRow) (map[string]decimal.Decimal, error) {
+	summary := make(map[string]decimal.Decimal)
+	for _, row := range rows {
+		amount, err := c.convert(row.Amount, row.Currency, row.Date)
+		if err != nil {
+			return nil, err
+		}
+		summary[row.Key] = summary[row.Key].Add(amount)
+	}
+	for key, amount := range summary {
+		summary[key] = amount.Round(2)
+	}
+	return summary, nil
+}
@@ -5,16 +5,19 @@ import (
 	"errors"
 	"fmt"
 	"github.com/KQLXK/Family-Finance-System/model"
+	"github.com/shopspring/decimal"
 	"strings"
 )
 
 // FamilyService 家庭服务接口
 type FamilyService interface {
-	CreateFamily(family *model.Family) error
-	GetFamilyByID(id uint) (*model.Family, error)
-	GetAllFamilies() ([]model.Family, error)
-	UpdateFamily(family *model.Family) error
-	DeleteFamily(id uint) error
+	CreateFamily(scope Scope, family *model.Family) error
+	GetFamilyByID(scope Scope, id uint) (*model.Family, error)
+	GetAllFamilies(scope Scope) ([]model.Family, error)
+	UpdateFamily(scope Scope, family *model.Family) error
+	UpdateApprovalThreshold(scope Scope, id uint, threshold decimal.Decimal) error
+	UpdateBaseCurrency(scope Scope, id uint, currency string) error
+	DeleteFamily(scope Scope, id uint) error
 	GetFamilyWithMembers(id uint) (*model.Family, error)
 	FamilyExists(id uint) (bool, error)
 }
@@ -32,12 +35,21 @@ func NewFamilyService() FamilyService {
 }
 
 // CreateFamily 创建家庭
-func (s *familyService) CreateFamily(family *model.Family) error {
+func (s *familyService) CreateFamily(scope Scope, family *model.Family) error {
 	// 验证家庭名称
 	if err := s.validateFamilyName(family.Name); err != nil {
 		return err
 	}
 
+	// 验证本位币，未指定时使用默认货币
+	if family.BaseCurrency == "" {
+		family.BaseCurrency = defaultCurrency
+	}
+	family.BaseCurrency = strings.ToUpper(family.BaseCurrency)
+	if !currencyPattern.MatchString(family.BaseCurrency) {
+		return errors.New("无效的货币代码")
+	}
+
 	// 检查家庭名称是否已存在
 	exists, err := s.familyNameExists(family.Name)
 	if err != nil {
@@ -52,16 +64,23 @@ func (s *familyService) CreateFamily(family *model.Family) error {
 		return fmt.Errorf("创建家庭失败: %v", err)
 	}
 
+	recordAudit(scope, family.ID, EntityFamily, family.ID, model.AuditCreate, nil, family)
+
 	return nil
 }
 
 // GetFamilyByID 根据ID获取家庭
-func (s *familyService) GetFamilyByID(id uint) (*model.Family, error) {
+func (s *familyService) GetFamilyByID(scope Scope, id uint) (*model.Family, error) {
 	// 验证ID
 	if id == 0 {
 		return nil, errors.New("无效的家庭ID")
 	}
 
+	// 只能查看自己所属的家庭
+	if err := scope.checkFamily(id); err != nil {
+		return nil, err
+	}
+
 	// 获取家庭
 	family, err := s.familyDao.GetFamilyByID(id)
 	if err != nil {
@@ -75,40 +94,45 @@ func (s *familyService) GetFamilyByID(id uint) (*model.Family, error) {
 	return family, nil
 }
 
-// GetAllFamilies 获取所有家庭
-func (s *familyService) GetAllFamilies() ([]model.Family, error) {
-	// 获取所有家庭
-	families, err := s.familyDao.GetAllFamilies()
+// GetAllFamilies 获取操作者可见的家庭（仅其所属家庭）
+func (s *familyService) GetAllFamilies(scope Scope) ([]model.Family, error) {
+	if scope.FamilyID == 0 {
+		return []model.Family{}, nil
+	}
+
+	family, err := s.familyDao.GetFamilyByID(scope.FamilyID)
 	if err != nil {
 		return nil, fmt.Errorf("获取家庭列表失败: %v", err)
 	}
 
-	return families, nil
+	return []model.Family{*family}, nil
 }
 
 // UpdateFamily 更新家庭信息
-func (s *familyService) UpdateFamily(family *model.Family) error {
+func (s *familyService) UpdateFamily(scope Scope, family *model.Family) error {
 	// 验证家庭ID
 	if family.ID == 0 {
 		return errors.New("无效的家庭ID")
 	}
 
+	// 只能修改自己所属的家庭
+	if err := scope.checkFamily(family.ID); err != nil {
+		return err
+	}
+
 	// 验证家庭名称
 	if err := s.validateFamilyName(family.Name); err != nil {
 		return err
 	}
 
 	// 检查家庭是否存在
-	exists, err := s.FamilyExists(family.ID)
-	if err != nil {
-		return fmt.Errorf("检查家庭是否存在时出错: %v", err)
-	}
-	if !exists {
+	existing, err := s.familyDao.GetFamilyByID(family.ID)
+	if err != nil || existing == nil {
 		return errors.New("家庭不存在")
 	}
 
 	// 检查家庭名称是否已被其他家庭使用
-	exists, err = s.otherFamilyHasSameName(family.ID, family.Name)
+	exists, err := s.otherFamilyHasSameName(family.ID, family.Name)
 	if err != nil {
 		return fmt.Errorf("检查家庭名称是否重复时出错: %v", err)
 	}
@@ -121,16 +145,82 @@ func (s *familyService) UpdateFamily(family *model.Family) error {
 		return fmt.Errorf("更新家庭信息失败: %v", err)
 	}
 
+	updated := *existing
+	updated.Name = family.Name
+	updated.UpdatedAt = family.UpdatedAt
+	recordAudit(scope, family.ID, EntityFamily, family.ID, model.AuditUpdate, existing, &updated)
+
+	return nil
+}
+
+// UpdateApprovalThreshold 设置家庭的交易审批阈值，0 表示关闭金额审批
+func (s *familyService) UpdateApprovalThreshold(scope Scope, id uint, threshold decimal.Decimal) error {
+	if err := scope.checkFamily(id); err != nil {
+		return err
+	}
+
+	if threshold.IsNegative() {
+		return errors.New("审批阈值不能为负数")
+	}
+	if !isCents(threshold) {
+		return errors.New("审批阈值最多保留两位小数")
+	}
+
+	existing, err := s.familyDao.GetFamilyByID(id)
+	if err != nil || existing == nil {
+		return errors.New("家庭不存在")
+	}
+
+	if err := s.familyDao.UpdateApprovalThreshold(id, threshold); err != nil {
+		return fmt.Errorf("更新审批阈值失败: %v", err)
+	}
+
+	updated := *existing
+	updated.ApprovalThreshold = threshold
+	recordAudit(scope, id, EntityFamily, id, model.AuditUpdate, existing, &updated)
+
+	return nil
+}
+
+// UpdateBaseCurrency 设置家庭本位币，统计金额将换算为该币种
+func (s *familyService) UpdateBaseCurrency(scope Scope, id uint, currency string) error {
+	if err := scope.checkFamily(id); err != nil {
+		return err
+	}
+
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if !currencyPattern.MatchString(currency) {
+		return errors.New("无效的货币代码")
+	}
+
+	existing, err := s.familyDao.GetFamilyByID(id)
+	if err != nil || existing == nil {
+		return errors.New("家庭不存在")
+	}
+
+	if err := s.familyDao.UpdateBaseCurrency(id, currency); err != nil {
+		return fmt.Errorf("更新本位币失败: %v", err)
+	}
+
+	updated := *existing
+	updated.BaseCurrency = currency
+	recordAudit(scope, id, EntityFamily, id, model.AuditUpdate, existing, &updated)
+
 	return nil
 }
 
 // DeleteFamily 删除家庭
-func (s *familyService) DeleteFamily(id uint) error {
+func (s *familyService) DeleteFamily(scope Scope, id uint) error {
 	// 验证ID
 	if id == 0 {
 		return errors.New("无效的家庭ID")
 	}
 
+	// 只能删除自己所属的家庭
+	if err := scope.checkFamily(id); err != nil {
+		return err
+	}
+
 	// 检查家庭是否存在
 	exists, err := s.FamilyExists(id)
 	if err != nil {
@@ -156,6 +246,8 @@ func (s *familyService) DeleteFamily(id uint) error {
 		return fmt.Errorf("删除家庭失败: %v", err)
 	}
 
+	recordAudit(scope, id, EntityFamily, id, model.AuditDelete, family, nil)
+
 	return nil
 }
 
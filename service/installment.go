@@ -0,0 +1,523 @@
+// service/installment_service.go
+package service
+
+import (
+	"errors"
+	"fmt"
+	"github.com/KQLXK/Family-Finance-System/model"
+	"github.com/shopspring/decimal"
+	"log"
+	"strings"
+	"time"
+)
+
+// 分期期数范围
+const (
+	minInstallmentPeriods = 3
+	maxInstallmentPeriods = 24
+)
+
+// installmentClaimTimeout 登记后超过该时间仍未生成交易的期数视为登记的任务已中断，可重新登记
+const installmentClaimTimeout = 30 * time.Minute
+
+// InstallmentSchedule 分期计划及其还款进度
+type InstallmentSchedule struct {
+	model.InstallmentPlan
+	TotalFee           decimal.Decimal `json:"total_fee"`
+	PostedPeriods      int             `json:"posted_periods"`
+	Paid               decimal.Decimal `json:"paid"`                // 已生成交易的本金和手续费，含提前结清的部分
+	RemainingPrincipal decimal.Decimal `json:"remaining_principal"` // 未生成交易的本金
+	RemainingBalance   decimal.Decimal `json:"remaining_balance"`   // 未生成交易的本金和手续费
+	NextDueDate        *time.Time      `json:"next_due_date"`
+}
+
+// InstallmentService 分期服务接口
+type InstallmentService interface {
+	CreatePlan(scope Scope, plan *model.InstallmentPlan) error
+	GetPlan(scope Scope, id uint) (*InstallmentSchedule, error)
+	GetPlans(scope Scope, familyID uint, status model.InstallmentStatus) ([]InstallmentSchedule, error)
+	CancelPlan(scope Scope, id uint) (*InstallmentSchedule, error)
+	PostDueInstallments(now time.Time) error
+}
+
+// installmentService 分期服务实现
+type installmentService struct {
+	installmentDao     model.InstallmentDao
+	familyDao          model.FamilyDao
+	memberDao          model.MemberDao
+	categoryDao        model.CategoryDao
+	accountDao         model.AccountDao
+	transactionService TransactionService
+}
+
+// NewInstallmentService 创建分期服务实例
+func NewInstallmentService() InstallmentService {
+	return &installmentService{
+		installmentDao:     *model.NewInstallmentDaoInstance(),
+		familyDao:          *model.NewFamilyDaoInstance(),
+		memberDao:          *model.NewMemberDaoInstance(),
+		categoryDao:        *model.NewCategoryDaoInstance(),
+		accountDao:         *model.NewAccountDaoInstance(),
+		transactionService: NewTransactionService(),
+	}
+}
+
+// CreatePlan 创建分期计划，生成还款计划并为已到期的各期生成交易
+func (s *installmentService) CreatePlan(scope Scope, plan *model.InstallmentPlan) error {
+	if err := s.validatePlan(plan); err != nil {
+		return err
+	}
+
+	if err := scope.checkFamily(plan.FamilyID); err != nil {
+		return err
+	}
+
+	family, err := s.familyDao.GetFamilyByID(plan.FamilyID)
+	if err != nil || family == nil {
+		return errors.New("关联的家庭不存在")
+	}
+
+	// 未指定消费成员时记在操作者名下，非管理员只能为自己创建分期
+	if plan.MemberID == 0 {
+		plan.MemberID = scope.MemberID
+	}
+	if scope.Role != model.RoleAdmin && plan.MemberID != scope.MemberID {
+		return errors.New("只能为自己创建分期计划")
+	}
+	member, err := s.memberDao.GetMemberByID(plan.MemberID)
+	if err != nil || member == nil || member.FamilyID != plan.FamilyID || member.Status != 1 {
+		return errors.New("成员不存在或不属于该家庭")
+	}
+
+	if err := s.checkCategory(plan.CategoryID); err != nil {
+		return err
+	}
+	if plan.FeeCategoryID != nil {
+		if err := s.checkCategory(*plan.FeeCategoryID); err != nil {
+			return err
+		}
+	}
+
+	// 关联账户时使用账户币种，否则使用家庭本位币
+	currency := strings.ToUpper(strings.TrimSpace(plan.Currency))
+	if plan.AccountID != nil {
+		account, err := s.accountDao.GetAccountByID(*plan.AccountID)
+		if err != nil || account == nil || account.FamilyID != plan.FamilyID {
+			return errors.New("账户不存在或不属于该家庭")
+		}
+		if account.Archived {
+			return errors.New("账户已归档")
+		}
+		if currency != "" && currency != account.Currency {
+			return errors.New("分期币种必须与账户币种一致")
+		}
+		currency = account.Currency
+	}
+	if currency == "" {
+		currency = family.BaseCurrency
+	}
+	if currency == "" {
+		currency = defaultCurrency
+	}
+	if !currencyPattern.MatchString(currency) {
+		return errors.New("无效的货币代码")
+	}
+	plan.Currency = currency
+
+	plan.ID = 0
+	plan.Status = model.InstallmentActive
+	plan.SettlementTransactionID = nil
+	plan.CancelledAt = nil
+	plan.CreatedBy = scope.MemberID
+	plan.Installments = buildInstallments(plan)
+
+	if err := s.installmentDao.CreatePlan(plan); err != nil {
+		return fmt.Errorf("创建分期计划失败: %v", err)
+	}
+
+	recordAudit(scope, plan.FamilyID, EntityInstallmentPlan, plan.ID, model.AuditCreate, nil, plan)
+
+	if err := s.postPlan(plan.ID, time.Now()); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetPlan 获取分期计划、还款计划和剩余金额
+func (s *installmentService) GetPlan(scope Scope, id uint) (*InstallmentSchedule, error) {
+	plan, err := s.getPlan(scope, id)
+	if err != nil {
+		return nil, err
+	}
+	return buildSchedule(plan), nil
+}
+
+// GetPlans 获取家庭的分期计划，status 为空时返回全部
+func (s *installmentService) GetPlans(scope Scope, familyID uint, status model.InstallmentStatus) ([]InstallmentSchedule, error) {
+	if familyID == 0 {
+		return nil, errors.New("无效的家庭ID")
+	}
+
+	if err := scope.checkFamily(familyID); err != nil {
+		return nil, err
+	}
+
+	switch status {
+	case "", model.InstallmentActive, model.InstallmentCompleted, model.InstallmentCancelled:
+	default:
+		return nil, errors.New("无效的分期状态，支持: active, completed, cancelled")
+	}
+
+	plans, err := s.installmentDao.GetPlansByFamilyID(familyID, status)
+	if err != nil {
+		return nil, fmt.Errorf("获取分期计划失败: %v", err)
+	}
+
+	schedules := make([]InstallmentSchedule, 0, len(plans))
+	for i := range plans {
+		schedules = append(schedules, *buildSchedule(&plans[i]))
+	}
+	return schedules, nil
+}
+
+// CancelPlan 提前结清分期：先补齐已到期的各期，剩余本金和手续费合并为一笔交易。
+// 先将计划标记为已取消，之后其他任务不会再登记该计划的期数；再登记所有未生成交易的期数，
+// 正在生成交易的任务登记失效后会撤销其交易。生成结清交易失败时恢复计划
+func (s *installmentService) CancelPlan(scope Scope, id uint) (*InstallmentSchedule, error) {
+	plan, err := s.getPlan(scope, id)
+	if err != nil {
+		return nil, err
+	}
+	if plan.Status != model.InstallmentActive {
+		return nil, errors.New("分期计划已结束")
+	}
+
+	if err := s.postPlan(plan.ID, time.Now()); err != nil {
+		return nil, err
+	}
+	if plan, err = s.installmentDao.GetPlanByID(id); err != nil {
+		return nil, fmt.Errorf("获取分期计划失败: %v", err)
+	}
+	if plan.Status != model.InstallmentActive {
+		// 各期已全部生成，计划已完成
+		return buildSchedule(plan), nil
+	}
+
+	now := time.Now()
+	ok, err := s.installmentDao.UpdatePlanStatus(plan.ID, model.InstallmentCancelled, &now)
+	if err != nil {
+		return nil, fmt.Errorf("更新分期计划状态失败: %v", err)
+	}
+	if !ok {
+		return nil, errors.New("分期计划已结束")
+	}
+	claimedAt := claimTime(now)
+	if err := s.installmentDao.ClaimUnpostedInstallments(plan.ID, claimedAt); err != nil {
+		s.reopenPlan(plan.ID, claimedAt)
+		return nil, fmt.Errorf("登记剩余还款计划失败: %v", err)
+	}
+	if plan, err = s.installmentDao.GetPlanByID(id); err != nil {
+		return nil, fmt.Errorf("获取分期计划失败: %v", err)
+	}
+
+	// 已生成交易的期数不再结清，其余各期合并结清
+	principal, fee := decimal.Zero, decimal.Zero
+	for _, installment := range plan.Installments {
+		if installment.TransactionID == nil {
+			principal = principal.Add(installment.Principal)
+			fee = fee.Add(installment.Fee)
+		}
+	}
+
+	var settlementID *uint
+	if principal.IsPositive() {
+		transaction := s.planTransaction(plan, principal.Add(fee), now, fmt.Sprintf("分期「%s」提前结清", plan.Name))
+		if fee.IsPositive() {
+			transaction.Splits = []model.TransactionSplit{
+				{CategoryID: plan.CategoryID, Amount: principal, Note: "剩余本金"},
+				{CategoryID: feeCategoryID(plan), Amount: fee, Note: "剩余手续费"},
+			}
+		}
+		if err := s.createPlanTransaction(plan, transaction); err != nil {
+			s.reopenPlan(plan.ID, claimedAt)
+			return nil, fmt.Errorf("生成结清交易失败: %v", err)
+		}
+		if err := s.installmentDao.SetSettlementTransaction(plan.ID, transaction.ID); err != nil {
+			return nil, fmt.Errorf("记录结清交易失败: %v", err)
+		}
+		settlementID = &transaction.ID
+	}
+
+	recordAudit(scope, plan.FamilyID, EntityInstallmentPlan, plan.ID, model.AuditUpdate,
+		map[string]interface{}{"status": model.InstallmentActive},
+		map[string]interface{}{"status": model.InstallmentCancelled, "settlement_transaction_id": settlementID})
+
+	return s.GetPlan(scope, id)
+}
+
+// reopenPlan 提前结清失败时撤销结清的登记并恢复分期计划
+func (s *installmentService) reopenPlan(planID uint, claimedAt time.Time) {
+	if err := s.installmentDao.ReleaseInstallments(planID, claimedAt); err != nil {
+		log.Printf("撤销分期还款计划登记失败 PlanID=%d: %v", planID, err)
+	}
+	if err := s.installmentDao.ReopenPlan(planID); err != nil {
+		log.Printf("恢复分期计划失败 PlanID=%d: %v", planID, err)
+	}
+}
+
+// PostDueInstallments 为所有进行中分期计划的到期各期生成交易，供定时任务调用
+func (s *installmentService) PostDueInstallments(now time.Time) error {
+	ids, err := s.installmentDao.GetPlanIDsWithDueInstallments(now, now.Add(-installmentClaimTimeout))
+	if err != nil {
+		return fmt.Errorf("获取到期分期计划失败: %v", err)
+	}
+
+	for _, id := range ids {
+		if err := s.postPlan(id, now); err != nil {
+			log.Printf("生成分期交易失败 PlanID=%d: %v", id, err)
+		}
+	}
+	return nil
+}
+
+// postPlan 为分期计划已到期的各期生成交易，手续费作为拆分明细与本金记在同一笔交易中，全部生成后计划完成。
+// 每期先登记再生成交易，已被其他任务登记的期数不会重复生成；生成失败时撤销登记，下次重试。
+// 登记超时仍未生成交易的期数可重新登记；登记已失效（如已提前结清）时撤销生成的交易
+func (s *installmentService) postPlan(planID uint, now time.Time) error {
+	plan, err := s.installmentDao.GetPlanByID(planID)
+	if err != nil {
+		return fmt.Errorf("获取分期计划失败: %v", err)
+	}
+	if plan.Status != model.InstallmentActive {
+		return nil
+	}
+
+	claimedAt := claimTime(now)
+	staleBefore := now.Add(-installmentClaimTimeout)
+	remaining := 0
+	for _, installment := range plan.Installments {
+		if installment.TransactionID != nil {
+			continue
+		}
+		if installment.DueDate.After(now) || (installment.PostedAt != nil && !installment.PostedAt.Before(staleBefore)) {
+			// 未到期或正由其他任务生成
+			remaining++
+			continue
+		}
+
+		claimed, err := s.installmentDao.ClaimInstallment(installment.ID, claimedAt, staleBefore)
+		if err != nil {
+			return fmt.Errorf("登记第%d期还款计划失败: %v", installment.Seq, err)
+		}
+		if !claimed {
+			remaining++
+			continue
+		}
+
+		transaction := s.planTransaction(plan, installment.Principal.Add(installment.Fee), installment.DueDate,
+			fmt.Sprintf("分期「%s」第%d/%d期", plan.Name, installment.Seq, plan.Periods))
+		if installment.Fee.IsPositive() {
+			transaction.Splits = []model.TransactionSplit{
+				{CategoryID: plan.CategoryID, Amount: installment.Principal, Note: "本金"},
+				{CategoryID: feeCategoryID(plan), Amount: installment.Fee, Note: "手续费"},
+			}
+		}
+		scope, err := s.planScope(plan)
+		if err == nil {
+			err = s.transactionService.CreateTransaction(scope, transaction)
+		}
+		if err != nil {
+			if releaseErr := s.installmentDao.ReleaseInstallments(plan.ID, claimedAt); releaseErr != nil {
+				log.Printf("撤销分期还款计划登记失败 PlanID=%d: %v", plan.ID, releaseErr)
+			}
+			return fmt.Errorf("生成第%d期交易失败: %v", installment.Seq, err)
+		}
+
+		posted, err := s.installmentDao.MarkInstallmentPosted(installment.ID, claimedAt, transaction.ID)
+		if err == nil && !posted {
+			// 登记已被提前结清或超时后的其他任务取代，该期不再由本次生成
+			err = errors.New("还款计划登记已失效")
+		}
+		if err != nil {
+			if deleteErr := s.transactionService.DeleteTransaction(scope, transaction.ID); deleteErr != nil {
+				log.Printf("撤销分期交易失败 TransactionID=%d: %v", transaction.ID, deleteErr)
+			}
+			if releaseErr := s.installmentDao.ReleaseInstallments(plan.ID, claimedAt); releaseErr != nil {
+				log.Printf("撤销分期还款计划登记失败 PlanID=%d: %v", plan.ID, releaseErr)
+			}
+			return fmt.Errorf("更新第%d期还款计划失败: %v", installment.Seq, err)
+		}
+	}
+
+	if remaining == 0 {
+		if _, err := s.installmentDao.UpdatePlanStatus(plan.ID, model.InstallmentCompleted, nil); err != nil {
+			return fmt.Errorf("更新分期计划状态失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// createPlanTransaction 以分期计划的记账身份创建交易
+func (s *installmentService) createPlanTransaction(plan *model.InstallmentPlan, transaction *model.Transaction) error {
+	scope, err := s.planScope(plan)
+	if err != nil {
+		return err
+	}
+	return s.transactionService.CreateTransaction(scope, transaction)
+}
+
+// planScope 返回分期计划的记账身份。管理员创建的计划按管理员记账；
+// 否则按消费成员当前的角色记账，适用成员的审批规则和消费限额
+func (s *installmentService) planScope(plan *model.InstallmentPlan) (Scope, error) {
+	creator, err := s.memberDao.GetMemberByID(plan.CreatedBy)
+	if err == nil && creator != nil && creator.FamilyID == plan.FamilyID && creator.Status == 1 && creator.Role == model.RoleAdmin {
+		return Scope{MemberID: creator.ID, FamilyID: plan.FamilyID, Role: creator.Role}, nil
+	}
+
+	member, err := s.memberDao.GetMemberByID(plan.MemberID)
+	if err != nil || member == nil || member.FamilyID != plan.FamilyID || member.Status != 1 {
+		return Scope{}, errors.New("消费成员已不在家庭中")
+	}
+	return Scope{MemberID: member.ID, FamilyID: plan.FamilyID, Role: member.Role}, nil
+}
+
+// claimTime 返回登记时间，截断到数据库保存的毫秒精度，以便按登记时间匹配自己的登记
+func claimTime(t time.Time) time.Time {
+	return t.Truncate(time.Millisecond)
+}
+
+// planTransaction 构造分期计划生成的支出交易
+func (s *installmentService) planTransaction(plan *model.InstallmentPlan, amount decimal.Decimal, transactionTime time.Time, note string) *model.Transaction {
+	categoryID := plan.CategoryID
+	return &model.Transaction{
+		FamilyID:        plan.FamilyID,
+		MemberID:        plan.MemberID,
+		Amount:          amount,
+		Currency:        plan.Currency,
+		Type:            model.Expense,
+		CategoryID:      &categoryID,
+		AccountID:       plan.AccountID,
+		TransactionTime: transactionTime,
+		Note:            note,
+	}
+}
+
+// getPlan 获取属于操作者家庭的分期计划
+func (s *installmentService) getPlan(scope Scope, id uint) (*model.InstallmentPlan, error) {
+	if id == 0 {
+		return nil, errors.New("无效的分期计划ID")
+	}
+
+	plan, err := s.installmentDao.GetPlanByID(id)
+	if err != nil || plan == nil || !scope.owns(plan.FamilyID) {
+		return nil, errors.New("分期计划不存在")
+	}
+	return plan, nil
+}
+
+// checkCategory 检查分类存在且为支出分类
+func (s *installmentService) checkCategory(categoryID uint) error {
+	category, err := s.categoryDao.GetCategoryByID(categoryID)
+	if err != nil || category == nil || category.IsDeleted || category.Type != model.CategoryExpense {
+		return errors.New("分类不存在或不是支出分类")
+	}
+	return nil
+}
+
+// validatePlan 验证分期计划数据
+func (s *installmentService) validatePlan(plan *model.InstallmentPlan) error {
+	plan.Name = strings.TrimSpace(plan.Name)
+	if plan.Name == "" {
+		return errors.New("分期名称不能为空")
+	}
+	if len(plan.Name) > 100 {
+		return errors.New("分期名称长度不能超过100个字符")
+	}
+
+	if !plan.Principal.IsPositive() || !isCents(plan.Principal) {
+		return errors.New("分期本金必须大于0且最多保留两位小数")
+	}
+	if plan.Periods < minInstallmentPeriods || plan.Periods > maxInstallmentPeriods {
+		return fmt.Errorf("分期期数必须在%d到%d之间", minInstallmentPeriods, maxInstallmentPeriods)
+	}
+	if plan.Principal.LessThan(decimal.New(int64(plan.Periods), -2)) {
+		return errors.New("分期本金过小，每期至少0.01")
+	}
+	if plan.FeeRate.IsNegative() || plan.FeeRate.GreaterThan(decimal.NewFromInt(10)) {
+		return errors.New("每期手续费率必须在0到10之间")
+	}
+	if plan.CategoryID == 0 {
+		return errors.New("分期需要指定分类")
+	}
+	if plan.FirstDueDate.IsZero() {
+		return errors.New("首期还款日期不能为空")
+	}
+	plan.FirstDueDate = time.Date(plan.FirstDueDate.Year(), plan.FirstDueDate.Month(), plan.FirstDueDate.Day(), 0, 0, 0, 0, time.Local)
+
+	return nil
+}
+
+// buildInstallments 生成还款计划：本金按期均分，尾差计入最后一期；每期手续费按本金和费率计算；
+// 各期还款日与首期同一天，超过当月天数时为月末
+func buildInstallments(plan *model.InstallmentPlan) []model.Installment {
+	periods := decimal.NewFromInt(int64(plan.Periods))
+	principal := plan.Principal.Div(periods).RoundDown(2)
+	fee := plan.Principal.Mul(plan.FeeRate).Div(decimal.NewFromInt(100)).Round(2)
+	first := plan.FirstDueDate
+
+	installments := make([]model.Installment, 0, plan.Periods)
+	for i := 0; i < plan.Periods; i++ {
+		amount := principal
+		if i == plan.Periods-1 {
+			amount = plan.Principal.Sub(principal.Mul(decimal.NewFromInt(int64(plan.Periods - 1))))
+		}
+		installments = append(installments, model.Installment{
+			Seq:       i + 1,
+			DueDate:   clampedDate(first.Year(), first.Month()+time.Month(i), first.Day()),
+			Principal: amount,
+			Fee:       fee,
+		})
+	}
+	return installments
+}
+
+// buildSchedule 统计分期计划的还款进度，提前结清后没有剩余金额
+func buildSchedule(plan *model.InstallmentPlan) *InstallmentSchedule {
+	schedule := &InstallmentSchedule{
+		InstallmentPlan:    *plan,
+		TotalFee:           decimal.Zero,
+		Paid:               decimal.Zero,
+		RemainingPrincipal: decimal.Zero,
+		RemainingBalance:   decimal.Zero,
+	}
+	for i := range plan.Installments {
+		installment := &plan.Installments[i]
+		schedule.TotalFee = schedule.TotalFee.Add(installment.Fee)
+		if installment.TransactionID != nil {
+			schedule.PostedPeriods++
+			schedule.Paid = schedule.Paid.Add(installment.Principal).Add(installment.Fee)
+			continue
+		}
+		if plan.Status != model.InstallmentActive {
+			// 提前结清时剩余各期已合并为结清交易
+			schedule.Paid = schedule.Paid.Add(installment.Principal).Add(installment.Fee)
+			continue
+		}
+		schedule.RemainingPrincipal = schedule.RemainingPrincipal.Add(installment.Principal)
+		schedule.RemainingBalance = schedule.RemainingBalance.Add(installment.Principal).Add(installment.Fee)
+		if schedule.NextDueDate == nil {
+			dueDate := installment.DueDate
+			schedule.NextDueDate = &dueDate
+		}
+	}
+	return schedule
+}
+
+// feeCategoryID 返回手续费交易的分类
+func feeCategoryID(plan *model.InstallmentPlan) uint {
+	if plan.FeeCategoryID != nil {
+		return *plan.FeeCategoryID
+	}
+	return plan.CategoryID
+}
@@ -0,0 +1,205 @@
+// service/invitation_service.go
+package service
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"github.com/KQLXK/Family-Finance-System/model"
+	"math/big"
+	"strings"
+	"time"
+)
+
+const (
+	invitationCodeLength   = 8
+	invitationCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789" // 去掉易混淆的 0/O、1/I
+	defaultInvitationTTL   = 7 * 24 * time.Hour
+	maxInvitationTTL       = 30 * 24 * time.Hour
+	maxInvitationUses      = 100
+)
+
+// CreateInvitationRequest 创建邀请码请求
+type CreateInvitationRequest struct {
+	Role       model.MemberRole `json:"role"`
+	MaxUses    int              `json:"max_uses"`    // 默认1次
+	ValidHours int              `json:"valid_hours"` // 默认7天
+}
+
+// RedeemInvitationRequest 兑换邀请码请求
+type RedeemInvitationRequest struct {
+	Code     string `json:"code"`
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Phone    string `json:"phone"`
+	Password string `json:"password"`
+}
+
+// InvitationService 邀请码服务接口
+type InvitationService interface {
+	CreateInvitation(scope Scope, familyID uint, request *CreateInvitationRequest) (*model.Invitation, error)
+	GetInvitationsByFamilyID(scope Scope, familyID uint, onlyActive bool) ([]model.Invitation, error)
+	RevokeInvitation(scope Scope, familyID, id uint) error
+	RedeemInvitation(request *RedeemInvitationRequest) (*LoginResult, error)
+}
+
+// invitationService 邀请码服务实现
+type invitationService struct {
+	invitationDao model.InvitationDao
+	memberService MemberService
+}
+
+// NewInvitationService 创建邀请码服务实例
+func NewInvitationService() InvitationService {
+	return &invitationService{
+		invitationDao: *model.NewInvitationDaoInstance(),
+		memberService: NewMemberService(),
+	}
+}
+
+// CreateInvitation 创建邀请码
+func (s *invitationService) CreateInvitation(scope Scope, familyID uint, request *CreateInvitationRequest) (*model.Invitation, error) {
+	if err := scope.checkFamily(familyID); err != nil {
+		return nil, err
+	}
+
+	// 验证角色
+	if request.Role == "" {
+		request.Role = model.RoleMember
+	}
+	switch request.Role {
+	case model.RoleAdmin, model.RoleMember, model.RoleViewer:
+	default:
+		return nil, errors.New("无效的成员角色")
+	}
+
+	// 验证使用次数
+	if request.MaxUses == 0 {
+		request.MaxUses = 1
+	}
+	if request.MaxUses < 0 || request.MaxUses > maxInvitationUses {
+		return nil, fmt.Errorf("使用次数必须在1到%d之间", maxInvitationUses)
+	}
+
+	// 验证有效期
+	ttl := defaultInvitationTTL
+	if request.ValidHours != 0 {
+		ttl = time.Duration(request.ValidHours) * time.Hour
+	}
+	if ttl <= 0 || ttl > maxInvitationTTL {
+		return nil, errors.New("有效期必须在1小时到30天之间")
+	}
+
+	code, err := generateInvitationCode()
+	if err != nil {
+		return nil, err
+	}
+
+	invitation := &model.Invitation{
+		FamilyID:  familyID,
+		Code:      code,
+		Role:      request.Role,
+		MaxUses:   request.MaxUses,
+		ExpiresAt: time.Now().Add(ttl),
+		CreatedBy: scope.MemberID,
+	}
+	if err := s.invitationDao.CreateInvitation(invitation); err != nil {
+		return nil, fmt.Errorf("创建邀请码失败: %v", err)
+	}
+
+	return invitation, nil
+}
+
+// GetInvitationsByFamilyID 获取家庭的邀请码列表
+func (s *invitationService) GetInvitationsByFamilyID(scope Scope, familyID uint, onlyActive bool) ([]model.Invitation, error) {
+	if err := scope.checkFamily(familyID); err != nil {
+		return nil, err
+	}
+
+	invitations, err := s.invitationDao.GetInvitationsByFamilyID(familyID, onlyActive)
+	if err != nil {
+		return nil, fmt.Errorf("获取邀请码列表失败: %v", err)
+	}
+
+	return invitations, nil
+}
+
+// RevokeInvitation 撤销邀请码
+func (s *invitationService) RevokeInvitation(scope Scope, familyID, id uint) error {
+	if err := scope.checkFamily(familyID); err != nil {
+		return err
+	}
+
+	invitation, err := s.invitationDao.GetInvitationByID(id)
+	if err != nil || invitation == nil || invitation.FamilyID != familyID {
+		return errors.New("邀请码不存在")
+	}
+	if invitation.RevokedAt != nil {
+		return errors.New("邀请码已被撤销")
+	}
+
+	if err := s.invitationDao.RevokeInvitation(id); err != nil {
+		return fmt.Errorf("撤销邀请码失败: %v", err)
+	}
+
+	return nil
+}
+
+// RedeemInvitation 兑换邀请码，加入家庭并直接登录
+func (s *invitationService) RedeemInvitation(request *RedeemInvitationRequest) (*LoginResult, error) {
+	code := strings.ToUpper(strings.TrimSpace(request.Code))
+	if code == "" {
+		return nil, errors.New("邀请码不能为空")
+	}
+
+	passwordHash, err := HashPassword(request.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	invitation, err := s.invitationDao.GetInvitationByCode(code)
+	if err != nil || invitation == nil {
+		return nil, errors.New("邀请码无效或已失效")
+	}
+
+	// 先占用使用次数，避免并发兑换超过上限
+	ok, err := s.invitationDao.ClaimInvitation(invitation.ID)
+	if err != nil {
+		return nil, fmt.Errorf("兑换邀请码失败: %v", err)
+	}
+	if !ok {
+		return nil, errors.New("邀请码无效或已失效")
+	}
+
+	member := &model.Member{
+		FamilyID:     invitation.FamilyID,
+		Name:         request.Name,
+		Email:        strings.TrimSpace(request.Email),
+		Phone:        strings.TrimSpace(request.Phone),
+		Role:         invitation.Role,
+		PasswordHash: passwordHash,
+	}
+	if err := s.memberService.CreateMember(Scope{FamilyID: invitation.FamilyID, MemberID: invitation.CreatedBy}, member); err != nil {
+		// 成员创建失败时归还使用次数
+		if releaseErr := s.invitationDao.ReleaseInvitation(invitation.ID); releaseErr != nil {
+			return nil, fmt.Errorf("%v（归还邀请码失败: %v）", err, releaseErr)
+		}
+		return nil, err
+	}
+
+	return issueLoginToken(member)
+}
+
+// generateInvitationCode 生成随机邀请码
+func generateInvitationCode() (string, error) {
+	max := big.NewInt(int64(len(invitationCodeAlphabet)))
+	code := make([]byte, invitationCodeLength)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", errors.New("生成邀请码失败")
+		}
+		code[i] = invitationCodeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
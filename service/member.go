@@ -10,14 +10,15 @@ import (
 
 // MemberService 成员服务接口
 type MemberService interface {
-	CreateMember(member *model.Member) error
-	GetMemberByID(id uint) (*model.Member, error)
-	GetMembersByFamilyID(familyID uint) ([]model.Member, error)
-	GetAllMembers() ([]model.Member, error)
-	UpdateMember(member *model.Member) error
-	DeleteMember(id uint) error
-	ChangeMemberRole(id uint, role model.MemberRole) error
-	GetActiveMembersByFamilyID(familyID uint) ([]model.Member, error)
+	CreateMember(scope Scope, member *model.Member) error
+	GetMemberByID(scope Scope, id uint) (*model.Member, error)
+	GetMembersByFamilyID(scope Scope, familyID uint) ([]model.Member, error)
+	GetAllMembers(scope Scope) ([]model.Member, error)
+	UpdateMember(scope Scope, member *model.Member) error
+	DeleteMember(scope Scope, id uint) error
+	ChangeMemberRole(scope Scope, id uint, role model.MemberRole) error
+	SetRequireApproval(scope Scope, id uint, requireApproval bool) error
+	GetActiveMembersByFamilyID(scope Scope, familyID uint) ([]model.Member, error)
 	MemberExists(id uint) (bool, error)
 }
 
@@ -38,12 +39,22 @@ func NewMemberService() MemberService {
 }
 
 // CreateMember 创建成员
-func (s *memberService) CreateMember(member *model.Member) error {
+func (s *memberService) CreateMember(scope Scope, member *model.Member) error {
 	// 验证成员数据
 	if err := s.validateMember(member); err != nil {
 		return err
 	}
 
+	// 只能向自己所属的家庭添加成员
+	if err := scope.checkFamily(member.FamilyID); err != nil {
+		return err
+	}
+
+	// 设置了密码的成员需要邮箱或手机号作为登录账号
+	if member.PasswordHash != "" && member.Email == "" && member.Phone == "" {
+		return errors.New("设置密码的成员必须提供邮箱或手机号作为登录账号")
+	}
+
 	// 检查家庭是否存在
 	familyExists, err := s.familyService.FamilyExists(member.FamilyID)
 	if err != nil {
@@ -83,11 +94,13 @@ func (s *memberService) CreateMember(member *model.Member) error {
 		return fmt.Errorf("创建成员失败: %v", err)
 	}
 
+	recordAudit(scope, member.FamilyID, EntityMember, member.ID, model.AuditCreate, nil, member)
+
 	return nil
 }
 
 // GetMemberByID 根据ID获取成员
-func (s *memberService) GetMemberByID(id uint) (*model.Member, error) {
+func (s *memberService) GetMemberByID(scope Scope, id uint) (*model.Member, error) {
 	// 验证ID
 	if id == 0 {
 		return nil, errors.New("无效的成员ID")
@@ -99,7 +112,8 @@ func (s *memberService) GetMemberByID(id uint) (*model.Member, error) {
 		return nil, fmt.Errorf("获取成员失败: %v", err)
 	}
 
-	if member == nil {
+	// 其他家庭的成员视为不存在
+	if member == nil || !scope.owns(member.FamilyID) {
 		return nil, errors.New("成员不存在")
 	}
 
@@ -107,12 +121,16 @@ func (s *memberService) GetMemberByID(id uint) (*model.Member, error) {
 }
 
 // GetMembersByFamilyID 根据家庭ID获取成员列表
-func (s *memberService) GetMembersByFamilyID(familyID uint) ([]model.Member, error) {
+func (s *memberService) GetMembersByFamilyID(scope Scope, familyID uint) ([]model.Member, error) {
 	// 验证家庭ID
 	if familyID == 0 {
 		return nil, errors.New("无效的家庭ID")
 	}
 
+	if err := scope.checkFamily(familyID); err != nil {
+		return nil, err
+	}
+
 	// 检查家庭是否存在
 	familyExists, err := s.familyService.FamilyExists(familyID)
 	if err != nil {
@@ -131,10 +149,10 @@ func (s *memberService) GetMembersByFamilyID(familyID uint) ([]model.Member, err
 	return members, nil
 }
 
-// GetAllMembers 获取所有成员
-func (s *memberService) GetAllMembers() ([]model.Member, error) {
+// GetAllMembers 获取操作者所属家庭的所有成员（包括已移除的成员）
+func (s *memberService) GetAllMembers(scope Scope) ([]model.Member, error) {
 	// 获取所有成员
-	members, err := s.memberDao.GetAllMembers()
+	members, err := s.memberDao.GetAllMembersByFamilyID(scope.FamilyID)
 	if err != nil {
 		return nil, fmt.Errorf("获取所有成员失败: %v", err)
 	}
@@ -143,24 +161,23 @@ func (s *memberService) GetAllMembers() ([]model.Member, error) {
 }
 
 // UpdateMember 更新成员信息
-func (s *memberService) UpdateMember(member *model.Member) error {
+func (s *memberService) UpdateMember(scope Scope, member *model.Member) error {
 	// 验证成员ID
 	if member.ID == 0 {
 		return errors.New("无效的成员ID")
 	}
 
-	// 验证成员数据
-	if err := s.validateMember(member); err != nil {
+	// 检查成员是否存在且属于操作者所属家庭
+	existing, err := s.GetMemberByID(scope, member.ID)
+	if err != nil {
 		return err
 	}
+	member.FamilyID = existing.FamilyID
+	member.Role = existing.Role
 
-	// 检查成员是否存在
-	exists, err := s.MemberExists(member.ID)
-	if err != nil {
-		return fmt.Errorf("检查成员是否存在时出错: %v", err)
-	}
-	if !exists {
-		return errors.New("成员不存在")
+	// 验证成员数据
+	if err := s.validateMember(member); err != nil {
+		return err
 	}
 
 	// 检查邮箱是否已被其他成员使用
@@ -190,23 +207,21 @@ func (s *memberService) UpdateMember(member *model.Member) error {
 		return fmt.Errorf("更新成员信息失败: %v", err)
 	}
 
+	updated := *existing
+	updated.Name = member.Name
+	updated.Phone = member.Phone
+	updated.Email = member.Email
+	recordAudit(scope, existing.FamilyID, EntityMember, existing.ID, model.AuditUpdate, existing, &updated)
+
 	return nil
 }
 
 // DeleteMember 删除成员（软删除）
-func (s *memberService) DeleteMember(id uint) error {
-	// 验证ID
-	if id == 0 {
-		return errors.New("无效的成员ID")
-	}
-
-	// 检查成员是否存在
-	exists, err := s.MemberExists(id)
+func (s *memberService) DeleteMember(scope Scope, id uint) error {
+	// 检查成员是否存在且属于操作者所属家庭
+	existing, err := s.GetMemberByID(scope, id)
 	if err != nil {
-		return fmt.Errorf("检查成员是否存在时出错: %v", err)
-	}
-	if !exists {
-		return errors.New("成员不存在")
+		return err
 	}
 
 	// 软删除成员（设置状态为0）
@@ -214,28 +229,22 @@ func (s *memberService) DeleteMember(id uint) error {
 		return fmt.Errorf("删除成员失败: %v", err)
 	}
 
+	recordAudit(scope, existing.FamilyID, EntityMember, id, model.AuditDelete, existing, nil)
+
 	return nil
 }
 
 // ChangeMemberRole 更改成员角色
-func (s *memberService) ChangeMemberRole(id uint, role model.MemberRole) error {
-	// 验证ID
-	if id == 0 {
-		return errors.New("无效的成员ID")
-	}
-
+func (s *memberService) ChangeMemberRole(scope Scope, id uint, role model.MemberRole) error {
 	// 验证角色
 	if !s.isValidRole(role) {
 		return errors.New("无效的成员角色")
 	}
 
-	// 检查成员是否存在
-	exists, err := s.MemberExists(id)
+	// 检查成员是否存在且属于操作者所属家庭
+	existing, err := s.GetMemberByID(scope, id)
 	if err != nil {
-		return fmt.Errorf("检查成员是否存在时出错: %v", err)
-	}
-	if !exists {
-		return errors.New("成员不存在")
+		return err
 	}
 
 	// 更新成员角色
@@ -243,16 +252,43 @@ func (s *memberService) ChangeMemberRole(id uint, role model.MemberRole) error {
 		return fmt.Errorf("更改成员角色失败: %v", err)
 	}
 
+	updated := *existing
+	updated.Role = role
+	recordAudit(scope, existing.FamilyID, EntityMember, id, model.AuditUpdate, existing, &updated)
+
+	return nil
+}
+
+// SetRequireApproval 设置成员记录的交易是否需要审批
+func (s *memberService) SetRequireApproval(scope Scope, id uint, requireApproval bool) error {
+	// 检查成员是否存在且属于操作者所属家庭
+	existing, err := s.GetMemberByID(scope, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.memberDao.UpdateRequireApproval(id, requireApproval); err != nil {
+		return fmt.Errorf("更新成员审批设置失败: %v", err)
+	}
+
+	updated := *existing
+	updated.RequireApproval = requireApproval
+	recordAudit(scope, existing.FamilyID, EntityMember, id, model.AuditUpdate, existing, &updated)
+
 	return nil
 }
 
 // GetActiveMembersByFamilyID 根据家庭ID获取活跃成员列表
-func (s *memberService) GetActiveMembersByFamilyID(familyID uint) ([]model.Member, error) {
+func (s *memberService) GetActiveMembersByFamilyID(scope Scope, familyID uint) ([]model.Member, error) {
 	// 验证家庭ID
 	if familyID == 0 {
 		return nil, errors.New("无效的家庭ID")
 	}
 
+	if err := scope.checkFamily(familyID); err != nil {
+		return nil, err
+	}
+
 	// 检查家庭是否存在
 	familyExists, err := s.familyService.FamilyExists(familyID)
 	if err != nil {
@@ -0,0 +1,419 @@
+// service/notification_service.go
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/KQLXK/Family-Finance-System/model"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ChannelRequest 创建或修改通知渠道请求
+type ChannelRequest struct {
+	Name     string            `json:"name"`
+	Type     model.ChannelType `json:"type"` // 创建后不能修改
+	URL      string            `json:"url"`
+	Secret   *string           `json:"secret"`    // 修改时为空表示不修改，空字符串表示取消签名
+	IsActive *bool             `json:"is_active"` // 新建的渠道总是启用
+}
+
+// NotificationMessage 发送到通知渠道的消息，webhook 以该结构的 JSON 作为请求体
+type NotificationMessage struct {
+	FamilyID   uint      `json:"family_id"`
+	Title      string    `json:"title"`
+	Content    string    `json:"content"`
+	EntityType string    `json:"entity_type"`
+	EntityID   uint      `json:"entity_id"`
+	SentAt     time.Time `json:"sent_at"`
+}
+
+// NotificationService 通知渠道服务接口
+type NotificationService interface {
+	CreateChannel(scope Scope, familyID uint, request *ChannelRequest) (*model.NotificationChannel, error)
+	GetChannels(scope Scope, familyID uint) ([]model.NotificationChannel, error)
+	UpdateChannel(scope Scope, id uint, request *ChannelRequest) (*model.NotificationChannel, error)
+	DeleteChannel(scope Scope, id uint) error
+	TestChannel(scope Scope, id uint) error
+	GetNotifications(scope Scope, familyID uint, page, pageSize int) ([]model.Notification, int64, error)
+}
+
+// notificationService 通知渠道服务实现
+type notificationService struct {
+	notificationDao model.NotificationDao
+}
+
+// NewNotificationService 创建通知渠道服务实例
+func NewNotificationService() NotificationService {
+	return &notificationService{
+		notificationDao: *model.NewNotificationDaoInstance(),
+	}
+}
+
+// CreateChannel 创建并启用通知渠道，每个家庭只能有一个站内通知渠道
+func (s *notificationService) CreateChannel(scope Scope, familyID uint, request *ChannelRequest) (*model.NotificationChannel, error) {
+	if familyID == 0 {
+		return nil, errors.New("无效的家庭ID")
+	}
+
+	if err := scope.checkFamily(familyID); err != nil {
+		return nil, err
+	}
+
+	if _, ok := notifiers[request.Type]; !ok {
+		return nil, errors.New("无效的通知渠道类型，支持: in_app, webhook")
+	}
+
+	channel := &model.NotificationChannel{
+		FamilyID:  familyID,
+		Type:      request.Type,
+		CreatedBy: scope.MemberID,
+	}
+	applyChannelRequest(channel, request)
+	channel.IsActive = true
+	if err := validateChannel(channel); err != nil {
+		return nil, err
+	}
+
+	if channel.Type == model.ChannelInApp {
+		channels, err := s.notificationDao.GetChannelsByFamilyID(familyID, false)
+		if err != nil {
+			return nil, fmt.Errorf("检查通知渠道是否存在时出错: %v", err)
+		}
+		for _, existing := range channels {
+			if existing.Type == model.ChannelInApp {
+				return nil, errors.New("家庭已有站内通知渠道")
+			}
+		}
+	}
+
+	if err := s.notificationDao.CreateChannel(channel); err != nil {
+		return nil, fmt.Errorf("创建通知渠道失败: %v", err)
+	}
+
+	recordAudit(scope, familyID, EntityChannel, channel.ID, model.AuditCreate, nil, channel)
+
+	return channel, nil
+}
+
+// GetChannels 获取家庭的通知渠道列表
+func (s *notificationService) GetChannels(scope Scope, familyID uint) ([]model.NotificationChannel, error) {
+	if familyID == 0 {
+		return nil, errors.New("无效的家庭ID")
+	}
+
+	if err := scope.checkFamily(familyID); err != nil {
+		return nil, err
+	}
+
+	channels, err := s.notificationDao.GetChannelsByFamilyID(familyID, false)
+	if err != nil {
+		return nil, fmt.Errorf("获取通知渠道失败: %v", err)
+	}
+
+	return channels, nil
+}
+
+// UpdateChannel 修改通知渠道的名称、地址、密钥和启用状态
+func (s *notificationService) UpdateChannel(scope Scope, id uint, request *ChannelRequest) (*model.NotificationChannel, error) {
+	existing, err := s.getChannel(scope, id)
+	if err != nil {
+		return nil, err
+	}
+	if request.Type != "" && request.Type != existing.Type {
+		return nil, errors.New("通知渠道类型不能修改")
+	}
+
+	channel := *existing
+	applyChannelRequest(&channel, request)
+	if err := validateChannel(&channel); err != nil {
+		return nil, err
+	}
+
+	channel.UpdatedAt = time.Now()
+	if err := s.notificationDao.UpdateChannel(&channel); err != nil {
+		return nil, fmt.Errorf("更新通知渠道失败: %v", err)
+	}
+
+	recordAudit(scope, channel.FamilyID, EntityChannel, channel.ID, model.AuditUpdate, existing, &channel)
+
+	return &channel, nil
+}
+
+// DeleteChannel 删除通知渠道
+func (s *notificationService) DeleteChannel(scope Scope, id uint) error {
+	existing, err := s.getChannel(scope, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.notificationDao.DeleteChannel(id); err != nil {
+		return fmt.Errorf("删除通知渠道失败: %v", err)
+	}
+
+	recordAudit(scope, existing.FamilyID, EntityChannel, id, model.AuditDelete, existing, nil)
+
+	return nil
+}
+
+// TestChannel 向通知渠道发送一条测试消息，停用的渠道也可以测试
+func (s *notificationService) TestChannel(scope Scope, id uint) error {
+	channel, err := s.getChannel(scope, id)
+	if err != nil {
+		return err
+	}
+
+	message := &NotificationMessage{
+		FamilyID:   channel.FamilyID,
+		Title:      "测试通知",
+		Content:    fmt.Sprintf("通知渠道「%s」配置成功", channel.Name),
+		EntityType: EntityChannel,
+		EntityID:   channel.ID,
+		SentAt:     time.Now(),
+	}
+	if err := notifiers[channel.Type].send(channel, message); err != nil {
+		return fmt.Errorf("发送测试通知失败: %v", err)
+	}
+	return nil
+}
+
+// GetNotifications 分页获取家庭的站内通知
+func (s *notificationService) GetNotifications(scope Scope, familyID uint, page, pageSize int) ([]model.Notification, int64, error) {
+	if familyID == 0 {
+		return nil, 0, errors.New("无效的家庭ID")
+	}
+
+	if err := scope.checkFamily(familyID); err != nil {
+		return nil, 0, err
+	}
+
+	notifications, total, err := s.notificationDao.GetNotificationsByFamilyID(familyID, page, pageSize)
+	if err != nil {
+		return nil, 0, fmt.Errorf("获取站内通知失败: %v", err)
+	}
+
+	return notifications, total, nil
+}
+
+// getChannel 获取属于操作者家庭的通知渠道
+func (s *notificationService) getChannel(scope Scope, id uint) (*model.NotificationChannel, error) {
+	if id == 0 {
+		return nil, errors.New("无效的通知渠道ID")
+	}
+
+	channel, err := s.notificationDao.GetChannelByID(id)
+	if err != nil || channel == nil || !scope.owns(channel.FamilyID) {
+		return nil, errors.New("通知渠道不存在")
+	}
+	return channel, nil
+}
+
+// applyChannelRequest 将请求中的字段写入通知渠道
+func applyChannelRequest(channel *model.NotificationChannel, request *ChannelRequest) {
+	channel.Name = strings.TrimSpace(request.Name)
+	channel.URL = strings.TrimSpace(request.URL)
+	if request.Secret != nil {
+		channel.Secret = *request.Secret
+	}
+	if request.IsActive != nil {
+		channel.IsActive = *request.IsActive
+	}
+}
+
+// validateChannel 验证通知渠道数据，webhook 必须提供 http 或 https 地址
+func validateChannel(channel *model.NotificationChannel) error {
+	if channel.Name == "" {
+		return errors.New("通知渠道名称不能为空")
+	}
+	if len([]rune(channel.Name)) > 100 {
+		return errors.New("通知渠道名称不能超过100个字符")
+	}
+	if len(channel.Secret) > 100 {
+		return errors.New("签名密钥不能超过100个字符")
+	}
+
+	switch channel.Type {
+	case model.ChannelInApp:
+		channel.URL = ""
+		channel.Secret = ""
+	case model.ChannelWebhook:
+		if channel.URL == "" {
+			return errors.New("webhook 通知渠道需要提供推送地址")
+		}
+		if len(channel.URL) > 500 {
+			return errors.New("推送地址不能超过500个字符")
+		}
+		parsed, err := url.Parse(channel.URL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+			return errors.New("推送地址必须是有效的 http 或 https 地址")
+		}
+		if parsed.User != nil {
+			return errors.New("推送地址不能包含用户名和密码")
+		}
+		if err := checkWebhookHost(parsed.Hostname()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkWebhookHost 解析推送地址的主机名，不允许指向回环、内网、链路本地等非公网地址
+func checkWebhookHost(host string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil || len(addrs) == 0 {
+		return errors.New("无法解析推送地址的主机名")
+	}
+	for _, addr := range addrs {
+		if !isPublicIP(addr.IP) {
+			return errors.New("推送地址不能指向内网或本机地址")
+		}
+	}
+	return nil
+}
+
+// sharedAddressSpace 运营商级 NAT 地址段 100.64.0.0/10
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// isPublicIP 判断是否为公网单播地址
+func isPublicIP(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+		if ip4[0] == 0 || sharedAddressSpace.Contains(ip4) {
+			return false
+		}
+	}
+	return ip.IsGlobalUnicast() && !ip.IsPrivate() && !ip.IsLoopback() && !ip.IsLinkLocalUnicast()
+}
+
+// notifier 通知渠道的发送方式，新增渠道类型时在 notifiers 中注册
+type notifier interface {
+	send(channel *model.NotificationChannel, message *NotificationMessage) error
+}
+
+// notifiers 各类型通知渠道的发送方式
+var notifiers = map[model.ChannelType]notifier{
+	model.ChannelInApp:   inAppNotifier{},
+	model.ChannelWebhook: webhookNotifier{client: newWebhookClient()},
+}
+
+// newWebhookClient 创建推送 webhook 的 HTTP 客户端：不使用代理、不跟随重定向，
+// 建立连接时再次检查实际连接的地址，防止域名解析变化后指向内网
+func newWebhookClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+				return errors.New("推送地址不能指向内网或本机地址")
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 5 * time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// inAppNotifier 站内通知，保存到通知表
+type inAppNotifier struct{}
+
+func (inAppNotifier) send(channel *model.NotificationChannel, message *NotificationMessage) error {
+	return model.NewNotificationDaoInstance().CreateNotification(&model.Notification{
+		FamilyID:   channel.FamilyID,
+		Title:      truncateRunes(message.Title, 200),
+		Content:    message.Content,
+		EntityType: message.EntityType,
+		EntityID:   message.EntityID,
+	})
+}
+
+// webhookNotifier 以 JSON 推送到渠道地址，配置了密钥时在 X-Signature 请求头附带请求体的 HMAC-SHA256（十六进制）
+type webhookNotifier struct {
+	client *http.Client
+}
+
+func (n webhookNotifier) send(channel *model.NotificationChannel, message *NotificationMessage) error {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, channel.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if channel.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(channel.Secret))
+		mac.Write(body)
+		req.Header.Set("X-Signature", hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("推送地址返回状态码 %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// notifyFamily 将消息发送到家庭所有启用的通知渠道，家庭未配置渠道时发送站内通知。
+// 某个渠道失败不影响其他渠道，全部渠道失败时返回错误
+func notifyFamily(familyID uint, message *NotificationMessage) error {
+	channels, err := model.NewNotificationDaoInstance().GetChannelsByFamilyID(familyID, true)
+	if err != nil {
+		return fmt.Errorf("获取通知渠道失败: %v", err)
+	}
+	if len(channels) == 0 {
+		channels = []model.NotificationChannel{{FamilyID: familyID, Type: model.ChannelInApp, Name: "站内通知"}}
+	}
+
+	message.FamilyID = familyID
+	message.SentAt = time.Now()
+
+	var lastErr error
+	sent := 0
+	for i := range channels {
+		sender, ok := notifiers[channels[i].Type]
+		if !ok {
+			continue
+		}
+		if err := sender.send(&channels[i], message); err != nil {
+			log.Printf("发送通知失败 ChannelID=%d: %v", channels[i].ID, err)
+			lastErr = err
+			continue
+		}
+		sent++
+	}
+	if sent == 0 && lastErr != nil {
+		return fmt.Errorf("通知发送失败: %v", lastErr)
+	}
+	return nil
+}
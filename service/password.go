@@ -0,0 +1,57 @@
+// service/password.go
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/bcrypt"
+	"unicode/utf8"
+)
+
+const (
+	minPasswordLength = 8
+	maxPasswordLength = 72 // bcrypt 最多处理72字节
+)
+
+// HashPassword 校验密码强度并生成 bcrypt 哈希
+func HashPassword(password string) (string, error) {
+	if utf8.RuneCountInString(password) < minPasswordLength {
+		return "", fmt.Errorf("密码长度不能少于%d个字符", minPasswordLength)
+	}
+	if len(password) > maxPasswordLength {
+		return "", fmt.Errorf("密码长度不能超过%d个字节", maxPasswordLength)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("生成密码哈希失败: %v", err)
+	}
+	return string(hash), nil
+}
+
+// checkPassword 校验密码是否与哈希匹配
+func checkPassword(passwordHash, password string) bool {
+	if passwordHash == "" {
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(password)) == nil
+}
+
+// generateSecret 生成随机令牌，返回明文及其 SHA-256 哈希（数据库只保存哈希）
+func generateSecret(prefix string, size int) (string, string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", errors.New("生成随机令牌失败")
+	}
+	secret := prefix + hex.EncodeToString(buf)
+	return secret, hashSecret(secret), nil
+}
+
+// hashSecret 计算令牌的 SHA-256 哈希
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
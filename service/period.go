@@ -0,0 +1,33 @@
+// service/period.go
+package service
+
+import (
+	"github.com/KQLXK/Family-Finance-System/model"
+	"time"
+)
+
+// isValidPeriod 验证周期类型是否有效
+func isValidPeriod(period model.Period) bool {
+	switch period {
+	case model.PeriodWeekly, model.PeriodMonthly, model.PeriodYearly:
+		return true
+	default:
+		return false
+	}
+}
+
+// periodRange 返回 t 所在周期的起止时间 [start, end)，周从周一开始
+func periodRange(period model.Period, t time.Time) (time.Time, time.Time) {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	switch period {
+	case model.PeriodWeekly:
+		start := day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+		return start, start.AddDate(0, 0, 7)
+	case model.PeriodYearly:
+		start := time.Date(t.Year(), 1, 1, 0, 0, 0, 0, t.Location())
+		return start, start.AddDate(1, 0, 0)
+	default:
+		start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+		return start, start.AddDate(0, 1, 0)
+	}
+}
@@ -0,0 +1,587 @@
+// service/recurring_service.go
+package service
+
+import (
+	"errors"
+	"fmt"
+	"github.com/KQLXK/Family-Finance-System/model"
+	"log"
+	"strings"
+	"time"
+)
+
+// occurrenceClaimTimeout 登记后超过该时间仍未生成交易的记录视为登记的任务已中断，可重新登记
+const occurrenceClaimTimeout = 30 * time.Minute
+
+// RecurringService 周期交易服务接口
+type RecurringService interface {
+	CreateTemplate(scope Scope, template *model.RecurringTemplate) error
+	GetTemplateByID(scope Scope, id uint) (*model.RecurringTemplate, error)
+	GetTemplatesByFamilyID(scope Scope, familyID uint) ([]model.RecurringTemplate, error)
+	UpdateTemplate(scope Scope, template *model.RecurringTemplate) error
+	DeleteTemplate(scope Scope, id uint) error
+	PauseTemplate(scope Scope, id uint) error
+	ResumeTemplate(scope Scope, id uint) error
+	GetOccurrences(scope Scope, id uint) ([]model.RecurringOccurrence, error)
+	RunDueTemplates(now time.Time) error
+}
+
+// recurringService 周期交易服务实现
+type recurringService struct {
+	recurringDao       model.RecurringDao
+	familyDao          model.FamilyDao
+	memberDao          model.MemberDao
+	categoryDao        model.CategoryDao
+	accountDao         model.AccountDao
+	transactionService TransactionService
+}
+
+// NewRecurringService 创建周期交易服务实例
+func NewRecurringService() RecurringService {
+	return &recurringService{
+		recurringDao:       *model.NewRecurringDaoInstance(),
+		familyDao:          *model.NewFamilyDaoInstance(),
+		memberDao:          *model.NewMemberDaoInstance(),
+		categoryDao:        *model.NewCategoryDaoInstance(),
+		accountDao:         *model.NewAccountDaoInstance(),
+		transactionService: NewTransactionService(),
+	}
+}
+
+// CreateTemplate 创建周期交易模板，并为开始日期至今已到期的日期生成交易
+func (s *recurringService) CreateTemplate(scope Scope, template *model.RecurringTemplate) error {
+	if err := s.validateTemplate(template); err != nil {
+		return err
+	}
+	// 创建时补生成的日期最多追溯一年
+	if template.StartDate.Before(today(time.Now()).AddDate(-1, 0, 0)) {
+		return errors.New("开始日期不能早于一年前")
+	}
+
+	if err := scope.checkFamily(template.FamilyID); err != nil {
+		return err
+	}
+
+	family, err := s.familyDao.GetFamilyByID(template.FamilyID)
+	if err != nil || family == nil {
+		return errors.New("关联的家庭不存在")
+	}
+
+	if err := s.checkReferences(scope, template); err != nil {
+		return err
+	}
+
+	template.ID = 0
+	template.IsActive = true
+	template.NextRunDate = nextRunDate(template, template.StartDate)
+	template.CreatedBy = scope.MemberID
+	if err := s.recurringDao.CreateTemplate(template); err != nil {
+		return fmt.Errorf("创建周期交易失败: %v", err)
+	}
+
+	recordAudit(scope, template.FamilyID, EntityRecurring, template.ID, model.AuditCreate, nil, template)
+
+	s.runNow(template.ID)
+	return nil
+}
+
+// GetTemplateByID 根据ID获取周期交易模板
+func (s *recurringService) GetTemplateByID(scope Scope, id uint) (*model.RecurringTemplate, error) {
+	if id == 0 {
+		return nil, errors.New("无效的周期交易ID")
+	}
+
+	template, err := s.recurringDao.GetTemplateByID(id)
+	if err != nil || template == nil || !scope.owns(template.FamilyID) {
+		return nil, errors.New("周期交易不存在")
+	}
+
+	return template, nil
+}
+
+// GetTemplatesByFamilyID 获取家庭的周期交易模板
+func (s *recurringService) GetTemplatesByFamilyID(scope Scope, familyID uint) ([]model.RecurringTemplate, error) {
+	if familyID == 0 {
+		return nil, errors.New("无效的家庭ID")
+	}
+
+	if err := scope.checkFamily(familyID); err != nil {
+		return nil, err
+	}
+
+	templates, err := s.recurringDao.GetTemplatesByFamilyID(familyID)
+	if err != nil {
+		return nil, fmt.Errorf("获取周期交易失败: %v", err)
+	}
+
+	return templates, nil
+}
+
+// UpdateTemplate 更新周期交易模板，修改计划后从原下一次生成日期起按新计划生成，已生成的交易不受影响
+func (s *recurringService) UpdateTemplate(scope Scope, template *model.RecurringTemplate) error {
+	existing, err := s.getOwnTemplate(scope, template.ID)
+	if err != nil {
+		return err
+	}
+	template.FamilyID = existing.FamilyID
+	template.IsActive = existing.IsActive
+	template.CreatedBy = existing.CreatedBy
+	template.CreatedAt = existing.CreatedAt
+
+	if err := s.validateTemplate(template); err != nil {
+		return err
+	}
+	if err := s.checkReferences(scope, template); err != nil {
+		return err
+	}
+
+	from := today(time.Now())
+	if existing.NextRunDate != nil {
+		from = *existing.NextRunDate
+	}
+	template.NextRunDate = nextRunDate(template, from)
+
+	template.UpdatedAt = time.Now()
+	if err := s.recurringDao.UpdateTemplate(template); err != nil {
+		return fmt.Errorf("更新周期交易失败: %v", err)
+	}
+
+	recordAudit(scope, template.FamilyID, EntityRecurring, template.ID, model.AuditUpdate, existing, template)
+
+	s.runNow(template.ID)
+	return nil
+}
+
+// DeleteTemplate 删除周期交易模板，已生成的交易保留
+func (s *recurringService) DeleteTemplate(scope Scope, id uint) error {
+	existing, err := s.getOwnTemplate(scope, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.recurringDao.DeleteTemplate(id); err != nil {
+		return fmt.Errorf("删除周期交易失败: %v", err)
+	}
+
+	recordAudit(scope, existing.FamilyID, EntityRecurring, id, model.AuditDelete, existing, nil)
+
+	return nil
+}
+
+// PauseTemplate 暂停周期交易
+func (s *recurringService) PauseTemplate(scope Scope, id uint) error {
+	existing, err := s.getOwnTemplate(scope, id)
+	if err != nil {
+		return err
+	}
+	if !existing.IsActive {
+		return errors.New("周期交易已暂停")
+	}
+
+	template := *existing
+	template.IsActive = false
+	template.UpdatedAt = time.Now()
+	if err := s.recurringDao.UpdateTemplate(&template); err != nil {
+		return fmt.Errorf("暂停周期交易失败: %v", err)
+	}
+
+	recordAudit(scope, template.FamilyID, EntityRecurring, id, model.AuditUpdate,
+		map[string]interface{}{"is_active": true}, map[string]interface{}{"is_active": false})
+
+	return nil
+}
+
+// ResumeTemplate 恢复周期交易，暂停期间错过的日期不再补生成
+func (s *recurringService) ResumeTemplate(scope Scope, id uint) error {
+	existing, err := s.getOwnTemplate(scope, id)
+	if err != nil {
+		return err
+	}
+	if existing.IsActive {
+		return errors.New("周期交易未暂停")
+	}
+
+	template := *existing
+	template.IsActive = true
+	template.NextRunDate = nextRunDate(&template, today(time.Now()))
+	template.UpdatedAt = time.Now()
+	if err := s.recurringDao.UpdateTemplate(&template); err != nil {
+		return fmt.Errorf("恢复周期交易失败: %v", err)
+	}
+
+	recordAudit(scope, template.FamilyID, EntityRecurring, id, model.AuditUpdate,
+		map[string]interface{}{"is_active": false}, map[string]interface{}{"is_active": true, "next_run_date": template.NextRunDate})
+
+	s.runNow(id)
+	return nil
+}
+
+// GetOccurrences 获取周期交易的生成记录
+func (s *recurringService) GetOccurrences(scope Scope, id uint) ([]model.RecurringOccurrence, error) {
+	template, err := s.GetTemplateByID(scope, id)
+	if err != nil {
+		return nil, err
+	}
+
+	occurrences, err := s.recurringDao.GetOccurrencesByTemplateID(template.ID)
+	if err != nil {
+		return nil, fmt.Errorf("获取生成记录失败: %v", err)
+	}
+
+	return occurrences, nil
+}
+
+// RunDueTemplates 为所有到期的周期交易生成交易，供定时任务调用；停机期间错过的日期会依次补生成
+func (s *recurringService) RunDueTemplates(now time.Time) error {
+	ids, err := s.recurringDao.GetDueTemplateIDs(now)
+	if err != nil {
+		return fmt.Errorf("获取到期周期交易失败: %v", err)
+	}
+
+	for _, id := range ids {
+		if err := s.runTemplate(id, now); err != nil {
+			log.Printf("生成周期交易失败 TemplateID=%d: %v", id, err)
+		}
+	}
+	return nil
+}
+
+// runTemplate 依次为模板不晚于今天的日期生成交易。每个日期先登记生成记录再创建交易，
+// 已登记的日期不会重复生成；创建交易失败时记录原因并停在该日期，下次运行时重试
+func (s *recurringService) runTemplate(id uint, now time.Time) error {
+	template, err := s.recurringDao.GetTemplateByID(id)
+	if err != nil {
+		return fmt.Errorf("获取周期交易失败: %v", err)
+	}
+	if !template.IsActive || template.NextRunDate == nil {
+		return nil
+	}
+
+	// 以创建者身份记账，适用创建者的审批规则和消费限额；创建者已离开家庭或只读时暂停模板
+	creator, err := s.memberDao.GetMemberByID(template.CreatedBy)
+	if err != nil || creator == nil || creator.FamilyID != template.FamilyID || creator.Status != 1 || creator.Role == model.RoleViewer {
+		template.IsActive = false
+		if err := s.recurringDao.UpdateTemplate(template); err != nil {
+			return fmt.Errorf("暂停周期交易失败: %v", err)
+		}
+		return errors.New("创建者已不能记账，周期交易已暂停")
+	}
+	scope := Scope{MemberID: creator.ID, FamilyID: template.FamilyID, Role: creator.Role}
+
+	claimedAt := claimTime(now)
+	staleBefore := now.Add(-occurrenceClaimTimeout)
+	end := today(now)
+	for template.NextRunDate != nil && !template.NextRunDate.After(end) {
+		date := *template.NextRunDate
+
+		// 已登记但未生成交易的日期，生成失败或登记超时的重新登记后重试，正由其他任务生成的留给该任务
+		occurrence := &model.RecurringOccurrence{TemplateID: template.ID, OccurrenceDate: date, ClaimedAt: &claimedAt}
+		claimed, err := s.recurringDao.ReserveOccurrence(occurrence)
+		if err != nil {
+			return fmt.Errorf("登记生成记录失败: %v", err)
+		}
+		if !claimed {
+			if occurrence, err = s.recurringDao.GetOccurrence(template.ID, date); err != nil {
+				return fmt.Errorf("获取生成记录失败: %v", err)
+			}
+			if occurrence.TransactionID == nil {
+				if claimed, err = s.recurringDao.ReclaimOccurrence(occurrence.ID, claimedAt, staleBefore); err != nil {
+					return fmt.Errorf("登记生成记录失败: %v", err)
+				}
+				if !claimed {
+					return nil
+				}
+			}
+		}
+		if claimed {
+			if err := s.postOccurrence(scope, template, occurrence.ID, date, claimedAt); err != nil {
+				return err
+			}
+		}
+
+		// 只有已生成交易的日期才推进下一次生成日期
+		next := followingRunDate(template, date)
+		advanced, err := s.recurringDao.AdvanceNextRun(template.ID, date, next)
+		if err != nil {
+			return fmt.Errorf("更新下一次生成日期失败: %v", err)
+		}
+		if !advanced {
+			// 模板已被修改或由其他任务推进
+			return nil
+		}
+		template.NextRunDate = next
+	}
+	return nil
+}
+
+// postOccurrence 为已登记的日期生成交易。生成失败时记录失败原因，下次运行时重试；
+// 登记已被超时后的其他任务取代时撤销生成的交易
+func (s *recurringService) postOccurrence(scope Scope, template *model.RecurringTemplate, occurrenceID uint, date time.Time, claimedAt time.Time) error {
+	transaction := s.templateTransaction(template, date)
+	if err := s.transactionService.CreateTransaction(scope, transaction); err != nil {
+		if _, finishErr := s.recurringDao.FinishOccurrence(occurrenceID, claimedAt, nil, truncateRunes(err.Error(), 500)); finishErr != nil {
+			log.Printf("更新生成记录失败 OccurrenceID=%d: %v", occurrenceID, finishErr)
+		}
+		return fmt.Errorf("生成%s的周期交易失败: %v", date.Format("2006-01-02"), err)
+	}
+
+	finished, err := s.recurringDao.FinishOccurrence(occurrenceID, claimedAt, &transaction.ID, "")
+	if err == nil && !finished {
+		err = errors.New("生成记录登记已失效")
+	}
+	if err != nil {
+		if deleteErr := s.transactionService.DeleteTransaction(scope, transaction.ID); deleteErr != nil {
+			log.Printf("撤销周期交易失败 TransactionID=%d: %v", transaction.ID, deleteErr)
+		}
+		return fmt.Errorf("更新生成记录失败: %v", err)
+	}
+	return nil
+}
+
+// runNow 创建、修改或恢复模板后立即生成已到期的交易，失败时由定时任务重试
+func (s *recurringService) runNow(id uint) {
+	if err := s.runTemplate(id, time.Now()); err != nil {
+		log.Printf("生成周期交易失败 TemplateID=%d: %v", id, err)
+	}
+}
+
+// templateTransaction 按模板构造某个日期的交易
+func (s *recurringService) templateTransaction(template *model.RecurringTemplate, date time.Time) *model.Transaction {
+	note := template.Note
+	if note == "" {
+		note = template.Name
+	}
+	return &model.Transaction{
+		FamilyID:        template.FamilyID,
+		MemberID:        template.MemberID,
+		Amount:          template.Amount,
+		Currency:        template.Currency,
+		Type:            template.Type,
+		CategoryID:      template.CategoryID,
+		AccountID:       template.AccountID,
+		ToAccountID:     template.ToAccountID,
+		Fee:             template.Fee,
+		PaymentMethod:   template.PaymentMethod,
+		TransactionTime: date,
+		Note:            note,
+	}
+}
+
+// getOwnTemplate 获取操作者可修改的模板，普通成员只能修改自己创建的模板
+func (s *recurringService) getOwnTemplate(scope Scope, id uint) (*model.RecurringTemplate, error) {
+	template, err := s.GetTemplateByID(scope, id)
+	if err != nil {
+		return nil, err
+	}
+	if scope.Role != model.RoleAdmin && template.CreatedBy != scope.MemberID {
+		return nil, errors.New("只能修改自己创建的周期交易")
+	}
+	return template, nil
+}
+
+// checkReferences 检查模板的成员、分类和账户，普通成员只能为自己创建周期交易
+func (s *recurringService) checkReferences(scope Scope, template *model.RecurringTemplate) error {
+	if template.MemberID == 0 {
+		template.MemberID = scope.MemberID
+	}
+	if scope.Role != model.RoleAdmin && template.MemberID != scope.MemberID {
+		return errors.New("只能为自己创建周期交易")
+	}
+	member, err := s.memberDao.GetMemberByID(template.MemberID)
+	if err != nil || member == nil || member.FamilyID != template.FamilyID || member.Status != 1 {
+		return errors.New("成员不存在或不属于该家庭")
+	}
+
+	if template.CategoryID != nil {
+		category, err := s.categoryDao.GetCategoryByID(*template.CategoryID)
+		if err != nil || category == nil || category.IsDeleted || string(category.Type) != string(template.Type) {
+			return errors.New("分类不存在或类型不匹配")
+		}
+	}
+
+	for _, accountID := range []*uint{template.AccountID, template.ToAccountID} {
+		if accountID == nil {
+			continue
+		}
+		account, err := s.accountDao.GetAccountByID(*accountID)
+		if err != nil || account == nil || account.FamilyID != template.FamilyID {
+			return errors.New("账户不存在或不属于该家庭")
+		}
+		if account.Archived {
+			return errors.New("账户已归档")
+		}
+	}
+
+	return nil
+}
+
+// validateTemplate 验证模板数据，未指定开始日期时从今天开始，按月和按年的日期默认取开始日期
+func (s *recurringService) validateTemplate(template *model.RecurringTemplate) error {
+	template.Name = strings.TrimSpace(template.Name)
+	if template.Name == "" {
+		return errors.New("周期交易名称不能为空")
+	}
+	if len(template.Name) > 100 {
+		return errors.New("周期交易名称长度不能超过100个字符")
+	}
+
+	switch template.Type {
+	case model.Income, model.Expense:
+		if template.CategoryID == nil {
+			return errors.New("收入和支出需要指定分类")
+		}
+		if template.ToAccountID != nil || !template.Fee.IsZero() {
+			return errors.New("只有转账可以指定转入账户和手续费")
+		}
+	case model.Transfer:
+		if template.AccountID == nil || template.ToAccountID == nil {
+			return errors.New("转账需要指定转出和转入账户")
+		}
+		if template.CategoryID != nil {
+			return errors.New("转账不需要分类")
+		}
+		if template.Fee.IsNegative() || !isCents(template.Fee) {
+			return errors.New("手续费不能为负且最多保留两位小数")
+		}
+	default:
+		return errors.New("无效的交易类型，支持: income, expense, transfer")
+	}
+
+	if !template.Amount.IsPositive() || !isCents(template.Amount) {
+		return errors.New("交易金额必须大于0且最多保留两位小数")
+	}
+
+	template.Currency = strings.ToUpper(strings.TrimSpace(template.Currency))
+	if template.Currency != "" && !currencyPattern.MatchString(template.Currency) {
+		return errors.New("无效的货币代码")
+	}
+
+	if template.StartDate.IsZero() {
+		template.StartDate = time.Now()
+	}
+	template.StartDate = today(template.StartDate)
+	if template.EndDate != nil {
+		endDate := today(*template.EndDate)
+		if endDate.Before(template.StartDate) {
+			return errors.New("结束日期不能早于开始日期")
+		}
+		template.EndDate = &endDate
+	}
+
+	if template.Interval == 0 {
+		template.Interval = 1
+	}
+	if template.Interval < 1 || template.Interval > 100 {
+		return errors.New("重复间隔必须在1到100之间")
+	}
+
+	switch template.Frequency {
+	case model.FrequencyDaily, model.FrequencyWeekly, model.FrequencyLastBusinessDay:
+		template.DayOfMonth = 0
+		template.MonthOfYear = 0
+	case model.FrequencyMonthly:
+		template.MonthOfYear = 0
+		if template.DayOfMonth == 0 {
+			template.DayOfMonth = template.StartDate.Day()
+		}
+	case model.FrequencyYearly:
+		if template.DayOfMonth == 0 {
+			template.DayOfMonth = template.StartDate.Day()
+		}
+		if template.MonthOfYear == 0 {
+			template.MonthOfYear = int(template.StartDate.Month())
+		}
+		if template.MonthOfYear < 1 || template.MonthOfYear > 12 {
+			return errors.New("月份必须在1到12之间")
+		}
+	default:
+		return errors.New("无效的重复频率，支持: daily, weekly, monthly, last_business_day, yearly")
+	}
+	if template.DayOfMonth < 0 || template.DayOfMonth > 31 {
+		return errors.New("日期必须在1到31之间")
+	}
+
+	return nil
+}
+
+// nextRunDate 返回模板不早于 from 的第一个日期，超过结束日期时返回 nil
+func nextRunDate(template *model.RecurringTemplate, from time.Time) *time.Time {
+	date := firstOccurrence(template)
+	for date.Before(from) {
+		date = followingOccurrence(template, date)
+	}
+	if template.EndDate != nil && date.After(*template.EndDate) {
+		return nil
+	}
+	return &date
+}
+
+// followingRunDate 返回模板在 date 之后的下一个日期，超过结束日期时返回 nil
+func followingRunDate(template *model.RecurringTemplate, date time.Time) *time.Time {
+	next := followingOccurrence(template, date)
+	if template.EndDate != nil && next.After(*template.EndDate) {
+		return nil
+	}
+	return &next
+}
+
+// firstOccurrence 返回模板不早于开始日期的第一个日期
+func firstOccurrence(template *model.RecurringTemplate) time.Time {
+	start := template.StartDate
+	switch template.Frequency {
+	case model.FrequencyMonthly, model.FrequencyLastBusinessDay:
+		date := monthOccurrence(template, start.Year(), start.Month())
+		if date.Before(start) {
+			date = followingOccurrence(template, date)
+		}
+		return date
+	case model.FrequencyYearly:
+		date := clampedDate(start.Year(), time.Month(template.MonthOfYear), template.DayOfMonth)
+		if date.Before(start) {
+			date = followingOccurrence(template, date)
+		}
+		return date
+	default:
+		return start
+	}
+}
+
+// followingOccurrence 返回 date 之后按间隔的下一个日期
+func followingOccurrence(template *model.RecurringTemplate, date time.Time) time.Time {
+	switch template.Frequency {
+	case model.FrequencyWeekly:
+		return date.AddDate(0, 0, 7*template.Interval)
+	case model.FrequencyMonthly, model.FrequencyLastBusinessDay:
+		return monthOccurrence(template, date.Year(), date.Month()+time.Month(template.Interval))
+	case model.FrequencyYearly:
+		return clampedDate(date.Year()+template.Interval, time.Month(template.MonthOfYear), template.DayOfMonth)
+	default:
+		return date.AddDate(0, 0, template.Interval)
+	}
+}
+
+// monthOccurrence 返回按月模板在某月的日期，月份可以超出 1-12
+func monthOccurrence(template *model.RecurringTemplate, year int, month time.Month) time.Time {
+	if template.Frequency == model.FrequencyLastBusinessDay {
+		date := time.Date(year, month+1, 0, 0, 0, 0, 0, time.Local)
+		for date.Weekday() == time.Saturday || date.Weekday() == time.Sunday {
+			date = date.AddDate(0, 0, -1)
+		}
+		return date
+	}
+	return clampedDate(year, month, template.DayOfMonth)
+}
+
+// today 返回 t 所在日期的零点
+func today(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// truncateRunes 截断字符串到最多 n 个字符
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) > n {
+		return string(runes[:n])
+	}
+	return s
+}
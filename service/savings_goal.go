@@ -0,0 +1,412 @@
+// service/savings_goal_service.go
+package service
+
+import (
+	"errors"
+	"fmt"
+	"github.com/KQLXK/Family-Finance-System/model"
+	"github.com/shopspring/decimal"
+	"strings"
+	"time"
+)
+
+// goalRateWindow 计算近期存入速度的时间窗口
+const goalRateWindow = 90 * 24 * time.Hour
+
+// daysPerMonth 按月换算存入速度时每月的平均天数
+var daysPerMonth = decimal.RequireFromString("30.44")
+
+// GoalProgress 储蓄目标进度，金额以家庭本位币计
+type GoalProgress struct {
+	Goal                model.SavingsGoal `json:"goal"`
+	Currency            string            `json:"currency"`
+	Saved               decimal.Decimal   `json:"saved"`
+	Remaining           decimal.Decimal   `json:"remaining"`
+	Percent             decimal.Decimal   `json:"percent"`
+	Completed           bool              `json:"completed"`
+	RecentMonthlyRate   decimal.Decimal   `json:"recent_monthly_rate"`  // 最近90天（目标开始不足90天时为开始以来）的月均存入
+	RequiredMonthly     *decimal.Decimal  `json:"required_monthly"`     // 按期完成每月需要存入的金额，未设置目标日期时为空
+	ProjectedCompletion *time.Time        `json:"projected_completion"` // 按近期存入速度推算的完成日期，速度不为正时为空
+	OnTrack             *bool             `json:"on_track"`             // 推算完成日期不晚于目标日期
+}
+
+// SavingsGoalService 储蓄目标服务接口
+type SavingsGoalService interface {
+	CreateGoal(scope Scope, goal *model.SavingsGoal) error
+	GetGoalProgress(scope Scope, id uint) (*GoalProgress, error)
+	GetGoalsByFamilyID(scope Scope, familyID uint) ([]GoalProgress, error)
+	UpdateGoal(scope Scope, goal *model.SavingsGoal) error
+	DeleteGoal(scope Scope, id uint) error
+	AddContribution(scope Scope, goalID uint, contribution *model.GoalContribution) error
+	GetContributions(scope Scope, goalID uint) ([]model.GoalContribution, error)
+	DeleteContribution(scope Scope, goalID, contributionID uint) error
+}
+
+// savingsGoalService 储蓄目标服务实现
+type savingsGoalService struct {
+	goalDao    model.SavingsGoalDao
+	familyDao  model.FamilyDao
+	accountDao model.AccountDao
+	tagDao     model.TagDao
+}
+
+// NewSavingsGoalService 创建储蓄目标服务实例
+func NewSavingsGoalService() SavingsGoalService {
+	return &savingsGoalService{
+		goalDao:    *model.NewSavingsGoalDaoInstance(),
+		familyDao:  *model.NewFamilyDaoInstance(),
+		accountDao: *model.NewAccountDaoInstance(),
+		tagDao:     *model.NewTagDaoInstance(),
+	}
+}
+
+// CreateGoal 创建储蓄目标
+func (s *savingsGoalService) CreateGoal(scope Scope, goal *model.SavingsGoal) error {
+	if err := s.validateGoal(goal); err != nil {
+		return err
+	}
+
+	if err := scope.checkFamily(goal.FamilyID); err != nil {
+		return err
+	}
+
+	family, err := s.familyDao.GetFamilyByID(goal.FamilyID)
+	if err != nil || family == nil {
+		return errors.New("关联的家庭不存在")
+	}
+
+	if err := s.checkLinks(goal); err != nil {
+		return err
+	}
+
+	goal.ID = 0
+	goal.CreatedBy = scope.MemberID
+	if err := s.goalDao.CreateGoal(goal); err != nil {
+		return fmt.Errorf("创建储蓄目标失败: %v", err)
+	}
+
+	recordAudit(scope, goal.FamilyID, EntitySavingsGoal, goal.ID, model.AuditCreate, nil, goal)
+
+	return nil
+}
+
+// GetGoalProgress 获取储蓄目标进度
+func (s *savingsGoalService) GetGoalProgress(scope Scope, id uint) (*GoalProgress, error) {
+	goal, err := s.getGoal(scope, id)
+	if err != nil {
+		return nil, err
+	}
+
+	family, err := s.familyDao.GetFamilyByID(goal.FamilyID)
+	if err != nil || family == nil {
+		return nil, errors.New("家庭不存在")
+	}
+
+	return s.progress(goal, newCurrencyConverter(family), time.Now())
+}
+
+// GetGoalsByFamilyID 获取家庭所有储蓄目标及进度
+func (s *savingsGoalService) GetGoalsByFamilyID(scope Scope, familyID uint) ([]GoalProgress, error) {
+	if familyID == 0 {
+		return nil, errors.New("无效的家庭ID")
+	}
+
+	if err := scope.checkFamily(familyID); err != nil {
+		return nil, err
+	}
+
+	family, err := s.familyDao.GetFamilyByID(familyID)
+	if err != nil || family == nil {
+		return nil, errors.New("家庭不存在")
+	}
+
+	goals, err := s.goalDao.GetGoalsByFamilyID(familyID)
+	if err != nil {
+		return nil, fmt.Errorf("获取储蓄目标失败: %v", err)
+	}
+
+	converter := newCurrencyConverter(family)
+	now := time.Now()
+	result := make([]GoalProgress, 0, len(goals))
+	for i := range goals {
+		progress, err := s.progress(&goals[i], converter, now)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *progress)
+	}
+
+	return result, nil
+}
+
+// UpdateGoal 更新储蓄目标
+func (s *savingsGoalService) UpdateGoal(scope Scope, goal *model.SavingsGoal) error {
+	existing, err := s.getGoal(scope, goal.ID)
+	if err != nil {
+		return err
+	}
+	goal.FamilyID = existing.FamilyID
+	goal.CreatedBy = existing.CreatedBy
+	goal.CreatedAt = existing.CreatedAt
+
+	if err := s.validateGoal(goal); err != nil {
+		return err
+	}
+	if err := s.checkLinks(goal); err != nil {
+		return err
+	}
+
+	goal.UpdatedAt = time.Now()
+	if err := s.goalDao.UpdateGoal(goal); err != nil {
+		return fmt.Errorf("更新储蓄目标失败: %v", err)
+	}
+
+	recordAudit(scope, goal.FamilyID, EntitySavingsGoal, goal.ID, model.AuditUpdate, existing, goal)
+
+	return nil
+}
+
+// DeleteGoal 删除储蓄目标，关联的账户、标签和交易保留
+func (s *savingsGoalService) DeleteGoal(scope Scope, id uint) error {
+	existing, err := s.getGoal(scope, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.goalDao.DeleteGoal(id); err != nil {
+		return fmt.Errorf("删除储蓄目标失败: %v", err)
+	}
+
+	recordAudit(scope, existing.FamilyID, EntitySavingsGoal, id, model.AuditDelete, existing, nil)
+
+	return nil
+}
+
+// AddContribution 手动登记存入或取出，金额为负表示取出
+func (s *savingsGoalService) AddContribution(scope Scope, goalID uint, contribution *model.GoalContribution) error {
+	goal, err := s.getGoal(scope, goalID)
+	if err != nil {
+		return err
+	}
+
+	if contribution.Amount.IsZero() || !isCents(contribution.Amount) {
+		return errors.New("存入金额不能为0且最多保留两位小数")
+	}
+	if len(contribution.Note) > 500 {
+		return errors.New("备注长度不能超过500个字符")
+	}
+	if contribution.ContributedAt.IsZero() {
+		contribution.ContributedAt = time.Now()
+	}
+	if contribution.ContributedAt.After(time.Now()) {
+		return errors.New("存入日期不能晚于今天")
+	}
+
+	contribution.ID = 0
+	contribution.GoalID = goal.ID
+	contribution.CreatedBy = scope.MemberID
+	if err := s.goalDao.CreateContribution(contribution); err != nil {
+		return fmt.Errorf("登记存入失败: %v", err)
+	}
+
+	recordAudit(scope, goal.FamilyID, EntityGoalContribution, contribution.ID, model.AuditCreate, nil, contribution)
+
+	return nil
+}
+
+// GetContributions 获取储蓄目标的手动存入记录
+func (s *savingsGoalService) GetContributions(scope Scope, goalID uint) ([]model.GoalContribution, error) {
+	goal, err := s.getGoal(scope, goalID)
+	if err != nil {
+		return nil, err
+	}
+
+	contributions, err := s.goalDao.GetContributionsByGoalID(goal.ID)
+	if err != nil {
+		return nil, fmt.Errorf("获取存入记录失败: %v", err)
+	}
+
+	return contributions, nil
+}
+
+// DeleteContribution 删除手动存入记录
+func (s *savingsGoalService) DeleteContribution(scope Scope, goalID, contributionID uint) error {
+	goal, err := s.getGoal(scope, goalID)
+	if err != nil {
+		return err
+	}
+
+	contribution, err := s.goalDao.GetContributionByID(contributionID)
+	if err != nil || contribution == nil || contribution.GoalID != goal.ID {
+		return errors.New("存入记录不存在")
+	}
+
+	if err := s.goalDao.DeleteContribution(contributionID); err != nil {
+		return fmt.Errorf("删除存入记录失败: %v", err)
+	}
+
+	recordAudit(scope, goal.FamilyID, EntityGoalContribution, contributionID, model.AuditDelete, contribution, nil)
+
+	return nil
+}
+
+// progress 计算储蓄目标进度。近期存入速度取时间窗口前后已存金额之差，
+// 据此推算完成日期；设置了目标日期时按剩余月数计算每月需要存入的金额
+func (s *savingsGoalService) progress(goal *model.SavingsGoal, converter *currencyConverter, now time.Time) (*GoalProgress, error) {
+	saved, err := s.savedAt(goal, converter, now)
+	if err != nil {
+		return nil, err
+	}
+
+	progress := &GoalProgress{
+		Goal:      *goal,
+		Currency:  converter.baseCurrency,
+		Saved:     saved,
+		Remaining: decimal.Max(goal.TargetAmount.Sub(saved), decimal.Zero),
+		Percent:   saved.Mul(decimal.NewFromInt(100)).Div(goal.TargetAmount).Round(2),
+		Completed: !saved.LessThan(goal.TargetAmount),
+	}
+
+	// 近期存入速度
+	windowStart := now.Add(-goalRateWindow)
+	if windowStart.Before(goal.StartDate) {
+		windowStart = goal.StartDate
+	}
+	if days := now.Sub(windowStart).Hours() / 24; days >= 1 {
+		before, err := s.savedAt(goal, converter, windowStart)
+		if err != nil {
+			return nil, err
+		}
+		progress.RecentMonthlyRate = saved.Sub(before).Mul(daysPerMonth).Div(decimal.NewFromFloat(days)).Round(2)
+	}
+
+	if progress.Completed {
+		return progress, nil
+	}
+
+	if goal.TargetDate != nil {
+		required := progress.Remaining
+		if days := goal.TargetDate.Sub(now).Hours() / 24; days > 0 {
+			months := decimal.Max(decimal.NewFromFloat(days).Div(daysPerMonth), decimal.NewFromInt(1))
+			required = progress.Remaining.Div(months).Round(2)
+		}
+		progress.RequiredMonthly = &required
+	}
+
+	if progress.RecentMonthlyRate.IsPositive() {
+		days := progress.Remaining.Mul(daysPerMonth).Div(progress.RecentMonthlyRate).Ceil().IntPart()
+		projected := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).AddDate(0, 0, int(days))
+		progress.ProjectedCompletion = &projected
+		if goal.TargetDate != nil {
+			onTrack := !projected.After(*goal.TargetDate)
+			progress.OnTrack = &onTrack
+		}
+	} else if goal.TargetDate != nil {
+		onTrack := false
+		progress.OnTrack = &onTrack
+	}
+
+	return progress, nil
+}
+
+// savedAt 计算截至 t 已存金额：关联账户的余额、开始日期之后关联标签的交易净额与手动存入之和
+func (s *savingsGoalService) savedAt(goal *model.SavingsGoal, converter *currencyConverter, t time.Time) (decimal.Decimal, error) {
+	saved, err := s.goalDao.GetContributionTotal(goal.ID, t)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("统计存入金额失败: %v", err)
+	}
+
+	if goal.AccountID != nil {
+		account, err := s.accountDao.GetAccountByID(*goal.AccountID)
+		if err != nil || account == nil {
+			return decimal.Zero, errors.New("关联账户不存在")
+		}
+		net, err := s.accountDao.GetAccountNetAmount(account.ID, t)
+		if err != nil {
+			return decimal.Zero, fmt.Errorf("获取账户余额失败: %v", err)
+		}
+		balance, err := converter.convert(account.OpeningBalance.Add(net), account.Currency, t)
+		if err != nil {
+			return decimal.Zero, err
+		}
+		saved = saved.Add(balance)
+	}
+
+	if goal.TagID != nil && !t.Before(goal.StartDate) {
+		rows, err := s.goalDao.GetTagNetAmount(goal.FamilyID, *goal.TagID, goal.StartDate, t)
+		if err != nil {
+			return decimal.Zero, fmt.Errorf("统计标签交易失败: %v", err)
+		}
+		totals, err := converter.sum(rows)
+		if err != nil {
+			return decimal.Zero, err
+		}
+		saved = saved.Add(totals[""])
+	}
+
+	return saved.Round(2), nil
+}
+
+// getGoal 获取属于操作者家庭的储蓄目标
+func (s *savingsGoalService) getGoal(scope Scope, id uint) (*model.SavingsGoal, error) {
+	if id == 0 {
+		return nil, errors.New("无效的储蓄目标ID")
+	}
+
+	goal, err := s.goalDao.GetGoalByID(id)
+	if err != nil || goal == nil || !scope.owns(goal.FamilyID) {
+		return nil, errors.New("储蓄目标不存在")
+	}
+	return goal, nil
+}
+
+// checkLinks 检查关联的账户或标签属于同一家庭，两者只能选择一个
+func (s *savingsGoalService) checkLinks(goal *model.SavingsGoal) error {
+	if goal.AccountID != nil && goal.TagID != nil {
+		return errors.New("关联账户和关联标签只能选择一个")
+	}
+
+	if goal.AccountID != nil {
+		account, err := s.accountDao.GetAccountByID(*goal.AccountID)
+		if err != nil || account == nil || account.FamilyID != goal.FamilyID {
+			return errors.New("账户不存在或不属于该家庭")
+		}
+	}
+
+	if goal.TagID != nil {
+		tag, err := s.tagDao.GetTagByID(*goal.TagID)
+		if err != nil || tag == nil || tag.FamilyID != goal.FamilyID || !tag.IsActive {
+			return errors.New("标签不存在或不属于该家庭")
+		}
+	}
+
+	return nil
+}
+
+// validateGoal 验证储蓄目标数据，未指定开始日期时从今天开始
+func (s *savingsGoalService) validateGoal(goal *model.SavingsGoal) error {
+	goal.Name = strings.TrimSpace(goal.Name)
+	if goal.Name == "" {
+		return errors.New("目标名称不能为空")
+	}
+	if len(goal.Name) > 100 {
+		return errors.New("目标名称长度不能超过100个字符")
+	}
+	if len(goal.Note) > 1000 {
+		return errors.New("备注长度不能超过1000个字符")
+	}
+
+	if !goal.TargetAmount.IsPositive() || !isCents(goal.TargetAmount) {
+		return errors.New("目标金额必须大于0且最多保留两位小数")
+	}
+
+	if goal.StartDate.IsZero() {
+		goal.StartDate = time.Now()
+	}
+	goal.StartDate = time.Date(goal.StartDate.Year(), goal.StartDate.Month(), goal.StartDate.Day(), 0, 0, 0, 0, time.Local)
+	if goal.TargetDate != nil && !goal.TargetDate.After(goal.StartDate) {
+		return errors.New("目标日期必须晚于开始日期")
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+// service/scheduler.go
+package service
+
+import (
+	"log"
+	"time"
+)
+
+// scheduledJob 定时任务
+type scheduledJob struct {
+	name     string
+	interval time.Duration
+	run      func(now time.Time) error
+}
+
+// Scheduler 进程内定时任务调度器，每个任务在独立的 goroutine 中按间隔执行。
+// 任务启动时立即执行一次，任务需要自行根据持久化状态补做停机期间错过的工作并保证不重复执行
+type Scheduler struct {
+	jobs []scheduledJob
+}
+
+// NewScheduler 创建定时任务调度器
+func NewScheduler() *Scheduler {
+	return &Scheduler{}
+}
+
+// Every 注册按固定间隔执行的任务
+func (s *Scheduler) Every(name string, interval time.Duration, run func(now time.Time) error) *Scheduler {
+	s.jobs = append(s.jobs, scheduledJob{name: name, interval: interval, run: run})
+	return s
+}
+
+// Start 启动所有已注册的任务
+func (s *Scheduler) Start() {
+	for _, job := range s.jobs {
+		go job.loop()
+	}
+}
+
+// loop 执行任务直到进程退出，任务出错或 panic 时记录日志后继续下一轮
+func (j scheduledJob) loop() {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		j.runOnce()
+		<-ticker.C
+	}
+}
+
+// runOnce 执行一次任务
+func (j scheduledJob) runOnce() {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("定时任务异常 %s: %v", j.name, r)
+		}
+	}()
+
+	if err := j.run(time.Now()); err != nil {
+		log.Printf("定时任务执行失败 %s: %v", j.name, err)
+	}
+}
@@ -0,0 +1,30 @@
+// service/scope.go
+package service
+
+import (
+	"errors"
+	"github.com/KQLXK/Family-Finance-System/model"
+)
+
+// ErrForbidden 访问了其他家庭的数据
+var ErrForbidden = errors.New("无权访问其他家庭的数据")
+
+// Scope 当前操作者及其所属家庭，服务层据此限制数据访问范围
+type Scope struct {
+	MemberID uint
+	FamilyID uint
+	Role     model.MemberRole
+}
+
+// checkFamily 检查目标家庭是否为操作者所属家庭
+func (s Scope) checkFamily(familyID uint) error {
+	if s.FamilyID == 0 || s.FamilyID != familyID {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// owns 判断数据是否属于操作者所属家庭
+func (s Scope) owns(familyID uint) bool {
+	return s.checkFamily(familyID) == nil
+}
@@ -0,0 +1,479 @@
+// service/shared_expense_service.go
+package service
+
+import (
+	"errors"
+	"fmt"
+	"github.com/KQLXK/Family-Finance-System/model"
+	"github.com/shopspring/decimal"
+	"sort"
+	"time"
+)
+
+// ShareInput 分摊明细输入，按比例分摊时填写 percentage，按金额分摊时填写 amount
+type ShareInput struct {
+	MemberID   uint            `json:"member_id"`
+	Percentage decimal.Decimal `json:"percentage"`
+	Amount     decimal.Decimal `json:"amount"`
+}
+
+// ShareRequest 设置共同支出分摊的请求
+type ShareRequest struct {
+	Method model.ShareMethod `json:"method"`
+	Shares []ShareInput      `json:"shares"`
+}
+
+// MemberBalance 成员在共同支出中的净额（本位币）。
+// Balance 为正表示其他成员欠该成员，为负表示该成员欠其他成员
+type MemberBalance struct {
+	MemberID uint            `json:"member_id"`
+	Paid     decimal.Decimal `json:"paid"`    // 垫付的共同支出
+	Owed     decimal.Decimal `json:"owed"`    // 应承担的金额
+	Settled  decimal.Decimal `json:"settled"` // 已结算的净额，付出为正，收到为负
+	Balance  decimal.Decimal `json:"balance"`
+}
+
+// SettlementPayment 结算建议中的一笔付款（本位币）
+type SettlementPayment struct {
+	FromMemberID uint            `json:"from_member_id"`
+	ToMemberID   uint            `json:"to_member_id"`
+	Amount       decimal.Decimal `json:"amount"`
+}
+
+// RecordSettlementRequest 记录一笔结算，会生成从付款账户到收款账户的转账
+type RecordSettlementRequest struct {
+	FromMemberID    uint            `json:"from_member_id"`
+	ToMemberID      uint            `json:"to_member_id"`
+	Amount          decimal.Decimal `json:"amount"`
+	FromAccountID   uint            `json:"from_account_id"`
+	ToAccountID     uint            `json:"to_account_id"`
+	TransactionTime time.Time       `json:"transaction_time"`
+	Note            string          `json:"note"`
+}
+
+// SharedExpenseService 共同支出服务接口
+type SharedExpenseService interface {
+	ShareTransaction(scope Scope, transactionID uint, request ShareRequest) (*model.SharedExpense, error)
+	GetSharedExpense(scope Scope, transactionID uint) (*model.SharedExpense, error)
+	RemoveSharing(scope Scope, transactionID uint) error
+	GetBalances(scope Scope, familyID uint) ([]MemberBalance, error)
+	GetSettlementPlan(scope Scope, familyID uint) ([]SettlementPayment, error)
+	RecordSettlements(scope Scope, familyID uint, requests []RecordSettlementRequest) ([]model.Settlement, error)
+	GetSettlements(scope Scope, familyID uint) ([]model.Settlement, error)
+}
+
+// sharedExpenseService 共同支出服务实现
+type sharedExpenseService struct {
+	sharedExpenseDao   model.SharedExpenseDao
+	settlementDao      model.SettlementDao
+	familyDao          model.FamilyDao
+	memberDao          model.MemberDao
+	transactionService TransactionService
+}
+
+// NewSharedExpenseService 创建共同支出服务实例
+func NewSharedExpenseService() SharedExpenseService {
+	return &sharedExpenseService{
+		sharedExpenseDao:   *model.NewSharedExpenseDaoInstance(),
+		settlementDao:      *model.NewSettlementDaoInstance(),
+		familyDao:          *model.NewFamilyDaoInstance(),
+		memberDao:          *model.NewMemberDaoInstance(),
+		transactionService: NewTransactionService(),
+	}
+}
+
+// ShareTransaction 设置支出交易的分摊方案，已有方案时整体替换
+func (s *sharedExpenseService) ShareTransaction(scope Scope, transactionID uint, request ShareRequest) (*model.SharedExpense, error) {
+	transaction, err := s.transactionService.GetTransactionByID(scope, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	if transaction.Type != model.Expense {
+		return nil, errors.New("只有支出可以分摊")
+	}
+
+	if len(request.Shares) == 0 {
+		return nil, errors.New("分摊成员不能为空")
+	}
+	if len(request.Shares) > 20 {
+		return nil, errors.New("分摊成员不能超过20人")
+	}
+
+	// 分摊成员必须是该家庭的正常成员，且不能重复
+	seen := make(map[uint]bool)
+	for _, share := range request.Shares {
+		if seen[share.MemberID] {
+			return nil, errors.New("分摊成员不能重复")
+		}
+		seen[share.MemberID] = true
+
+		member, err := s.memberDao.GetMemberByID(share.MemberID)
+		if err != nil || member == nil || member.FamilyID != transaction.FamilyID || member.Status != 1 {
+			return nil, fmt.Errorf("成员 %d 不存在或不属于该家庭", share.MemberID)
+		}
+	}
+
+	shares, err := computeShares(transaction.Amount, request)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.sharedExpenseDao.GetSharedExpenseByTransactionID(transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("获取共同支出失败: %v", err)
+	}
+
+	expense := &model.SharedExpense{
+		FamilyID:      transaction.FamilyID,
+		TransactionID: transactionID,
+		Method:        request.Method,
+		Shares:        shares,
+		CreatedBy:     scope.MemberID,
+	}
+	if err := s.sharedExpenseDao.SaveSharedExpense(expense); err != nil {
+		return nil, fmt.Errorf("保存共同支出失败: %v", err)
+	}
+
+	if existing != nil {
+		recordAudit(scope, expense.FamilyID, EntitySharedExpense, expense.ID, model.AuditUpdate, existing, expense)
+	} else {
+		recordAudit(scope, expense.FamilyID, EntitySharedExpense, expense.ID, model.AuditCreate, nil, expense)
+	}
+
+	return expense, nil
+}
+
+// GetSharedExpense 获取交易的分摊方案
+func (s *sharedExpenseService) GetSharedExpense(scope Scope, transactionID uint) (*model.SharedExpense, error) {
+	if _, err := s.transactionService.GetTransactionByID(scope, transactionID); err != nil {
+		return nil, err
+	}
+
+	expense, err := s.sharedExpenseDao.GetSharedExpenseByTransactionID(transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("获取共同支出失败: %v", err)
+	}
+	if expense == nil {
+		return nil, errors.New("该交易未设置分摊")
+	}
+
+	return expense, nil
+}
+
+// RemoveSharing 取消交易的分摊
+func (s *sharedExpenseService) RemoveSharing(scope Scope, transactionID uint) error {
+	expense, err := s.GetSharedExpense(scope, transactionID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.sharedExpenseDao.DeleteSharedExpense(expense.ID); err != nil {
+		return fmt.Errorf("取消分摊失败: %v", err)
+	}
+
+	recordAudit(scope, expense.FamilyID, EntitySharedExpense, expense.ID, model.AuditDelete, expense, nil)
+
+	return nil
+}
+
+// GetBalances 计算家庭成员在共同支出中的净额，金额按交易日期的汇率换算为本位币
+func (s *sharedExpenseService) GetBalances(scope Scope, familyID uint) ([]MemberBalance, error) {
+	if err := scope.checkFamily(familyID); err != nil {
+		return nil, err
+	}
+
+	family, err := s.familyDao.GetFamilyByID(familyID)
+	if err != nil || family == nil {
+		return nil, errors.New("家庭不存在")
+	}
+	converter := newCurrencyConverter(family)
+
+	paid, err := s.sharedExpenseDao.GetPaidAmounts(familyID)
+	if err != nil {
+		return nil, fmt.Errorf("统计共同支出失败: %v", err)
+	}
+	owed, err := s.sharedExpenseDao.GetOwedAmounts(familyID)
+	if err != nil {
+		return nil, fmt.Errorf("统计共同支出失败: %v", err)
+	}
+	settlements, err := s.settlementDao.GetEffectiveSettlements(familyID)
+	if err != nil {
+		return nil, fmt.Errorf("获取结算记录失败: %v", err)
+	}
+
+	balances := make(map[uint]*MemberBalance)
+	balanceOf := func(memberID uint) *MemberBalance {
+		if balances[memberID] == nil {
+			balances[memberID] = &MemberBalance{MemberID: memberID}
+		}
+		return balances[memberID]
+	}
+
+	for _, row := range paid {
+		amount, err := converter.convert(row.Amount, row.Currency, row.Date)
+		if err != nil {
+			return nil, err
+		}
+		balance := balanceOf(row.MemberID)
+		balance.Paid = balance.Paid.Add(amount)
+	}
+	for _, row := range owed {
+		amount, err := converter.convert(row.Amount, row.Currency, row.Date)
+		if err != nil {
+			return nil, err
+		}
+		balance := balanceOf(row.MemberID)
+		balance.Owed = balance.Owed.Add(amount)
+	}
+	for _, settlement := range settlements {
+		amount, err := converter.convert(settlement.Amount, settlement.Currency, settlement.SettledAt)
+		if err != nil {
+			return nil, err
+		}
+		from := balanceOf(settlement.FromMemberID)
+		from.Settled = from.Settled.Add(amount)
+		to := balanceOf(settlement.ToMemberID)
+		to.Settled = to.Settled.Sub(amount)
+	}
+
+	result := make([]MemberBalance, 0, len(balances))
+	for _, balance := range balances {
+		balance.Paid = balance.Paid.Round(2)
+		balance.Owed = balance.Owed.Round(2)
+		balance.Settled = balance.Settled.Round(2)
+		balance.Balance = balance.Paid.Sub(balance.Owed).Add(balance.Settled)
+		result = append(result, *balance)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].MemberID < result[j].MemberID })
+
+	return result, nil
+}
+
+// GetSettlementPlan 根据成员净额生成结算建议：每次由欠款最多的成员付给应收最多的成员，
+// n 个有余额的成员最多需要 n-1 笔付款
+func (s *sharedExpenseService) GetSettlementPlan(scope Scope, familyID uint) ([]SettlementPayment, error) {
+	balances, err := s.GetBalances(scope, familyID)
+	if err != nil {
+		return nil, err
+	}
+
+	var debtors, creditors []MemberBalance
+	for _, balance := range balances {
+		switch balance.Balance.Sign() {
+		case -1:
+			debtors = append(debtors, MemberBalance{MemberID: balance.MemberID, Balance: balance.Balance.Neg()})
+		case 1:
+			creditors = append(creditors, balance)
+		}
+	}
+
+	payments := []SettlementPayment{}
+	for len(debtors) > 0 && len(creditors) > 0 {
+		sort.Slice(debtors, func(i, j int) bool { return debtors[i].Balance.GreaterThan(debtors[j].Balance) })
+		sort.Slice(creditors, func(i, j int) bool { return creditors[i].Balance.GreaterThan(creditors[j].Balance) })
+
+		amount := decimal.Min(debtors[0].Balance, creditors[0].Balance)
+		payments = append(payments, SettlementPayment{
+			FromMemberID: debtors[0].MemberID,
+			ToMemberID:   creditors[0].MemberID,
+			Amount:       amount,
+		})
+
+		debtors[0].Balance = debtors[0].Balance.Sub(amount)
+		creditors[0].Balance = creditors[0].Balance.Sub(amount)
+		if debtors[0].Balance.IsZero() {
+			debtors = debtors[1:]
+		}
+		if creditors[0].Balance.IsZero() {
+			creditors = creditors[1:]
+		}
+	}
+
+	return payments, nil
+}
+
+// RecordSettlements 记录成员间的结算，每笔结算生成一笔转账交易。
+// 普通成员只能记录自己参与的结算；某笔失败时返回已记录的结算和错误
+func (s *sharedExpenseService) RecordSettlements(scope Scope, familyID uint, requests []RecordSettlementRequest) ([]model.Settlement, error) {
+	if err := scope.checkFamily(familyID); err != nil {
+		return nil, err
+	}
+	if len(requests) == 0 {
+		return nil, errors.New("结算记录不能为空")
+	}
+
+	// 先校验全部结算，避免部分生成转账后才发现错误
+	for i, request := range requests {
+		if err := s.validateSettlement(scope, familyID, request); err != nil {
+			return nil, fmt.Errorf("第%d笔结算: %v", i+1, err)
+		}
+	}
+
+	settlements := make([]model.Settlement, 0, len(requests))
+	for i, request := range requests {
+		fromAccountID, toAccountID := request.FromAccountID, request.ToAccountID
+		transactionTime := request.TransactionTime
+		if transactionTime.IsZero() {
+			transactionTime = time.Now()
+		}
+		note := request.Note
+		if note == "" {
+			note = "成员结算"
+		}
+
+		transfer := &model.Transaction{
+			FamilyID:        familyID,
+			MemberID:        request.FromMemberID,
+			Amount:          request.Amount,
+			Type:            model.Transfer,
+			AccountID:       &fromAccountID,
+			ToAccountID:     &toAccountID,
+			TransactionTime: transactionTime,
+			Note:            note,
+		}
+		if err := s.transactionService.CreateTransaction(scope, transfer); err != nil {
+			return settlements, fmt.Errorf("第%d笔结算生成转账失败: %v", i+1, err)
+		}
+
+		settlement := model.Settlement{
+			FamilyID:      familyID,
+			FromMemberID:  request.FromMemberID,
+			ToMemberID:    request.ToMemberID,
+			Amount:        request.Amount,
+			Currency:      transfer.Currency,
+			TransactionID: transfer.ID,
+			SettledAt:     transfer.TransactionTime,
+			Note:          request.Note,
+			CreatedBy:     scope.MemberID,
+		}
+		if err := s.settlementDao.CreateSettlement(&settlement); err != nil {
+			return settlements, fmt.Errorf("第%d笔结算记录失败: %v", i+1, err)
+		}
+		recordAudit(scope, familyID, EntitySettlement, settlement.ID, model.AuditCreate, nil, settlement)
+
+		settlements = append(settlements, settlement)
+	}
+
+	return settlements, nil
+}
+
+// GetSettlements 获取家庭的结算记录
+func (s *sharedExpenseService) GetSettlements(scope Scope, familyID uint) ([]model.Settlement, error) {
+	if err := scope.checkFamily(familyID); err != nil {
+		return nil, err
+	}
+
+	settlements, err := s.settlementDao.GetSettlementsByFamilyID(familyID)
+	if err != nil {
+		return nil, fmt.Errorf("获取结算记录失败: %v", err)
+	}
+
+	return settlements, nil
+}
+
+// validateSettlement 验证结算请求
+func (s *sharedExpenseService) validateSettlement(scope Scope, familyID uint, request RecordSettlementRequest) error {
+	if request.FromMemberID == request.ToMemberID {
+		return errors.New("付款成员和收款成员不能相同")
+	}
+	if scope.Role != model.RoleAdmin && scope.MemberID != request.FromMemberID && scope.MemberID != request.ToMemberID {
+		return errors.New("只能记录自己参与的结算")
+	}
+	if !request.Amount.IsPositive() || !isCents(request.Amount) {
+		return errors.New("结算金额必须大于0且最多保留两位小数")
+	}
+	if request.FromAccountID == 0 || request.ToAccountID == 0 {
+		return errors.New("结算需要指定付款账户和收款账户")
+	}
+	if len(request.Note) > 500 {
+		return errors.New("备注长度不能超过500个字符")
+	}
+
+	for _, memberID := range []uint{request.FromMemberID, request.ToMemberID} {
+		member, err := s.memberDao.GetMemberByID(memberID)
+		if err != nil || member == nil || member.FamilyID != familyID || member.Status != 1 {
+			return fmt.Errorf("成员 %d 不存在或不属于该家庭", memberID)
+		}
+	}
+
+	return nil
+}
+
+// computeShares 根据分摊方式计算每个成员应承担的金额。平均分摊时分不尽的零头依次分给前面的成员，
+// 按比例分摊时分给小数部分最大的成员
+func computeShares(total decimal.Decimal, request ShareRequest) ([]model.ExpenseShare, error) {
+	shares := make([]model.ExpenseShare, len(request.Shares))
+	for i, input := range request.Shares {
+		shares[i].MemberID = input.MemberID
+	}
+
+	switch request.Method {
+	case model.ShareEqual:
+		cents := total.Shift(2).IntPart()
+		count := int64(len(shares))
+		for i := range shares {
+			part := cents / count
+			if int64(i) < cents%count {
+				part++
+			}
+			shares[i].Amount = decimal.New(part, -2)
+		}
+
+	case model.SharePercentage:
+		hundred := decimal.NewFromInt(100)
+		sum := decimal.Zero
+		for _, input := range request.Shares {
+			if !input.Percentage.IsPositive() {
+				return nil, errors.New("分摊比例必须大于0")
+			}
+			sum = sum.Add(input.Percentage)
+		}
+		if !sum.Equal(hundred) {
+			return nil, errors.New("分摊比例之和必须等于100")
+		}
+
+		// 按分计算，先向下取整，分不尽的零头按小数部分从大到小依次分配
+		cents := total.Shift(2)
+		allocated := int64(0)
+		fractions := make([]decimal.Decimal, len(shares))
+		order := make([]int, len(shares))
+		for i, input := range request.Shares {
+			exact := cents.Mul(input.Percentage).Div(hundred)
+			part := exact.Floor()
+			shares[i].Percentage = input.Percentage
+			shares[i].Amount = part.Shift(-2)
+			fractions[i] = exact.Sub(part)
+			allocated += part.IntPart()
+			order[i] = i
+		}
+		sort.SliceStable(order, func(a, b int) bool {
+			return fractions[order[a]].GreaterThan(fractions[order[b]])
+		})
+		for _, i := range order[:cents.IntPart()-allocated] {
+			shares[i].Amount = shares[i].Amount.Add(decimal.New(1, -2))
+		}
+		for i := range shares {
+			if !shares[i].Amount.IsPositive() {
+				return nil, errors.New("分摊比例过小，每个成员的分摊金额至少为0.01")
+			}
+		}
+
+	case model.ShareExact:
+		sum := decimal.Zero
+		for i, input := range request.Shares {
+			if !input.Amount.IsPositive() || !isCents(input.Amount) {
+				return nil, errors.New("分摊金额必须大于0且最多保留两位小数")
+			}
+			shares[i].Amount = input.Amount
+			sum = sum.Add(input.Amount)
+		}
+		if !sum.Equal(total) {
+			return nil, errors.New("分摊金额之和必须等于交易金额")
+		}
+
+	default:
+		return nil, errors.New("无效的分摊方式，支持: equal, percentage, exact")
+	}
+
+	return shares, nil
+}
@@ -0,0 +1,284 @@
+// service/spending_limit_service.go
+package service
+
+import (
+	"errors"
+	"fmt"
+	"github.com/KQLXK/Family-Finance-System/model"
+	"github.com/shopspring/decimal"
+	"strings"
+	"time"
+)
+
+// Allowance 成员消费限额在某个周期内的使用情况，金额以家庭本位币计
+type Allowance struct {
+	Limit       model.SpendingLimit `json:"limit"`
+	MemberName  string              `json:"member_name"`
+	Currency    string              `json:"currency"`
+	PeriodStart time.Time           `json:"period_start"`
+	PeriodEnd   time.Time           `json:"period_end"` // 不包含
+	Spent       decimal.Decimal     `json:"spent"`
+	Remaining   decimal.Decimal     `json:"remaining"` // 超支时为0
+	Exceeded    bool                `json:"exceeded"`
+}
+
+// SpendingLimitService 成员消费限额服务接口
+type SpendingLimitService interface {
+	CreateLimit(scope Scope, limit *model.SpendingLimit) error
+	GetLimitsByFamilyID(scope Scope, familyID uint) ([]model.SpendingLimit, error)
+	UpdateLimit(scope Scope, limit *model.SpendingLimit) error
+	DeleteLimit(scope Scope, id uint) error
+	GetAllowances(scope Scope, familyID, memberID uint, date time.Time) ([]Allowance, error)
+}
+
+// spendingLimitService 成员消费限额服务实现
+type spendingLimitService struct {
+	limitDao    model.SpendingLimitDao
+	familyDao   model.FamilyDao
+	memberDao   model.MemberDao
+	categoryDao model.CategoryDao
+}
+
+// NewSpendingLimitService 创建成员消费限额服务实例
+func NewSpendingLimitService() SpendingLimitService {
+	return &spendingLimitService{
+		limitDao:    *model.NewSpendingLimitDaoInstance(),
+		familyDao:   *model.NewFamilyDaoInstance(),
+		memberDao:   *model.NewMemberDaoInstance(),
+		categoryDao: *model.NewCategoryDaoInstance(),
+	}
+}
+
+// CreateLimit 创建成员消费限额，同一成员同一分类每种周期只能有一个限额
+func (s *spendingLimitService) CreateLimit(scope Scope, limit *model.SpendingLimit) error {
+	if err := s.validateLimit(limit); err != nil {
+		return err
+	}
+
+	if err := scope.checkFamily(limit.FamilyID); err != nil {
+		return err
+	}
+
+	member, err := s.memberDao.GetMemberByID(limit.MemberID)
+	if err != nil || member == nil || member.FamilyID != limit.FamilyID || member.Status != 1 {
+		return errors.New("成员不存在或不属于该家庭")
+	}
+	if member.Role == model.RoleAdmin {
+		return errors.New("管理员记账不受消费限额约束")
+	}
+
+	if limit.CategoryID != nil {
+		category, err := s.categoryDao.GetCategoryByID(*limit.CategoryID)
+		if err != nil || category == nil || category.IsDeleted {
+			return errors.New("分类不存在")
+		}
+		if category.Type != model.CategoryExpense {
+			return errors.New("只能为支出分类设置消费限额")
+		}
+	}
+
+	limits, err := s.limitDao.GetLimitsByMemberID(limit.MemberID)
+	if err != nil {
+		return fmt.Errorf("检查消费限额是否存在时出错: %v", err)
+	}
+	for _, existing := range limits {
+		if derefID(existing.CategoryID) == derefID(limit.CategoryID) && existing.Period == limit.Period {
+			return errors.New("该成员在此分类和周期已有消费限额")
+		}
+	}
+
+	limit.ID = 0
+	limit.Category = nil
+	limit.CreatedBy = scope.MemberID
+	if err := s.limitDao.CreateLimit(limit); err != nil {
+		return fmt.Errorf("创建消费限额失败: %v", err)
+	}
+
+	recordAudit(scope, limit.FamilyID, EntitySpendingLimit, limit.ID, model.AuditCreate, nil, limit)
+
+	return nil
+}
+
+// GetLimitsByFamilyID 获取家庭的消费限额列表
+func (s *spendingLimitService) GetLimitsByFamilyID(scope Scope, familyID uint) ([]model.SpendingLimit, error) {
+	if familyID == 0 {
+		return nil, errors.New("无效的家庭ID")
+	}
+
+	if err := scope.checkFamily(familyID); err != nil {
+		return nil, err
+	}
+
+	limits, err := s.limitDao.GetLimitsByFamilyID(familyID)
+	if err != nil {
+		return nil, fmt.Errorf("获取消费限额失败: %v", err)
+	}
+
+	return limits, nil
+}
+
+// UpdateLimit 更新消费限额的金额、周期和处理方式，成员和分类不能修改
+func (s *spendingLimitService) UpdateLimit(scope Scope, limit *model.SpendingLimit) error {
+	existing, err := s.getLimit(scope, limit.ID)
+	if err != nil {
+		return err
+	}
+	limit.FamilyID = existing.FamilyID
+	limit.MemberID = existing.MemberID
+	limit.CategoryID = existing.CategoryID
+	limit.CreatedBy = existing.CreatedBy
+	limit.CreatedAt = existing.CreatedAt
+
+	if err := s.validateLimit(limit); err != nil {
+		return err
+	}
+
+	if limit.Period != existing.Period {
+		limits, err := s.limitDao.GetLimitsByMemberID(limit.MemberID)
+		if err != nil {
+			return fmt.Errorf("检查消费限额是否存在时出错: %v", err)
+		}
+		for _, other := range limits {
+			if other.ID != limit.ID && derefID(other.CategoryID) == derefID(limit.CategoryID) && other.Period == limit.Period {
+				return errors.New("该成员在此分类和周期已有消费限额")
+			}
+		}
+	}
+
+	limit.Category = existing.Category
+	limit.UpdatedAt = time.Now()
+	if err := s.limitDao.UpdateLimit(limit); err != nil {
+		return fmt.Errorf("更新消费限额失败: %v", err)
+	}
+
+	recordAudit(scope, limit.FamilyID, EntitySpendingLimit, limit.ID, model.AuditUpdate, existing, limit)
+
+	return nil
+}
+
+// DeleteLimit 删除消费限额
+func (s *spendingLimitService) DeleteLimit(scope Scope, id uint) error {
+	existing, err := s.getLimit(scope, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.limitDao.DeleteLimit(id); err != nil {
+		return fmt.Errorf("删除消费限额失败: %v", err)
+	}
+
+	recordAudit(scope, existing.FamilyID, EntitySpendingLimit, id, model.AuditDelete, existing, nil)
+
+	return nil
+}
+
+// GetAllowances 获取成员消费限额在 date 所在周期的剩余额度，memberID 为0时返回家庭所有成员
+func (s *spendingLimitService) GetAllowances(scope Scope, familyID, memberID uint, date time.Time) ([]Allowance, error) {
+	limits, err := s.GetLimitsByFamilyID(scope, familyID)
+	if err != nil {
+		return nil, err
+	}
+
+	family, err := s.familyDao.GetFamilyByID(familyID)
+	if err != nil || family == nil {
+		return nil, errors.New("家庭不存在")
+	}
+
+	members, err := s.memberDao.GetAllMembersByFamilyID(familyID)
+	if err != nil {
+		return nil, fmt.Errorf("获取家庭成员失败: %v", err)
+	}
+	names := make(map[uint]string, len(members))
+	for _, member := range members {
+		names[member.ID] = member.Name
+	}
+
+	converter := newCurrencyConverter(family)
+	result := make([]Allowance, 0, len(limits))
+	for _, limit := range limits {
+		if memberID != 0 && limit.MemberID != memberID {
+			continue
+		}
+
+		start, end := periodRange(limit.Period, date)
+		spent, err := limitSpending(s.limitDao, converter, &limit, start, end, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, Allowance{
+			Limit:       limit,
+			MemberName:  names[limit.MemberID],
+			Currency:    converter.baseCurrency,
+			PeriodStart: start,
+			PeriodEnd:   end,
+			Spent:       spent,
+			Remaining:   decimal.Max(limit.Amount.Sub(spent), decimal.Zero),
+			Exceeded:    spent.GreaterThan(limit.Amount),
+		})
+	}
+
+	return result, nil
+}
+
+// getLimit 获取属于操作者家庭的消费限额
+func (s *spendingLimitService) getLimit(scope Scope, id uint) (*model.SpendingLimit, error) {
+	if id == 0 {
+		return nil, errors.New("无效的消费限额ID")
+	}
+
+	limit, err := s.limitDao.GetLimitByID(id)
+	if err != nil || limit == nil || !scope.owns(limit.FamilyID) {
+		return nil, errors.New("消费限额不存在")
+	}
+	return limit, nil
+}
+
+// validateLimit 验证消费限额数据，未指定周期时按月，未指定处理方式时拒绝记账
+func (s *spendingLimitService) validateLimit(limit *model.SpendingLimit) error {
+	if limit.MemberID == 0 {
+		return errors.New("消费限额需要指定成员")
+	}
+	if !limit.Amount.IsPositive() || !isCents(limit.Amount) {
+		return errors.New("限额金额必须大于0且最多保留两位小数")
+	}
+	if limit.Period == "" {
+		limit.Period = model.PeriodMonthly
+	}
+	if !isValidPeriod(limit.Period) {
+		return errors.New("无效的限额周期，支持: weekly, monthly, yearly")
+	}
+	if limit.Action == "" {
+		limit.Action = model.LimitReject
+	}
+	if limit.Action != model.LimitReject && limit.Action != model.LimitApprove {
+		return errors.New("无效的超限处理方式，支持: reject, approve")
+	}
+	return nil
+}
+
+// limitSpending 统计成员在 [start, end) 内计入限额的支出，excludeID 用于修改交易时排除交易本身
+func limitSpending(limitDao model.SpendingLimitDao, converter *currencyConverter, limit *model.SpendingLimit, start, end time.Time, excludeID uint) (decimal.Decimal, error) {
+	path := ""
+	if limit.CategoryID != nil {
+		if limit.Category == nil || limit.Category.Path == "" {
+			return decimal.Zero, errors.New("限额分类不存在")
+		}
+		path = limit.Category.Path
+	}
+
+	rows, err := limitDao.GetMemberSpending(limit.MemberID, path, start, end, excludeID)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("统计成员支出失败: %v", err)
+	}
+	totals, err := converter.sum(rows)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return totals[""], nil
+}
+
+// inCategoryTree 判断分类是否属于以 root 为根的分类子树
+func inCategoryTree(category *model.Category, root *model.Category) bool {
+	return category.Path == root.Path || strings.HasPrefix(category.Path, root.Path+"/")
+}
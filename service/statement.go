@@ -0,0 +1,371 @@
+// service/statement_service.go
+package service
+
+import (
+	"errors"
+	"fmt"
+	"github.com/KQLXK/Family-Finance-System/model"
+	"github.com/shopspring/decimal"
+	"sort"
+	"time"
+)
+
+// maxStatementCount 一次最多查询的账单期数
+const maxStatementCount = 24
+
+// CardStatement 信用卡某期账单，由账单周期内的交易统计得出
+type CardStatement struct {
+	AccountID     uint            `json:"account_id"`
+	StatementDate time.Time       `json:"statement_date"` // 账单日
+	PeriodStart   time.Time       `json:"period_start"`   // 账单周期开始，包含
+	PeriodEnd     time.Time       `json:"period_end"`     // 账单周期结束，不包含
+	DueDate       time.Time       `json:"due_date"`
+	Closed        bool            `json:"closed"` // 已出账
+	Amount        decimal.Decimal `json:"amount"`
+	Paid          decimal.Decimal `json:"paid"`
+	Unpaid        decimal.Decimal `json:"unpaid"`
+	Overdue       bool            `json:"overdue"`
+}
+
+// StatementDetail 账单及其消费明细和还款记录
+type StatementDetail struct {
+	CardStatement
+	Transactions []model.Transaction      `json:"transactions"`
+	Payments     []model.StatementPayment `json:"payments"`
+}
+
+// UpcomingDue 信用卡待还账单
+type UpcomingDue struct {
+	Account   model.Account `json:"account"`
+	Statement CardStatement `json:"statement"`
+}
+
+// SettleStatementRequest 登记账单还款的请求，金额为空时使用转账金额和未还金额中较小者
+type SettleStatementRequest struct {
+	TransactionID uint            `json:"transaction_id"`
+	Amount        decimal.Decimal `json:"amount"`
+}
+
+// StatementService 信用卡账单服务接口
+type StatementService interface {
+	GetStatements(scope Scope, accountID uint, count int) ([]CardStatement, error)
+	GetStatement(scope Scope, accountID uint, statementDate time.Time) (*StatementDetail, error)
+	SettleStatement(scope Scope, accountID uint, statementDate time.Time, request SettleStatementRequest) (*model.StatementPayment, error)
+	DeleteStatementPayment(scope Scope, accountID, paymentID uint) error
+	GetUpcomingDues(scope Scope, familyID uint) ([]UpcomingDue, error)
+}
+
+// statementService 信用卡账单服务实现
+type statementService struct {
+	statementDao   model.StatementDao
+	accountDao     model.AccountDao
+	transactionDao model.TransactionDao
+}
+
+// NewStatementService 创建信用卡账单服务实例
+func NewStatementService() StatementService {
+	return &statementService{
+		statementDao:   *model.NewStatementDaoInstance(),
+		accountDao:     *model.NewAccountDaoInstance(),
+		transactionDao: *model.NewTransactionDaoInstance(),
+	}
+}
+
+// GetStatements 获取信用卡最近若干期账单，第一项为当前未出账的账单
+func (s *statementService) GetStatements(scope Scope, accountID uint, count int) ([]CardStatement, error) {
+	account, err := s.getCard(scope, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if count <= 0 {
+		count = 6
+	}
+	if count > maxStatementCount {
+		count = maxStatementCount
+	}
+
+	paid, err := s.statementDao.GetStatementPaidAmounts(account.ID)
+	if err != nil {
+		return nil, fmt.Errorf("获取账单还款失败: %v", err)
+	}
+
+	now := time.Now()
+	statementDate := nextStatementDate(account, now)
+	statements := make([]CardStatement, 0, count)
+	for i := 0; i < count; i++ {
+		statement, err := s.buildStatement(account, statementDate, paid, now)
+		if err != nil {
+			return nil, err
+		}
+		statements = append(statements, *statement)
+		statementDate = statementDateIn(account, statementDate.Year(), statementDate.Month()-1)
+	}
+
+	return statements, nil
+}
+
+// GetStatement 获取某期账单的详情
+func (s *statementService) GetStatement(scope Scope, accountID uint, statementDate time.Time) (*StatementDetail, error) {
+	account, err := s.getCard(scope, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkStatementDate(account, statementDate); err != nil {
+		return nil, err
+	}
+
+	paid, err := s.statementDao.GetStatementPaidAmounts(account.ID)
+	if err != nil {
+		return nil, fmt.Errorf("获取账单还款失败: %v", err)
+	}
+	statement, err := s.buildStatement(account, statementDate, paid, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	transactions, err := s.statementDao.GetStatementTransactions(account.ID, statement.PeriodStart, statement.PeriodEnd)
+	if err != nil {
+		return nil, fmt.Errorf("获取账单明细失败: %v", err)
+	}
+	payments, err := s.statementDao.GetStatementPayments(account.ID, statementDate)
+	if err != nil {
+		return nil, fmt.Errorf("获取账单还款失败: %v", err)
+	}
+
+	return &StatementDetail{
+		CardStatement: *statement,
+		Transactions:  transactions,
+		Payments:      payments,
+	}, nil
+}
+
+// SettleStatement 将转入信用卡的转账登记为某期账单的还款，每笔转账只能偿还一期账单
+func (s *statementService) SettleStatement(scope Scope, accountID uint, statementDate time.Time, request SettleStatementRequest) (*model.StatementPayment, error) {
+	account, err := s.getCard(scope, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkStatementDate(account, statementDate); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if statementDate.After(nextStatementDate(account, now)) {
+		return nil, errors.New("不能偿还尚未开始的账单")
+	}
+
+	// 还款必须是转入该信用卡的有效转账
+	transaction, err := s.transactionDao.GetTransactionByID(request.TransactionID)
+	if err != nil || transaction == nil || transaction.FamilyID != account.FamilyID || transaction.Status == model.Deleted {
+		return nil, errors.New("还款交易不存在")
+	}
+	if transaction.Type != model.Transfer || derefID(transaction.ToAccountID) != account.ID {
+		return nil, errors.New("还款交易必须是转入该信用卡的转账")
+	}
+	if transaction.Status != model.Valid {
+		return nil, errors.New("还款交易尚未生效")
+	}
+
+	existing, err := s.statementDao.GetStatementPaymentByTransactionID(transaction.ID)
+	if err != nil {
+		return nil, fmt.Errorf("检查还款记录时出错: %v", err)
+	}
+	if existing != nil {
+		return nil, errors.New("该交易已登记为账单还款")
+	}
+
+	paid, err := s.statementDao.GetStatementPaidAmounts(account.ID)
+	if err != nil {
+		return nil, fmt.Errorf("获取账单还款失败: %v", err)
+	}
+	statement, err := s.buildStatement(account, statementDate, paid, now)
+	if err != nil {
+		return nil, err
+	}
+	if transaction.TransactionTime.Before(statement.PeriodStart) {
+		return nil, errors.New("还款时间不能早于账单周期开始时间")
+	}
+	if !statement.Unpaid.IsPositive() {
+		return nil, errors.New("该期账单已还清")
+	}
+
+	amount := request.Amount
+	if amount.IsZero() {
+		amount = decimal.Min(transaction.Amount, statement.Unpaid)
+	}
+	if !amount.IsPositive() || !isCents(amount) {
+		return nil, errors.New("还款金额必须大于0且最多保留两位小数")
+	}
+	if amount.GreaterThan(transaction.Amount) {
+		return nil, errors.New("还款金额不能超过转账金额")
+	}
+	if amount.GreaterThan(statement.Unpaid) {
+		return nil, fmt.Errorf("还款金额不能超过账单未还金额 %s", statement.Unpaid.StringFixed(2))
+	}
+
+	payment := &model.StatementPayment{
+		AccountID:     account.ID,
+		StatementDate: statementDate,
+		TransactionID: transaction.ID,
+		Amount:        amount,
+		CreatedBy:     scope.MemberID,
+	}
+	if err := s.statementDao.CreateStatementPayment(payment); err != nil {
+		return nil, fmt.Errorf("登记账单还款失败: %v", err)
+	}
+
+	recordAudit(scope, account.FamilyID, EntityStatementPayment, payment.ID, model.AuditCreate, nil, payment)
+
+	return payment, nil
+}
+
+// DeleteStatementPayment 删除账单还款记录，关联的转账保留
+func (s *statementService) DeleteStatementPayment(scope Scope, accountID, paymentID uint) error {
+	account, err := s.getCard(scope, accountID)
+	if err != nil {
+		return err
+	}
+
+	payment, err := s.statementDao.GetStatementPaymentByID(paymentID)
+	if err != nil || payment == nil || payment.AccountID != account.ID {
+		return errors.New("还款记录不存在")
+	}
+
+	if err := s.statementDao.DeleteStatementPayment(paymentID); err != nil {
+		return fmt.Errorf("删除还款记录失败: %v", err)
+	}
+
+	recordAudit(scope, account.FamilyID, EntityStatementPayment, paymentID, model.AuditDelete, payment, nil)
+
+	return nil
+}
+
+// GetUpcomingDues 获取家庭各信用卡已出账但未还清的账单，按还款日排序，最多回溯一年
+func (s *statementService) GetUpcomingDues(scope Scope, familyID uint) ([]UpcomingDue, error) {
+	if familyID == 0 {
+		return nil, errors.New("无效的家庭ID")
+	}
+
+	if err := scope.checkFamily(familyID); err != nil {
+		return nil, err
+	}
+
+	accounts, err := s.accountDao.GetAccountsByFamilyID(familyID, false)
+	if err != nil {
+		return nil, fmt.Errorf("获取账户列表失败: %v", err)
+	}
+
+	now := time.Now()
+	dues := []UpcomingDue{}
+	for i := range accounts {
+		account := &accounts[i]
+		if account.Type != model.AccountCreditCard || account.StatementDay == 0 {
+			continue
+		}
+
+		paid, err := s.statementDao.GetStatementPaidAmounts(account.ID)
+		if err != nil {
+			return nil, fmt.Errorf("获取账单还款失败: %v", err)
+		}
+
+		current := nextStatementDate(account, now)
+		statementDate := statementDateIn(account, current.Year(), current.Month()-1)
+		for j := 0; j < 12; j++ {
+			statement, err := s.buildStatement(account, statementDate, paid, now)
+			if err != nil {
+				return nil, err
+			}
+			if statement.Unpaid.IsPositive() {
+				dues = append(dues, UpcomingDue{Account: *account, Statement: *statement})
+			}
+			statementDate = statementDateIn(account, statementDate.Year(), statementDate.Month()-1)
+		}
+	}
+
+	sort.Slice(dues, func(i, j int) bool {
+		return dues[i].Statement.DueDate.Before(dues[j].Statement.DueDate)
+	})
+
+	return dues, nil
+}
+
+// getCard 获取设置了账单日的信用卡账户
+func (s *statementService) getCard(scope Scope, accountID uint) (*model.Account, error) {
+	if accountID == 0 {
+		return nil, errors.New("无效的账户ID")
+	}
+
+	account, err := s.accountDao.GetAccountByID(accountID)
+	if err != nil || account == nil || !scope.owns(account.FamilyID) {
+		return nil, errors.New("账户不存在")
+	}
+	if account.Type != model.AccountCreditCard || account.StatementDay == 0 {
+		return nil, errors.New("账户不是设置了账单日的信用卡")
+	}
+
+	return account, nil
+}
+
+// buildStatement 统计某期账单，paid 为按账单日汇总的已还金额
+func (s *statementService) buildStatement(account *model.Account, statementDate time.Time, paid map[string]decimal.Decimal, now time.Time) (*CardStatement, error) {
+	previous := statementDateIn(account, statementDate.Year(), statementDate.Month()-1)
+	statement := &CardStatement{
+		AccountID:     account.ID,
+		StatementDate: statementDate,
+		PeriodStart:   previous.AddDate(0, 0, 1),
+		PeriodEnd:     statementDate.AddDate(0, 0, 1),
+		DueDate:       statementDueDate(account, statementDate),
+		Paid:          paid[statementDate.Format("2006-01-02")],
+	}
+	statement.Closed = !now.Before(statement.PeriodEnd)
+
+	amount, err := s.statementDao.GetStatementCharges(account.ID, statement.PeriodStart, statement.PeriodEnd)
+	if err != nil {
+		return nil, fmt.Errorf("统计账单金额失败: %v", err)
+	}
+	statement.Amount = amount
+	statement.Unpaid = decimal.Max(amount.Sub(statement.Paid), decimal.Zero)
+	statement.Overdue = statement.Closed && statement.Unpaid.IsPositive() && !now.Before(statement.DueDate.AddDate(0, 0, 1))
+
+	return statement, nil
+}
+
+// statementDateIn 返回信用卡在某月的账单日，账单日超过当月天数时取月末
+func statementDateIn(account *model.Account, year int, month time.Month) time.Time {
+	return clampedDate(year, month, account.StatementDay)
+}
+
+// nextStatementDate 返回包含 t 的账单周期的账单日
+func nextStatementDate(account *model.Account, t time.Time) time.Time {
+	statementDate := statementDateIn(account, t.Year(), t.Month())
+	if !t.Before(statementDate.AddDate(0, 0, 1)) {
+		statementDate = statementDateIn(account, t.Year(), t.Month()+1)
+	}
+	return statementDate
+}
+
+// statementDueDate 返回账单的还款日：还款日大于账单日时在当月，否则在次月
+func statementDueDate(account *model.Account, statementDate time.Time) time.Time {
+	month := statementDate.Month()
+	if account.DueDay <= account.StatementDay {
+		month++
+	}
+	return clampedDate(statementDate.Year(), month, account.DueDay)
+}
+
+// checkStatementDate 检查日期是否为信用卡的账单日
+func checkStatementDate(account *model.Account, statementDate time.Time) error {
+	if !statementDate.Equal(statementDateIn(account, statementDate.Year(), statementDate.Month())) {
+		return fmt.Errorf("%s 不是该信用卡的账单日", statementDate.Format("2006-01-02"))
+	}
+	return nil
+}
+
+// clampedDate 返回某月的第 day 天，超过当月天数时取月末
+func clampedDate(year int, month time.Month, day int) time.Time {
+	last := time.Date(year, month+1, 0, 0, 0, 0, 0, time.Local).Day()
+	if day > last {
+		day = last
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, time.Local)
+}
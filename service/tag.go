@@ -10,13 +10,13 @@ import (
 
 // TagService 标签服务接口
 type TagService interface {
-	CreateTag(tag *model.Tag) error
-	GetTagByID(id uint) (*model.Tag, error)
-	GetTagsByFamilyID(familyID uint) ([]model.Tag, error)
-	GetTagsByType(familyID uint, tagType string) ([]model.Tag, error)
-	GetAllTags() ([]model.Tag, error)
-	UpdateTag(tag *model.Tag) error
-	DeleteTag(id uint) error
+	CreateTag(scope Scope, tag *model.Tag) error
+	GetTagByID(scope Scope, id uint) (*model.Tag, error)
+	GetTagsByFamilyID(scope Scope, familyID uint) ([]model.Tag, error)
+	GetTagsByType(scope Scope, familyID uint, tagType string) ([]model.Tag, error)
+	GetAllTags(scope Scope) ([]model.Tag, error)
+	UpdateTag(scope Scope, tag *model.Tag) error
+	DeleteTag(scope Scope, id uint) error
 	TagExists(id uint) (bool, error)
 }
 
@@ -35,12 +35,17 @@ func NewTagService() TagService {
 }
 
 // CreateTag 创建标签
-func (s *tagService) CreateTag(tag *model.Tag) error {
+func (s *tagService) CreateTag(scope Scope, tag *model.Tag) error {
 	// 验证标签数据
 	if err := s.validateTag(tag); err != nil {
 		return err
 	}
 
+	// 只能为自己所属的家庭创建标签
+	if err := scope.checkFamily(tag.FamilyID); err != nil {
+		return err
+	}
+
 	// 检查家庭是否存在
 	familyExists, err := s.familyExists(tag.FamilyID)
 	if err != nil {
@@ -67,11 +72,13 @@ func (s *tagService) CreateTag(tag *model.Tag) error {
 		return fmt.Errorf("创建标签失败: %v", err)
 	}
 
+	recordAudit(scope, tag.FamilyID, EntityTag, tag.ID, model.AuditCreate, nil, tag)
+
 	return nil
 }
 
 // GetTagByID 根据ID获取标签
-func (s *tagService) GetTagByID(id uint) (*model.Tag, error) {
+func (s *tagService) GetTagByID(scope Scope, id uint) (*model.Tag, error) {
 	// 验证ID
 	if id == 0 {
 		return nil, errors.New("无效的标签ID")
@@ -83,7 +90,8 @@ func (s *tagService) GetTagByID(id uint) (*model.Tag, error) {
 		return nil, fmt.Errorf("获取标签失败: %v", err)
 	}
 
-	if tag == nil || !tag.IsActive {
+	// 其他家庭的标签视为不存在
+	if tag == nil || !tag.IsActive || !scope.owns(tag.FamilyID) {
 		return nil, errors.New("标签不存在或已被禁用")
 	}
 
@@ -91,12 +99,16 @@ func (s *tagService) GetTagByID(id uint) (*model.Tag, error) {
 }
 
 // GetTagsByFamilyID 根据家庭ID获取标签列表
-func (s *tagService) GetTagsByFamilyID(familyID uint) ([]model.Tag, error) {
+func (s *tagService) GetTagsByFamilyID(scope Scope, familyID uint) ([]model.Tag, error) {
 	// 验证家庭ID
 	if familyID == 0 {
 		return nil, errors.New("无效的家庭ID")
 	}
 
+	if err := scope.checkFamily(familyID); err != nil {
+		return nil, err
+	}
+
 	// 检查家庭是否存在
 	familyExists, err := s.familyExists(familyID)
 	if err != nil {
@@ -124,12 +136,16 @@ func (s *tagService) GetTagsByFamilyID(familyID uint) ([]model.Tag, error) {
 }
 
 // GetTagsByType 根据类型获取标签列表
-func (s *tagService) GetTagsByType(familyID uint, tagType string) ([]model.Tag, error) {
+func (s *tagService) GetTagsByType(scope Scope, familyID uint, tagType string) ([]model.Tag, error) {
 	// 验证家庭ID
 	if familyID == 0 {
 		return nil, errors.New("无效的家庭ID")
 	}
 
+	if err := scope.checkFamily(familyID); err != nil {
+		return nil, err
+	}
+
 	// 检查家庭是否存在
 	familyExists, err := s.familyExists(familyID)
 	if err != nil {
@@ -156,10 +172,10 @@ func (s *tagService) GetTagsByType(familyID uint, tagType string) ([]model.Tag,
 	return result, nil
 }
 
-// GetAllTags 获取所有标签
-func (s *tagService) GetAllTags() ([]model.Tag, error) {
+// GetAllTags 获取操作者所属家庭的所有标签
+func (s *tagService) GetAllTags(scope Scope) ([]model.Tag, error) {
 	// 获取所有标签
-	tags, err := s.tagDao.GetAllTags()
+	tags, err := s.tagDao.GetTagsByFamilyID(scope.FamilyID)
 	if err != nil {
 		return nil, fmt.Errorf("获取所有标签失败: %v", err)
 	}
@@ -176,7 +192,7 @@ func (s *tagService) GetAllTags() ([]model.Tag, error) {
 }
 
 // UpdateTag 更新标签信息
-func (s *tagService) UpdateTag(tag *model.Tag) error {
+func (s *tagService) UpdateTag(scope Scope, tag *model.Tag) error {
 	// 验证标签ID
 	if tag.ID == 0 {
 		return errors.New("无效的标签ID")
@@ -187,17 +203,19 @@ func (s *tagService) UpdateTag(tag *model.Tag) error {
 		return err
 	}
 
-	// 检查标签是否存在
-	exists, err := s.TagExists(tag.ID)
+	// 检查标签是否存在且属于操作者所属家庭
+	existing, err := s.GetTagByID(scope, tag.ID)
 	if err != nil {
-		return fmt.Errorf("检查标签是否存在时出错: %v", err)
-	}
-	if !exists {
-		return errors.New("标签不存在")
+		return err
 	}
 
+	// 所属家庭、启用状态和创建时间不允许通过更新修改
+	tag.FamilyID = existing.FamilyID
+	tag.IsActive = existing.IsActive
+	tag.CreatedAt = existing.CreatedAt
+
 	// 检查标签名称是否已被其他标签使用（同一家庭下）
-	exists, err = s.tagNameExists(tag.Name, tag.FamilyID, tag.ID)
+	exists, err := s.tagNameExists(tag.Name, tag.FamilyID, tag.ID)
 	if err != nil {
 		return fmt.Errorf("检查标签名称是否已存在时出错: %v", err)
 	}
@@ -210,23 +228,17 @@ func (s *tagService) UpdateTag(tag *model.Tag) error {
 		return fmt.Errorf("更新标签信息失败: %v", err)
 	}
 
+	recordAudit(scope, tag.FamilyID, EntityTag, tag.ID, model.AuditUpdate, existing, tag)
+
 	return nil
 }
 
 // DeleteTag 删除标签（软删除，设置IsActive为false）
-func (s *tagService) DeleteTag(id uint) error {
-	// 验证ID
-	if id == 0 {
-		return errors.New("无效的标签ID")
-	}
-
-	// 检查标签是否存在
-	exists, err := s.TagExists(id)
+func (s *tagService) DeleteTag(scope Scope, id uint) error {
+	// 检查标签是否存在且属于操作者所属家庭
+	existing, err := s.GetTagByID(scope, id)
 	if err != nil {
-		return fmt.Errorf("检查标签是否存在时出错: %v", err)
-	}
-	if !exists {
-		return errors.New("标签不存在")
+		return err
 	}
 
 	// 检查标签是否被交易使用
@@ -243,6 +255,8 @@ func (s *tagService) DeleteTag(id uint) error {
 		return fmt.Errorf("删除标签失败: %v", err)
 	}
 
+	recordAudit(scope, existing.FamilyID, EntityTag, id, model.AuditDelete, existing, nil)
+
 	return nil
 }
 
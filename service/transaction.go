@@ -5,21 +5,30 @@ import (
 	"errors"
 	"fmt"
 	"github.com/KQLXK/Family-Finance-System/model"
+	"github.com/shopspring/decimal"
+	"log"
+	"strings"
 	"time"
 )
 
 // TransactionService 交易服务接口
 type TransactionService interface {
-	CreateTransaction(transaction *model.Transaction) error
-	GetTransactionByID(id uint) (*model.Transaction, error)
-	GetTransactionsByFamilyID(familyID uint, page, pageSize int, filters map[string]interface{}) ([]model.Transaction, int64, error)
-	GetTransactionsByTimeRange(familyID uint, startTime, endTime time.Time, filters map[string]interface{}) ([]model.Transaction, error)
-	UpdateTransaction(transaction *model.Transaction) error
-	DeleteTransaction(id uint) error
-	AddTagToTransaction(transactionID, tagID uint) error
-	RemoveTagFromTransaction(transactionID, tagID uint) error
-	GetTransactionSummaryByCategory(familyID uint, startTime, endTime time.Time, transactionType model.TransactionType) (map[string]float64, error)
-	GetTransactionSummaryByTime(familyID uint, startTime, endTime time.Time, groupBy string) (map[string]float64, error)
+	CreateTransaction(scope Scope, transaction *model.Transaction) error
+	GetTransactionByID(scope Scope, id uint) (*model.Transaction, error)
+	GetTransactionsByFamilyID(scope Scope, familyID uint, page, pageSize int, filters map[string]interface{}) ([]model.Transaction, int64, error)
+	GetTransactionsByTimeRange(scope Scope, familyID uint, startTime, endTime time.Time, filters map[string]interface{}) ([]model.Transaction, error)
+	UpdateTransaction(scope Scope, transaction *model.Transaction) error
+	DeleteTransaction(scope Scope, id uint) error
+	AddTagToTransaction(scope Scope, transactionID, tagID uint) error
+	RemoveTagFromTransaction(scope Scope, transactionID, tagID uint) error
+	GetTransactionSummaryByCategory(scope Scope, familyID uint, startTime, endTime time.Time, transactionType model.TransactionType) (map[string]decimal.Decimal, error)
+	GetTransactionSummaryByTime(scope Scope, familyID uint, startTime, endTime time.Time, groupBy string) (map[string]decimal.Decimal, error)
+	GetRefunds(scope Scope, transactionID uint) ([]model.Transaction, error)
+	GetPendingTransactions(scope Scope, familyID uint) ([]model.Transaction, error)
+	GetPlannedTransactions(scope Scope, familyID uint, until time.Time) ([]model.Transaction, error)
+	PromotePlannedTransactions(now time.Time) error
+	ApproveTransaction(scope Scope, id uint, note string) error
+	RejectTransaction(scope Scope, id uint, note string) error
 }
 
 // transactionService 交易服务实现
@@ -29,6 +38,12 @@ type transactionService struct {
 	memberDao      model.MemberDao
 	categoryDao    model.CategoryDao
 	tagDao         model.TagDao
+	accountDao     model.AccountDao
+	sharedDao      model.SharedExpenseDao
+	limitDao       model.SpendingLimitDao
+	billDao        model.BillDao
+	debtDao        model.DebtDao
+	statementDao   model.StatementDao
 }
 
 // NewTransactionService 创建交易服务实例
@@ -39,16 +54,27 @@ func NewTransactionService() TransactionService {
 		memberDao:      *model.NewMemberDaoInstance(),
 		categoryDao:    *model.NewCategoryDaoInstance(),
 		tagDao:         *model.NewTagDaoInstance(),
+		accountDao:     *model.NewAccountDaoInstance(),
+		sharedDao:      *model.NewSharedExpenseDaoInstance(),
+		limitDao:       *model.NewSpendingLimitDaoInstance(),
+		billDao:        *model.NewBillDaoInstance(),
+		debtDao:        *model.NewDebtDaoInstance(),
+		statementDao:   *model.NewStatementDaoInstance(),
 	}
 }
 
 // CreateTransaction 创建交易
-func (s *transactionService) CreateTransaction(transaction *model.Transaction) error {
+func (s *transactionService) CreateTransaction(scope Scope, transaction *model.Transaction) error {
 	// 验证交易数据
 	if err := s.validateTransaction(transaction); err != nil {
 		return err
 	}
 
+	// 只能为自己所属的家庭记账
+	if err := scope.checkFamily(transaction.FamilyID); err != nil {
+		return err
+	}
+
 	// 检查家庭是否存在
 	familyExists, err := s.familyExists(transaction.FamilyID)
 	if err != nil {
@@ -67,17 +93,43 @@ func (s *transactionService) CreateTransaction(transaction *model.Transaction) e
 		return errors.New("成员不存在或不属于该家庭")
 	}
 
-	// 检查分类是否存在且类型匹配
-	categoryExists, err := s.categoryExistsAndMatchesType(transaction.CategoryID, transaction.Type)
+	// 退款必须关联原支出，累计退款不能超过原支出金额
+	if err := s.checkRefund(transaction); err != nil {
+		return err
+	}
+
+	// 检查分类是否存在且类型匹配，转账不需要分类，拆分交易逐行检查
+	if err := s.checkCategories(transaction); err != nil {
+		return err
+	}
+
+	// 检查资金账户并确定交易币种
+	account, err := s.checkAccounts(transaction, nil)
 	if err != nil {
-		return fmt.Errorf("检查分类是否存在时出错: %v", err)
+		return err
 	}
-	if !categoryExists {
-		return errors.New("分类不存在或类型不匹配")
+	if err := s.resolveCurrency(transaction, account); err != nil {
+		return err
+	}
+
+	// 超出成员消费限额时拒绝记账或转为审批
+	held, err := s.checkSpendingLimits(scope, transaction, 0)
+	if err != nil {
+		return err
 	}
 
-	// 设置默认状态
-	transaction.Status = model.Valid
+	// 超过家庭审批阈值或成员需要审批时，交易先进入待审批状态；交易时间在未来的记为计划交易
+	pending, err := s.requiresApproval(scope, transaction)
+	if err != nil {
+		return err
+	}
+	transaction.Status = settledStatus(transaction.TransactionTime)
+	if pending || held {
+		transaction.Status = model.Pending
+	}
+	transaction.ReviewedBy = nil
+	transaction.ReviewedAt = nil
+	transaction.ReviewNote = ""
 	transaction.CreatedAt = time.Now()
 	transaction.UpdatedAt = time.Now()
 
@@ -86,11 +138,13 @@ func (s *transactionService) CreateTransaction(transaction *model.Transaction) e
 		return fmt.Errorf("创建交易失败: %v", err)
 	}
 
+	recordAudit(scope, transaction.FamilyID, EntityTransaction, transaction.ID, model.AuditCreate, nil, transaction)
+
 	return nil
 }
 
 // GetTransactionByID 根据ID获取交易
-func (s *transactionService) GetTransactionByID(id uint) (*model.Transaction, error) {
+func (s *transactionService) GetTransactionByID(scope Scope, id uint) (*model.Transaction, error) {
 	// 验证ID
 	if id == 0 {
 		return nil, errors.New("无效的交易ID")
@@ -102,7 +156,8 @@ func (s *transactionService) GetTransactionByID(id uint) (*model.Transaction, er
 		return nil, fmt.Errorf("获取交易失败: %v", err)
 	}
 
-	if transaction == nil || transaction.Status == model.Deleted {
+	// 其他家庭的交易视为不存在
+	if transaction == nil || transaction.Status == model.Deleted || !scope.owns(transaction.FamilyID) {
 		return nil, errors.New("交易不存在或已被删除")
 	}
 
@@ -110,12 +165,16 @@ func (s *transactionService) GetTransactionByID(id uint) (*model.Transaction, er
 }
 
 // GetTransactionsByFamilyID 根据家庭ID获取交易列表
-func (s *transactionService) GetTransactionsByFamilyID(familyID uint, page, pageSize int, filters map[string]interface{}) ([]model.Transaction, int64, error) {
+func (s *transactionService) GetTransactionsByFamilyID(scope Scope, familyID uint, page, pageSize int, filters map[string]interface{}) ([]model.Transaction, int64, error) {
 	// 验证家庭ID
 	if familyID == 0 {
 		return nil, 0, errors.New("无效的家庭ID")
 	}
 
+	if err := scope.checkFamily(familyID); err != nil {
+		return nil, 0, err
+	}
+
 	// 检查家庭是否存在
 	familyExists, err := s.familyExists(familyID)
 	if err != nil {
@@ -146,12 +205,16 @@ func (s *transactionService) GetTransactionsByFamilyID(familyID uint, page, page
 }
 
 // GetTransactionsByTimeRange 根据时间范围获取交易列表
-func (s *transactionService) GetTransactionsByTimeRange(familyID uint, startTime, endTime time.Time, filters map[string]interface{}) ([]model.Transaction, error) {
+func (s *transactionService) GetTransactionsByTimeRange(scope Scope, familyID uint, startTime, endTime time.Time, filters map[string]interface{}) ([]model.Transaction, error) {
 	// 验证家庭ID
 	if familyID == 0 {
 		return nil, errors.New("无效的家庭ID")
 	}
 
+	if err := scope.checkFamily(familyID); err != nil {
+		return nil, err
+	}
+
 	// 检查家庭是否存在
 	familyExists, err := s.familyExists(familyID)
 	if err != nil {
@@ -182,7 +245,7 @@ func (s *transactionService) GetTransactionsByTimeRange(familyID uint, startTime
 }
 
 // UpdateTransaction 更新交易信息
-func (s *transactionService) UpdateTransaction(transaction *model.Transaction) error {
+func (s *transactionService) UpdateTransaction(scope Scope, transaction *model.Transaction) error {
 	// 验证交易ID
 	if transaction.ID == 0 {
 		return errors.New("无效的交易ID")
@@ -193,13 +256,57 @@ func (s *transactionService) UpdateTransaction(transaction *model.Transaction) e
 		return err
 	}
 
-	// 检查交易是否存在
-	existingTransaction, err := s.transactionDao.GetTransactionByID(transaction.ID)
+	// 检查交易是否存在且属于操作者所属家庭
+	existingTransaction, err := s.GetTransactionByID(scope, transaction.ID)
+	if err != nil {
+		return err
+	}
+
+	// 交易不能转移到其他家庭
+	transaction.FamilyID = existingTransaction.FamilyID
+
+	// 已设置分摊的交易，分摊金额依赖交易金额和类型
+	if !transaction.Amount.Equal(existingTransaction.Amount) || transaction.Type != existingTransaction.Type {
+		shared, err := s.sharedDao.GetSharedExpenseByTransactionID(transaction.ID)
+		if err != nil {
+			return fmt.Errorf("检查交易分摊时出错: %v", err)
+		}
+		if shared != nil {
+			return errors.New("交易已设置分摊，修改金额或类型前请先取消分摊")
+		}
+	}
+
+	// 已登记为借贷还款的交易，还款金额、方向、币种和还款时间依赖交易金额、类型、账户和交易时间
+	repayment, err := s.debtDao.GetRepaymentByTransactionID(transaction.ID)
+	if err != nil {
+		return fmt.Errorf("检查借贷还款时出错: %v", err)
+	}
+	if repayment != nil && (!transaction.Amount.Equal(existingTransaction.Amount) || transaction.Type != existingTransaction.Type ||
+		derefID(transaction.AccountID) != derefID(existingTransaction.AccountID) ||
+		!transaction.TransactionTime.Equal(existingTransaction.TransactionTime)) {
+		return errors.New("交易已登记为借贷还款，修改金额、类型、账户或交易时间前请先删除还款记录")
+	}
+
+	// 已登记为信用卡账单还款的转账，还款金额和所还账单依赖转账金额、转入账户和交易时间
+	payment, err := s.statementDao.GetStatementPaymentByTransactionID(transaction.ID)
 	if err != nil {
-		return fmt.Errorf("检查交易是否存在时出错: %v", err)
+		return fmt.Errorf("检查账单还款时出错: %v", err)
 	}
-	if existingTransaction == nil || existingTransaction.Status == model.Deleted {
-		return errors.New("交易不存在或已被删除")
+	if payment != nil && (!transaction.Amount.Equal(existingTransaction.Amount) || transaction.Type != existingTransaction.Type ||
+		derefID(transaction.ToAccountID) != derefID(existingTransaction.ToAccountID) ||
+		!transaction.TransactionTime.Equal(existingTransaction.TransactionTime)) {
+		return errors.New("交易已登记为信用卡账单还款，修改金额、类型、转入账户或交易时间前请先删除还款记录")
+	}
+
+	// 已有退款的支出，金额不能低于已退款金额，也不能修改类型
+	if existingTransaction.Type == model.Expense {
+		refunded, err := s.transactionDao.GetRefundedAmount(transaction.ID, 0)
+		if err != nil {
+			return fmt.Errorf("检查退款金额时出错: %v", err)
+		}
+		if refunded.IsPositive() && (transaction.Type != model.Expense || transaction.Amount.LessThan(refunded)) {
+			return errors.New("交易已有退款，不能修改类型，金额不能低于已退款金额")
+		}
 	}
 
 	// 检查家庭是否存在
@@ -220,38 +327,95 @@ func (s *transactionService) UpdateTransaction(transaction *model.Transaction) e
 		return errors.New("成员不存在或不属于该家庭")
 	}
 
-	// 检查分类是否存在且类型匹配
-	categoryExists, err := s.categoryExistsAndMatchesType(transaction.CategoryID, transaction.Type)
+	// 退款必须关联原支出，累计退款不能超过原支出金额
+	if err := s.checkRefund(transaction); err != nil {
+		return err
+	}
+
+	// 检查分类是否存在且类型匹配，转账不需要分类，拆分交易逐行检查
+	if err := s.checkCategories(transaction); err != nil {
+		return err
+	}
+
+	// 检查资金账户并确定交易币种，已归档账户上的原有交易仍可修改
+	account, err := s.checkAccounts(transaction, existingTransaction)
 	if err != nil {
-		return fmt.Errorf("检查分类是否存在时出错: %v", err)
+		return err
 	}
-	if !categoryExists {
-		return errors.New("分类不存在或类型不匹配")
+	if err := s.resolveCurrency(transaction, account); err != nil {
+		return err
+	}
+
+	// 管理员修改保持原状态；其他成员修改待审批或已拒绝的交易需重新审批，修改正常交易时重新检查审批规则。
+	// 正常交易和计划交易按修改后的交易时间互相转换
+	transaction.Status = existingTransaction.Status
+	if transaction.Status == model.Valid || transaction.Status == model.Planned {
+		transaction.Status = settledStatus(transaction.TransactionTime)
+	}
+	transaction.ReviewedBy = existingTransaction.ReviewedBy
+	transaction.ReviewedAt = existingTransaction.ReviewedAt
+	transaction.ReviewNote = existingTransaction.ReviewNote
+	if scope.Role != model.RoleAdmin {
+		pending, err := s.checkSpendingLimits(scope, transaction, existingTransaction.ID)
+		if err != nil {
+			return err
+		}
+		pending = pending || existingTransaction.Status == model.Pending || existingTransaction.Status == model.Rejected
+		if !pending {
+			if pending, err = s.requiresApproval(scope, transaction); err != nil {
+				return err
+			}
+		}
+		if pending {
+			transaction.Status = model.Pending
+		}
 	}
 
 	// 更新交易信息
+	transaction.CreatedAt = existingTransaction.CreatedAt
 	transaction.UpdatedAt = time.Now()
 	if err := s.transactionDao.UpdateTransaction(transaction); err != nil {
 		return fmt.Errorf("更新交易信息失败: %v", err)
 	}
 
+	recordAudit(scope, transaction.FamilyID, EntityTransaction, transaction.ID, model.AuditUpdate, existingTransaction, transaction)
+
+	// 还款交易的状态变化后重新判断借贷是否还清
+	if repayment != nil {
+		if err := refreshDebtClosed(s.debtDao, repayment.DebtID); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
 // DeleteTransaction 删除交易（软删除）
-func (s *transactionService) DeleteTransaction(id uint) error {
-	// 验证ID
-	if id == 0 {
-		return errors.New("无效的交易ID")
+func (s *transactionService) DeleteTransaction(scope Scope, id uint) error {
+	// 检查交易是否存在且属于操作者所属家庭
+	existing, err := s.GetTransactionByID(scope, id)
+	if err != nil {
+		return err
 	}
 
-	// 检查交易是否存在
-	transaction, err := s.transactionDao.GetTransactionByID(id)
+	// 有退款的支出需先删除退款
+	if existing.Type == model.Expense {
+		refunded, err := s.transactionDao.GetRefundedAmount(id, 0)
+		if err != nil {
+			return fmt.Errorf("检查退款金额时出错: %v", err)
+		}
+		if refunded.IsPositive() {
+			return errors.New("交易已有退款，请先删除退款")
+		}
+	}
+
+	// 已用于支付账单的交易需先撤销账单付款
+	bill, err := s.billDao.GetBillByTransactionID(id)
 	if err != nil {
-		return fmt.Errorf("检查交易是否存在时出错: %v", err)
+		return fmt.Errorf("检查账单付款时出错: %v", err)
 	}
-	if transaction == nil || transaction.Status == model.Deleted {
-		return errors.New("交易不存在或已被删除")
+	if bill != nil {
+		return errors.New("交易已用于支付账单，请先撤销账单付款")
 	}
 
 	// 软删除交易（设置状态为deleted）
@@ -259,23 +423,33 @@ func (s *transactionService) DeleteTransaction(id uint) error {
 		return fmt.Errorf("删除交易失败: %v", err)
 	}
 
+	recordAudit(scope, existing.FamilyID, EntityTransaction, id, model.AuditDelete, existing, nil)
+
+	// 还款交易删除后不再计入已还金额，重新判断借贷是否还清
+	repayment, err := s.debtDao.GetRepaymentByTransactionID(id)
+	if err != nil {
+		return fmt.Errorf("检查借贷还款时出错: %v", err)
+	}
+	if repayment != nil {
+		if err := refreshDebtClosed(s.debtDao, repayment.DebtID); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
 // AddTagToTransaction 为交易添加标签
-func (s *transactionService) AddTagToTransaction(transactionID, tagID uint) error {
+func (s *transactionService) AddTagToTransaction(scope Scope, transactionID, tagID uint) error {
 	// 验证ID
 	if transactionID == 0 || tagID == 0 {
 		return errors.New("无效的交易ID或标签ID")
 	}
 
-	// 检查交易是否存在
-	transaction, err := s.transactionDao.GetTransactionByID(transactionID)
+	// 检查交易是否存在且属于操作者所属家庭
+	transaction, err := s.GetTransactionByID(scope, transactionID)
 	if err != nil {
-		return fmt.Errorf("检查交易是否存在时出错: %v", err)
-	}
-	if transaction == nil || transaction.Status == model.Deleted {
-		return errors.New("交易不存在或已被删除")
+		return err
 	}
 
 	// 检查标签是否存在
@@ -306,23 +480,24 @@ func (s *transactionService) AddTagToTransaction(transactionID, tagID uint) erro
 		return fmt.Errorf("添加标签到交易失败: %v", err)
 	}
 
+	tagIDs := labelIDs(transaction)
+	recordAudit(scope, transaction.FamilyID, EntityTransaction, transactionID, model.AuditUpdate,
+		map[string][]uint{"tag_ids": tagIDs}, map[string][]uint{"tag_ids": append(tagIDs, tagID)})
+
 	return nil
 }
 
 // RemoveTagFromTransaction 从交易移除标签
-func (s *transactionService) RemoveTagFromTransaction(transactionID, tagID uint) error {
+func (s *transactionService) RemoveTagFromTransaction(scope Scope, transactionID, tagID uint) error {
 	// 验证ID
 	if transactionID == 0 || tagID == 0 {
 		return errors.New("无效的交易ID或标签ID")
 	}
 
-	// 检查交易是否存在
-	transaction, err := s.transactionDao.GetTransactionByID(transactionID)
+	// 检查交易是否存在且属于操作者所属家庭
+	transaction, err := s.GetTransactionByID(scope, transactionID)
 	if err != nil {
-		return fmt.Errorf("检查交易是否存在时出错: %v", err)
-	}
-	if transaction == nil || transaction.Status == model.Deleted {
-		return errors.New("交易不存在或已被删除")
+		return err
 	}
 
 	// 检查标签是否存在于交易
@@ -339,47 +514,67 @@ func (s *transactionService) RemoveTagFromTransaction(transactionID, tagID uint)
 		return fmt.Errorf("从交易移除标签失败: %v", err)
 	}
 
+	tagIDs := labelIDs(transaction)
+	remaining := make([]uint, 0, len(tagIDs))
+	for _, id := range tagIDs {
+		if id != tagID {
+			remaining = append(remaining, id)
+		}
+	}
+	recordAudit(scope, transaction.FamilyID, EntityTransaction, transactionID, model.AuditUpdate,
+		map[string][]uint{"tag_ids": tagIDs}, map[string][]uint{"tag_ids": remaining})
+
 	return nil
 }
 
-// GetTransactionSummaryByCategory 按分类统计交易金额
-func (s *transactionService) GetTransactionSummaryByCategory(familyID uint, startTime, endTime time.Time, transactionType model.TransactionType) (map[string]float64, error) {
+// GetTransactionSummaryByCategory 按分类统计交易金额，只统计有效交易，待审批的交易通过后才计入
+func (s *transactionService) GetTransactionSummaryByCategory(scope Scope, familyID uint, startTime, endTime time.Time, transactionType model.TransactionType) (map[string]decimal.Decimal, error) {
 	// 验证家庭ID
 	if familyID == 0 {
 		return nil, errors.New("无效的家庭ID")
 	}
 
-	// 检查家庭是否存在
-	familyExists, err := s.familyExists(familyID)
-	if err != nil {
-		return nil, fmt.Errorf("检查家庭是否存在时出错: %v", err)
+	if err := scope.checkFamily(familyID); err != nil {
+		return nil, err
 	}
-	if !familyExists {
+
+	// 检查家庭是否存在
+	family, err := s.familyDao.GetFamilyByID(familyID)
+	if err != nil || family == nil {
 		return nil, errors.New("家庭不存在")
 	}
 
-	// 获取分类统计
-	summary, err := s.transactionDao.GetTransactionSummaryByCategory(familyID, startTime, endTime, transactionType)
+	// 转账没有分类，不参与分类统计；退款在支出统计中冲减原分类
+	if transactionType == model.Transfer {
+		return nil, errors.New("转账不参与分类统计")
+	}
+	if transactionType == model.Refund {
+		return nil, errors.New("退款计入支出分类统计，不单独统计")
+	}
+
+	// 获取分类统计，按交易日期的汇率换算为本位币
+	rows, err := s.transactionDao.GetTransactionSummaryByCategory(familyID, startTime, endTime, transactionType)
 	if err != nil {
 		return nil, fmt.Errorf("获取分类统计失败: %v", err)
 	}
 
-	return summary, nil
+	return newCurrencyConverter(family).sum(rows)
 }
 
-// GetTransactionSummaryByTime 按时间统计交易金额
-func (s *transactionService) GetTransactionSummaryByTime(familyID uint, startTime, endTime time.Time, groupBy string) (map[string]float64, error) {
+// GetTransactionSummaryByTime 按时间统计交易金额，只统计有效交易，待审批的交易通过后才计入，转账不计入
+func (s *transactionService) GetTransactionSummaryByTime(scope Scope, familyID uint, startTime, endTime time.Time, groupBy string) (map[string]decimal.Decimal, error) {
 	// 验证家庭ID
 	if familyID == 0 {
 		return nil, errors.New("无效的家庭ID")
 	}
 
-	// 检查家庭是否存在
-	familyExists, err := s.familyExists(familyID)
-	if err != nil {
-		return nil, fmt.Errorf("检查家庭是否存在时出错: %v", err)
+	if err := scope.checkFamily(familyID); err != nil {
+		return nil, err
 	}
-	if !familyExists {
+
+	// 检查家庭是否存在
+	family, err := s.familyDao.GetFamilyByID(familyID)
+	if err != nil || family == nil {
 		return nil, errors.New("家庭不存在")
 	}
 
@@ -388,35 +583,307 @@ func (s *transactionService) GetTransactionSummaryByTime(familyID uint, startTim
 		return nil, errors.New("无效的分组方式，支持: day, month, year")
 	}
 
-	// 获取时间统计
-	summary, err := s.transactionDao.GetTransactionSummaryByTime(familyID, startTime, endTime, groupBy)
+	// 获取时间统计，按交易日期的汇率换算为本位币
+	rows, err := s.transactionDao.GetTransactionSummaryByTime(familyID, startTime, endTime, groupBy)
 	if err != nil {
 		return nil, fmt.Errorf("获取时间统计失败: %v", err)
 	}
 
-	return summary, nil
+	return newCurrencyConverter(family).sum(rows)
+}
+
+// GetRefunds 获取支出交易的退款记录
+func (s *transactionService) GetRefunds(scope Scope, transactionID uint) ([]model.Transaction, error) {
+	transaction, err := s.GetTransactionByID(scope, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	if transaction.Type != model.Expense {
+		return nil, errors.New("只有支出交易可以退款")
+	}
+
+	refunds, err := s.transactionDao.GetRefundsByTransactionID(transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("获取退款记录失败: %v", err)
+	}
+
+	return refunds, nil
+}
+
+// GetPendingTransactions 获取家庭待审批的交易
+func (s *transactionService) GetPendingTransactions(scope Scope, familyID uint) ([]model.Transaction, error) {
+	if familyID == 0 {
+		return nil, errors.New("无效的家庭ID")
+	}
+
+	if err := scope.checkFamily(familyID); err != nil {
+		return nil, err
+	}
+
+	transactions, err := s.transactionDao.GetTransactionsByStatus(familyID, model.Pending)
+	if err != nil {
+		return nil, fmt.Errorf("获取待审批交易失败: %v", err)
+	}
+
+	return transactions, nil
+}
+
+// ApproveTransaction 审批通过交易，通过后计入统计；交易时间在未来的转为计划交易
+func (s *transactionService) ApproveTransaction(scope Scope, id uint, note string) error {
+	return s.reviewTransaction(scope, id, model.Valid, note)
+}
+
+// RejectTransaction 拒绝交易
+func (s *transactionService) RejectTransaction(scope Scope, id uint, note string) error {
+	return s.reviewTransaction(scope, id, model.Rejected, note)
+}
+
+// reviewTransaction 记录审批结果
+func (s *transactionService) reviewTransaction(scope Scope, id uint, status model.TransactionStatus, note string) error {
+	if len(note) > 500 {
+		return errors.New("审批备注长度不能超过500个字符")
+	}
+
+	existing, err := s.GetTransactionByID(scope, id)
+	if err != nil {
+		return err
+	}
+	if existing.Status != model.Pending {
+		return errors.New("交易不是待审批状态")
+	}
+	if status == model.Valid {
+		status = settledStatus(existing.TransactionTime)
+	}
+
+	ok, err := s.transactionDao.ReviewTransaction(id, status, scope.MemberID, note)
+	if err != nil {
+		return fmt.Errorf("审批交易失败: %v", err)
+	}
+	if !ok {
+		return errors.New("交易不是待审批状态")
+	}
+
+	recordAudit(scope, existing.FamilyID, EntityTransaction, id, model.AuditUpdate,
+		map[string]interface{}{"status": existing.Status},
+		map[string]interface{}{"status": status, "review_note": note})
+
+	// 还款交易审批后计入或不计入已还金额，重新判断借贷是否还清
+	repayment, err := s.debtDao.GetRepaymentByTransactionID(id)
+	if err != nil {
+		return fmt.Errorf("检查借贷还款时出错: %v", err)
+	}
+	if repayment != nil {
+		if err := refreshDebtClosed(s.debtDao, repayment.DebtID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetPlannedTransactions 获取家庭交易时间不晚于 until 的计划交易
+func (s *transactionService) GetPlannedTransactions(scope Scope, familyID uint, until time.Time) ([]model.Transaction, error) {
+	if familyID == 0 {
+		return nil, errors.New("无效的家庭ID")
+	}
+
+	if err := scope.checkFamily(familyID); err != nil {
+		return nil, err
+	}
+
+	transactions, err := s.transactionDao.GetPlannedTransactions(familyID, until)
+	if err != nil {
+		return nil, fmt.Errorf("获取计划交易失败: %v", err)
+	}
+
+	return transactions, nil
+}
+
+// PromotePlannedTransactions 将交易时间已到的计划交易转为正常交易，供定时任务调用
+func (s *transactionService) PromotePlannedTransactions(now time.Time) error {
+	count, err := s.transactionDao.PromotePlannedTransactions(now)
+	if err != nil {
+		return fmt.Errorf("转换到期计划交易失败: %v", err)
+	}
+	if count > 0 {
+		log.Printf("已将%d笔到期计划交易转为正常交易", count)
+	}
+	return nil
+}
+
+// settledStatus 返回审批通过后的交易状态，交易时间在未来的为计划交易
+func settledStatus(transactionTime time.Time) model.TransactionStatus {
+	if transactionTime.After(time.Now()) {
+		return model.Planned
+	}
+	return model.Valid
+}
+
+// requiresApproval 判断交易是否需要管理员审批，管理员记录的交易无需审批
+func (s *transactionService) requiresApproval(scope Scope, transaction *model.Transaction) (bool, error) {
+	if scope.Role == model.RoleAdmin {
+		return false, nil
+	}
+
+	member, err := s.memberDao.GetMemberByID(transaction.MemberID)
+	if err != nil {
+		return false, fmt.Errorf("检查成员审批设置时出错: %v", err)
+	}
+	if member != nil && member.RequireApproval {
+		return true, nil
+	}
+
+	family, err := s.familyDao.GetFamilyByID(transaction.FamilyID)
+	if err != nil {
+		return false, fmt.Errorf("检查家庭审批阈值时出错: %v", err)
+	}
+	if family == nil || !family.ApprovalThreshold.IsPositive() {
+		return false, nil
+	}
+
+	// 审批阈值以本位币计，缺少汇率无法换算时交由管理员审批
+	amount, err := newCurrencyConverter(family).convert(transaction.Amount, transaction.Currency, transaction.TransactionTime)
+	if err != nil {
+		return true, nil
+	}
+	return amount.GreaterThan(family.ApprovalThreshold), nil
+}
+
+// checkSpendingLimits 检查支出是否超出记账成员的消费限额，超出拒绝记账的限额时返回错误，
+// 超出需审批的限额时返回 true；管理员记录的交易不受限额约束
+func (s *transactionService) checkSpendingLimits(scope Scope, transaction *model.Transaction, excludeID uint) (bool, error) {
+	if scope.Role == model.RoleAdmin || transaction.Type != model.Expense {
+		return false, nil
+	}
+
+	limits, err := s.limitDao.GetLimitsByMemberID(transaction.MemberID)
+	if err != nil {
+		return false, fmt.Errorf("检查消费限额时出错: %v", err)
+	}
+	if len(limits) == 0 {
+		return false, nil
+	}
+
+	family, err := s.familyDao.GetFamilyByID(transaction.FamilyID)
+	if err != nil || family == nil {
+		return false, errors.New("关联的家庭不存在")
+	}
+	converter := newCurrencyConverter(family)
+
+	// 交易各分类的金额，拆分交易按明细
+	amounts := make(map[uint]decimal.Decimal)
+	if len(transaction.Splits) > 0 {
+		for _, split := range transaction.Splits {
+			amounts[split.CategoryID] = amounts[split.CategoryID].Add(split.Amount)
+		}
+	} else {
+		amounts[derefID(transaction.CategoryID)] = transaction.Amount
+	}
+	categories := make(map[uint]*model.Category, len(amounts))
+	for id := range amounts {
+		category, err := s.categoryDao.GetCategoryByID(id)
+		if err != nil || category == nil {
+			return false, errors.New("分类不存在")
+		}
+		categories[id] = category
+	}
+
+	held := false
+	for i := range limits {
+		limit := &limits[i]
+
+		amount := decimal.Zero
+		for id, categoryAmount := range amounts {
+			if limit.Category == nil || inCategoryTree(categories[id], limit.Category) {
+				amount = amount.Add(categoryAmount)
+			}
+		}
+		if limit.CategoryID != nil && amount.IsZero() {
+			continue
+		}
+
+		amount, err = converter.convert(amount, transaction.Currency, transaction.TransactionTime)
+		if err != nil {
+			return false, err
+		}
+		start, end := periodRange(limit.Period, transaction.TransactionTime)
+		spent, err := limitSpending(s.limitDao, converter, limit, start, end, excludeID)
+		if err != nil {
+			return false, err
+		}
+		if !spent.Add(amount).GreaterThan(limit.Amount) {
+			continue
+		}
+
+		if limit.Action == model.LimitReject {
+			return false, fmt.Errorf("超出消费限额：本期限额 %s %s，已支出 %s，剩余 %s",
+				limit.Amount.StringFixed(2), converter.baseCurrency, spent.StringFixed(2),
+				decimal.Max(limit.Amount.Sub(spent), decimal.Zero).StringFixed(2))
+		}
+		held = true
+	}
+
+	return held, nil
 }
 
 // validateTransaction 验证交易数据
 func (s *transactionService) validateTransaction(transaction *model.Transaction) error {
 	// 验证金额
-	if transaction.Amount <= 0 {
+	if !transaction.Amount.IsPositive() {
 		return errors.New("交易金额必须大于0")
 	}
+	if !isCents(transaction.Amount) {
+		return errors.New("交易金额最多保留两位小数")
+	}
 
 	// 验证类型
 	if !s.isValidTransactionType(transaction.Type) {
 		return errors.New("无效的交易类型")
 	}
 
+	// 转账需要转出和转入账户，不需要分类；手续费只适用于转账
+	if transaction.Type == model.Transfer {
+		if transaction.AccountID == nil || transaction.ToAccountID == nil {
+			return errors.New("转账需要指定转出账户和转入账户")
+		}
+		if *transaction.AccountID == *transaction.ToAccountID {
+			return errors.New("转出账户和转入账户不能相同")
+		}
+		if transaction.Fee.IsNegative() {
+			return errors.New("手续费不能为负数")
+		}
+		if !isCents(transaction.Fee) {
+			return errors.New("手续费最多保留两位小数")
+		}
+		transaction.CategoryID = nil
+		transaction.Category = nil
+	} else if transaction.ToAccountID != nil || !transaction.Fee.IsZero() {
+		return errors.New("只有转账可以指定转入账户和手续费")
+	}
+
+	// 退款必须关联原支出，其他交易不能关联
+	if transaction.Type == model.Refund {
+		if transaction.RefundOfID == nil || *transaction.RefundOfID == 0 {
+			return errors.New("退款需要指定原支出交易")
+		}
+		if *transaction.RefundOfID == transaction.ID {
+			return errors.New("退款不能关联自身")
+		}
+	} else if transaction.RefundOfID != nil {
+		return errors.New("只有退款可以关联原支出交易")
+	}
+
 	// 验证交易时间
 	if transaction.TransactionTime.IsZero() {
 		return errors.New("交易时间不能为空")
 	}
 
-	// 验证交易时间不能晚于当前时间
-	if transaction.TransactionTime.After(time.Now()) {
-		return errors.New("交易时间不能晚于当前时间")
+	// 交易时间在未来的作为计划交易，最多提前两年；退款必须已经发生
+	if transaction.TransactionTime.After(time.Now().AddDate(2, 0, 0)) {
+		return errors.New("计划交易的时间不能晚于两年后")
+	}
+	if transaction.Type == model.Refund && transaction.TransactionTime.After(time.Now()) {
+		return errors.New("退款时间不能晚于当前时间")
 	}
 
 	// 验证备注长度
@@ -486,12 +953,215 @@ func (s *transactionService) categoryExistsAndMatchesType(categoryID uint, trans
 	return category.Type == expectedCategoryType, nil
 }
 
+// checkCategories 检查交易分类。拆分交易的每行明细分类都必须与交易类型匹配，
+// 明细金额之和必须等于交易金额；未指定主分类时使用第一行明细的分类
+func (s *transactionService) checkCategories(transaction *model.Transaction) error {
+	if transaction.Type == model.Transfer {
+		if len(transaction.Splits) > 0 {
+			return errors.New("转账不能拆分")
+		}
+		return nil
+	}
+
+	if len(transaction.Splits) > 0 {
+		if len(transaction.Splits) > 50 {
+			return errors.New("拆分明细不能超过50行")
+		}
+
+		total := decimal.Zero
+		for i := range transaction.Splits {
+			split := &transaction.Splits[i]
+			if !split.Amount.IsPositive() || !isCents(split.Amount) {
+				return fmt.Errorf("第%d行拆分金额必须大于0且最多保留两位小数", i+1)
+			}
+			if len(split.Note) > 500 {
+				return fmt.Errorf("第%d行拆分备注长度不能超过500个字符", i+1)
+			}
+
+			categoryExists, err := s.categoryExistsAndMatchesType(split.CategoryID, transaction.Type)
+			if err != nil {
+				return fmt.Errorf("检查分类是否存在时出错: %v", err)
+			}
+			if !categoryExists {
+				return fmt.Errorf("第%d行拆分的分类不存在或类型不匹配", i+1)
+			}
+
+			split.Category = nil
+			total = total.Add(split.Amount)
+		}
+		if !total.Equal(transaction.Amount) {
+			return errors.New("拆分金额之和必须等于交易金额")
+		}
+
+		if transaction.CategoryID == nil {
+			categoryID := transaction.Splits[0].CategoryID
+			transaction.CategoryID = &categoryID
+		}
+	}
+
+	categoryExists, err := s.categoryExistsAndMatchesType(derefID(transaction.CategoryID), transaction.Type)
+	if err != nil {
+		return fmt.Errorf("检查分类是否存在时出错: %v", err)
+	}
+	if !categoryExists {
+		return errors.New("分类不存在或类型不匹配")
+	}
+	transaction.Category = nil
+
+	return nil
+}
+
+// checkRefund 检查退款关联的原支出：必须是同一家庭的有效支出，退款不早于原支出，
+// 币种与原支出一致，分类为原支出的分类之一（默认为原支出主分类），累计退款不超过原支出金额
+func (s *transactionService) checkRefund(transaction *model.Transaction) error {
+	if transaction.Type != model.Refund {
+		return nil
+	}
+
+	original, err := s.transactionDao.GetTransactionByID(*transaction.RefundOfID)
+	if err != nil || original == nil || original.FamilyID != transaction.FamilyID {
+		return errors.New("原支出交易不存在")
+	}
+	if original.Type != model.Expense || original.Status != model.Valid {
+		return errors.New("只能为有效的支出交易退款")
+	}
+	if transaction.TransactionTime.Before(original.TransactionTime) {
+		return errors.New("退款时间不能早于原支出时间")
+	}
+
+	currency := strings.ToUpper(strings.TrimSpace(transaction.Currency))
+	if currency != "" && currency != original.Currency {
+		return errors.New("退款币种必须与原支出一致")
+	}
+	transaction.Currency = original.Currency
+
+	// 退款不拆分，计入原支出的某个分类
+	if len(transaction.Splits) > 0 {
+		return errors.New("退款不能拆分")
+	}
+	if transaction.CategoryID == nil {
+		transaction.CategoryID = original.CategoryID
+	}
+	matched := derefID(transaction.CategoryID) == derefID(original.CategoryID)
+	for _, split := range original.Splits {
+		if split.CategoryID == derefID(transaction.CategoryID) {
+			matched = true
+		}
+	}
+	if !matched {
+		return errors.New("退款分类必须是原支出的分类")
+	}
+
+	refunded, err := s.transactionDao.GetRefundedAmount(original.ID, transaction.ID)
+	if err != nil {
+		return fmt.Errorf("检查退款金额时出错: %v", err)
+	}
+	if refunded.Add(transaction.Amount).GreaterThan(original.Amount) {
+		return fmt.Errorf("累计退款金额不能超过原支出金额，剩余可退 %s", original.Amount.Sub(refunded).StringFixed(2))
+	}
+
+	return nil
+}
+
+// checkAccounts 检查交易关联的账户，转账的转出和转入账户币种必须相同。existing 为修改前的交易，
+// 返回交易的（转出）账户，未关联账户时返回 nil
+func (s *transactionService) checkAccounts(transaction *model.Transaction, existing *model.Transaction) (*model.Account, error) {
+	var previousFrom, previousTo *uint
+	if existing != nil {
+		previousFrom, previousTo = existing.AccountID, existing.ToAccountID
+	}
+
+	from, err := s.checkAccount(transaction.AccountID, transaction.FamilyID, previousFrom)
+	if err != nil {
+		return nil, err
+	}
+	if transaction.Type != model.Transfer {
+		return from, nil
+	}
+
+	to, err := s.checkAccount(transaction.ToAccountID, transaction.FamilyID, previousTo)
+	if err != nil {
+		return nil, err
+	}
+	if from.Currency != to.Currency {
+		return nil, errors.New("转出账户和转入账户的币种必须相同")
+	}
+
+	return from, nil
+}
+
+// resolveCurrency 确定交易币种：关联账户时必须与账户币种一致，未指定时使用账户币种或家庭本位币
+func (s *transactionService) resolveCurrency(transaction *model.Transaction, account *model.Account) error {
+	currency := strings.ToUpper(strings.TrimSpace(transaction.Currency))
+	if account != nil {
+		if currency == "" {
+			currency = account.Currency
+		} else if currency != account.Currency {
+			return errors.New("交易币种必须与账户币种一致")
+		}
+	} else if currency == "" {
+		family, err := s.familyDao.GetFamilyByID(transaction.FamilyID)
+		if err != nil || family == nil {
+			return errors.New("关联的家庭不存在")
+		}
+		currency = family.BaseCurrency
+		if currency == "" {
+			currency = defaultCurrency
+		}
+	}
+
+	if !currencyPattern.MatchString(currency) {
+		return errors.New("无效的货币代码")
+	}
+	transaction.Currency = currency
+	return nil
+}
+
+// checkAccount 检查账户属于该家庭且未归档，previous 为交易原来关联的账户
+func (s *transactionService) checkAccount(accountID *uint, familyID uint, previous *uint) (*model.Account, error) {
+	if accountID == nil {
+		return nil, nil
+	}
+
+	account, err := s.accountDao.GetAccountByID(*accountID)
+	if err != nil || account == nil || account.FamilyID != familyID {
+		return nil, errors.New("账户不存在或不属于该家庭")
+	}
+	if account.Archived && (previous == nil || *previous != account.ID) {
+		return nil, errors.New("账户已归档")
+	}
+
+	return account, nil
+}
+
 // isValidTransactionType 验证交易类型是否有效
 func (s *transactionService) isValidTransactionType(transactionType model.TransactionType) bool {
 	switch transactionType {
-	case model.Income, model.Expense:
+	case model.Income, model.Expense, model.Transfer, model.Refund:
 		return true
 	default:
 		return false
 	}
 }
+
+// labelIDs 获取交易当前的标签ID列表
+func labelIDs(transaction *model.Transaction) []uint {
+	ids := make([]uint, 0, len(transaction.Labels))
+	for _, label := range transaction.Labels {
+		ids = append(ids, label.ID)
+	}
+	return ids
+}
+
+// derefID 返回可选ID的值，为空时返回 0
+func derefID(id *uint) uint {
+	if id == nil {
+		return 0
+	}
+	return *id
+}
+
+// isCents 判断金额是否最多保留两位小数，与数据库 DECIMAL(12,2) 一致
+func isCents(amount decimal.Decimal) bool {
+	return amount.Equal(amount.Round(2))
+}